@@ -0,0 +1,186 @@
+// Package audit implements challenge-response audit of stored objects.
+//
+// The verifier knows the object size and hash only. It splits the object into
+// ranges at random points and asks the storage node for hashes of these ranges.
+// Hashes of the consecutive ranges combined must be equal to the object hash,
+// so the response can be checked without the data.
+//
+// A node which knows the object hash can still forge a consistent response
+// without the data, so this check must be combined with comparing responses
+// of independent nodes storing the same object to the same challenge (see
+// Agree), which is what audit in NeoFS-like systems does.
+package audit
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// ErrMismatch is returned when response hashes don't combine into
+// the object hash.
+var ErrMismatch = errors.New("range hashes don't match the object hash")
+
+// Range is a range of the object.
+type Range struct {
+	Offset int64
+	Length int64
+}
+
+// Challenge is a set of consecutive ranges covering the whole object.
+type Challenge struct {
+	Size   int64
+	Ranges []Range
+}
+
+// Response contains hashes of challenge ranges in the same order.
+type Response struct {
+	Hashes []tz.Hash
+}
+
+// RangeHashFunc returns the hash of length bytes of the object starting at off.
+type RangeHashFunc func(off, length int64) (tz.Hash, error)
+
+// NewChallenge returns challenge splitting an object of the specified size
+// into n non-empty ranges at random points read from rnd. crypto/rand
+// is used if rnd is nil. The number of ranges is limited by the size,
+// an empty object is covered by a single empty range.
+func NewChallenge(size int64, n int, rnd io.Reader) (*Challenge, error) {
+	if size < 0 || n <= 0 {
+		return nil, errors.New("size must be non-negative and number of ranges must be positive")
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	if int64(n) > size {
+		n = int(size)
+		if n == 0 {
+			n = 1
+		}
+	}
+
+	// Cut points are distinct offsets in (0, size).
+	cuts := make(map[int64]struct{}, n-1)
+	limit := big.NewInt(size - 1)
+	for len(cuts) < n-1 {
+		v, err := rand.Int(rnd, limit)
+		if err != nil {
+			return nil, fmt.Errorf("can't generate challenge: %w", err)
+		}
+		cuts[v.Int64()+1] = struct{}{}
+	}
+
+	offs := make([]int64, 0, n+1)
+	offs = append(offs, 0)
+	for off := range cuts {
+		offs = append(offs, off)
+	}
+	offs = append(offs, size)
+	sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+
+	c := &Challenge{Size: size, Ranges: make([]Range, n)}
+	for i := range c.Ranges {
+		c.Ranges[i] = Range{Offset: offs[i], Length: offs[i+1] - offs[i]}
+	}
+	return c, nil
+}
+
+// Validate checks that challenge ranges are consecutive and cover
+// the whole object.
+func (c *Challenge) Validate() error {
+	if len(c.Ranges) == 0 {
+		return errors.New("no ranges")
+	}
+	var off int64
+	for i, r := range c.Ranges {
+		if r.Offset != off || r.Length < 0 {
+			return fmt.Errorf("range %d doesn't follow the previous one", i)
+		}
+		off += r.Length
+	}
+	if off != c.Size {
+		return fmt.Errorf("ranges cover %d bytes of %d", off, c.Size)
+	}
+	return nil
+}
+
+// Respond returns the response to the challenge with range hashes
+// computed by f.
+func Respond(c *Challenge, f RangeHashFunc) (*Response, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := &Response{Hashes: make([]tz.Hash, len(c.Ranges))}
+	for i, r := range c.Ranges {
+		h, err := f(r.Offset, r.Length)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: %w", i, err)
+		}
+		resp.Hashes[i] = h
+	}
+	return resp, nil
+}
+
+// ReaderAtHashFunc returns RangeHashFunc hashing data read from r.
+func ReaderAtHashFunc(r io.ReaderAt) RangeHashFunc {
+	return func(off, length int64) (tz.Hash, error) {
+		d := tz.NewDigest()
+		n, err := io.Copy(d, io.NewSectionReader(r, off, length))
+		if err != nil {
+			return tz.Hash{}, err
+		}
+		if n != length {
+			return tz.Hash{}, io.ErrUnexpectedEOF
+		}
+		return d.Checksum(), nil
+	}
+}
+
+// Verify checks that the response to the challenge is consistent with
+// the object hash. ErrMismatch is returned if it isn't.
+func Verify(c *Challenge, resp *Response, hash tz.Hash) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if len(resp.Hashes) != len(c.Ranges) {
+		return fmt.Errorf("expected %d hashes, got %d", len(c.Ranges), len(resp.Hashes))
+	}
+
+	hs := make([][]byte, len(resp.Hashes))
+	for i := range resp.Hashes {
+		if err := resp.Hashes[i].Validate(); err != nil {
+			return fmt.Errorf("hash %d: %w", i, err)
+		}
+		hs[i] = resp.Hashes[i][:]
+	}
+	var sum tz.Hash
+	b, _ := tz.Concat(hs) // hashes are validated
+	copy(sum[:], b)
+	if sum != hash {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Agree checks that responses of different nodes to the same challenge
+// are equal. The error mentions the first response and range which differ
+// from the first response.
+func Agree(resps ...*Response) error {
+	for i := 1; i < len(resps); i++ {
+		if len(resps[i].Hashes) != len(resps[0].Hashes) {
+			return fmt.Errorf("response %d: expected %d hashes, got %d", i, len(resps[0].Hashes), len(resps[i].Hashes))
+		}
+		for j := range resps[i].Hashes {
+			if resps[i].Hashes[j] != resps[0].Hashes[j] {
+				return fmt.Errorf("response %d: range %d differs", i, j)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/rangehash"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+	h := tz.Hash(tz.Sum(data))
+
+	c, err := NewChallenge(int64(len(data)), 5, nil)
+	require.NoError(t, err)
+	require.Len(t, c.Ranges, 5)
+	require.NoError(t, c.Validate())
+	for _, r := range c.Ranges {
+		require.Positive(t, r.Length)
+	}
+
+	resp, err := Respond(c, ReaderAtHashFunc(bytes.NewReader(data)))
+	require.NoError(t, err)
+	require.NoError(t, Verify(c, resp, h))
+	for i, r := range c.Ranges {
+		require.Equal(t, tz.Hash(tz.Sum(data[r.Offset:r.Offset+r.Length])), resp.Hashes[i])
+	}
+
+	t.Run("index", func(t *testing.T) {
+		ix, err := rangehash.Build(bytes.NewReader(data), 64)
+		require.NoError(t, err)
+
+		r := bytes.NewReader(data)
+		actual, err := Respond(c, func(off, length int64) (tz.Hash, error) {
+			return ix.Range(r, off, length)
+		})
+		require.NoError(t, err)
+		require.NoError(t, Agree(resp, actual))
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		bad := append([]byte{}, data...)
+		bad[500] ^= 1
+
+		actual, err := Respond(c, ReaderAtHashFunc(bytes.NewReader(bad)))
+		require.NoError(t, err)
+		require.ErrorIs(t, Verify(c, actual, h), ErrMismatch)
+		require.Error(t, Agree(resp, actual))
+	})
+	t.Run("short", func(t *testing.T) {
+		_, err := Respond(c, ReaderAtHashFunc(bytes.NewReader(data[:900])))
+		require.Error(t, err)
+	})
+	t.Run("invalid response", func(t *testing.T) {
+		require.Error(t, Verify(c, &Response{Hashes: resp.Hashes[1:]}, h))
+
+		hs := append([]tz.Hash{}, resp.Hashes...)
+		hs[0] = tz.Hash{}
+		require.Error(t, Verify(c, &Response{Hashes: hs}, h))
+		require.Error(t, Agree(resp, &Response{Hashes: hs[1:]}))
+	})
+	t.Run("range error", func(t *testing.T) {
+		errRange := errors.New("range error")
+		_, err := Respond(c, func(off, length int64) (tz.Hash, error) {
+			return tz.Hash{}, errRange
+		})
+		require.ErrorIs(t, err, errRange)
+	})
+}
+
+func TestNewChallenge(t *testing.T) {
+	c, err := NewChallenge(0, 3, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Range{{0, 0}}, c.Ranges)
+
+	c, err = NewChallenge(3, 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Range{{0, 1}, {1, 1}, {2, 1}}, c.Ranges)
+
+	c, err = NewChallenge(100, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Range{{0, 100}}, c.Ranges)
+
+	a, err := NewChallenge(1<<40, 8, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	b, err := NewChallenge(1<<40, 8, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	_, err = NewChallenge(-1, 1, nil)
+	require.Error(t, err)
+	_, err = NewChallenge(1, 0, nil)
+	require.Error(t, err)
+	_, err = NewChallenge(100, 3, bytes.NewReader(nil))
+	require.Error(t, err)
+}
+
+func TestChallengeValidate(t *testing.T) {
+	require.Error(t, (&Challenge{}).Validate())
+	require.Error(t, (&Challenge{Size: 10, Ranges: []Range{{0, 5}, {6, 4}}}).Validate())
+	require.Error(t, (&Challenge{Size: 10, Ranges: []Range{{0, 5}, {5, 4}}}).Validate())
+	require.Error(t, (&Challenge{Size: 10, Ranges: []Range{{0, 11}, {11, -1}}}).Validate())
+	require.NoError(t, (&Challenge{Size: 10, Ranges: []Range{{0, 5}, {5, 5}}}).Validate())
+}
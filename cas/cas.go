@@ -0,0 +1,162 @@
+// Package cas implements content-addressed blob store over a key-value
+// storage. Blobs are stored under tz.Hash.Key of their contents and verified
+// on every read, so corruption in the underlying storage is detected.
+//
+// Collisions of Tillich-Zémor hash can be computed in practice, so the store
+// is safe for trusted writers only. Put compares data with the blob already
+// stored under the same hash and refuses to replace it with different data,
+// but verification on read can't detect that the blob was written by
+// a hostile writer before the legitimate one. Readers which don't trust
+// writers must check blobs with a cryptographic hash obtained elsewhere.
+//
+// It is a reference integration: any engine like bbolt or Badger can be used
+// by implementing KV, e.g. over a single bbolt bucket:
+//
+//	func (b boltKV) Get(key []byte) ([]byte, error) {
+//		var v []byte
+//		err := b.db.View(func(tx *bbolt.Tx) error {
+//			if v = tx.Bucket(b.name).Get(key); v == nil {
+//				return cas.ErrNotFound
+//			}
+//			v = append([]byte(nil), v...)
+//			return nil
+//		})
+//		return v, err
+//	}
+package cas
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+var (
+	// ErrNotFound is returned when there is no blob with the requested hash.
+	// KV implementations must return it (possibly wrapped) for missing keys.
+	ErrNotFound = errors.New("blob not found")
+	// ErrCorrupted is returned when stored blob doesn't match its hash.
+	ErrCorrupted = errors.New("blob is corrupted")
+	// ErrCollision is returned by Put for data which differs from the stored
+	// blob with the same hash.
+	ErrCollision = errors.New("hash collision")
+)
+
+// KV is a key-value storage used by Store.
+type KV interface {
+	// Get returns value stored under the key or ErrNotFound.
+	// Returned slice must not be modified by the storage afterwards.
+	Get(key []byte) ([]byte, error)
+	// Put stores the value under the key.
+	Put(key, value []byte) error
+	// Delete removes the key. Missing keys are not an error.
+	Delete(key []byte) error
+}
+
+// sum is the hash function, tests replace it to produce collisions.
+var sum = tz.Sum
+
+// Store is content-addressed blob store.
+type Store struct {
+	kv KV
+}
+
+// New returns Store over kv.
+func New(kv KV) *Store {
+	return &Store{kv: kv}
+}
+
+// Put stores data and returns its hash. If the blob with the same hash
+// is already stored, data is compared with it: equal blobs are not written
+// again, corrupted ones are replaced and different ones with the same hash
+// are refused with ErrCollision.
+func (s *Store) Put(data []byte) (tz.Hash, error) {
+	h := tz.Hash(sum(data))
+	stored, err := s.kv.Get(h.Key())
+	switch {
+	case err == nil:
+		if bytes.Equal(stored, data) {
+			return h, nil
+		}
+		if sum(stored) == h {
+			return h, fmt.Errorf("put %s: %w", h, ErrCollision)
+		}
+	case !errors.Is(err, ErrNotFound):
+		return h, fmt.Errorf("put %s: %w", h, err)
+	}
+	if err := s.kv.Put(h.Key(), data); err != nil {
+		return h, fmt.Errorf("put %s: %w", h, err)
+	}
+	return h, nil
+}
+
+// Get returns blob with the hash h after verifying it.
+func (s *Store) Get(h tz.Hash) ([]byte, error) {
+	data, err := s.kv.Get(h.Key())
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", h, err)
+	}
+	if sum(data) != h {
+		return nil, fmt.Errorf("get %s: %w", h, ErrCorrupted)
+	}
+	return data, nil
+}
+
+// Has checks if the blob with the hash h is present. The blob isn't verified.
+func (s *Store) Has(h tz.Hash) (bool, error) {
+	_, err := s.kv.Get(h.Key())
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete removes the blob with the hash h.
+func (s *Store) Delete(h tz.Hash) error {
+	if err := s.kv.Delete(h.Key()); err != nil {
+		return fmt.Errorf("delete %s: %w", h, err)
+	}
+	return nil
+}
+
+// MemoryKV is an in-memory KV safe for concurrent use.
+type MemoryKV struct {
+	mtx sync.RWMutex
+	m   map[string][]byte
+}
+
+// NewMemoryKV returns empty MemoryKV.
+func NewMemoryKV() *MemoryKV {
+	return &MemoryKV{m: make(map[string][]byte)}
+}
+
+// Get implements KV. The value is copied.
+func (m *MemoryKV) Get(key []byte) ([]byte, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	v, ok := m.m[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), v...), nil
+}
+
+// Put implements KV. The value is copied.
+func (m *MemoryKV) Put(key, value []byte) error {
+	m.mtx.Lock()
+	m.m[string(key)] = append([]byte(nil), value...)
+	m.mtx.Unlock()
+	return nil
+}
+
+// Delete implements KV.
+func (m *MemoryKV) Delete(key []byte) error {
+	m.mtx.Lock()
+	delete(m.m, string(key))
+	m.mtx.Unlock()
+	return nil
+}
@@ -0,0 +1,81 @@
+package cas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	kv := NewMemoryKV()
+	s := New(kv)
+
+	data := []byte("content")
+	h, err := s.Put(data)
+	require.NoError(t, err)
+	require.Equal(t, tz.Hash(tz.Sum(data)), h)
+
+	actual, err := s.Get(h)
+	require.NoError(t, err)
+	require.Equal(t, data, actual)
+
+	ok, err := s.Has(h)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	t.Run("corrupted", func(t *testing.T) {
+		require.NoError(t, kv.Put(h.Key(), []byte("other content")))
+		_, err := s.Get(h)
+		require.ErrorIs(t, err, ErrCorrupted)
+
+		_, err = s.Put(data)
+		require.NoError(t, err)
+	})
+	t.Run("collision", func(t *testing.T) {
+		sum = func([]byte) [tz.Size]byte { return h }
+		t.Cleanup(func() { sum = tz.Sum })
+
+		s := New(NewMemoryKV())
+		_, err := s.Put([]byte("first"))
+		require.NoError(t, err)
+		_, err = s.Put([]byte("first"))
+		require.NoError(t, err)
+		_, err = s.Put([]byte("second"))
+		require.ErrorIs(t, err, ErrCollision)
+
+		actual, err := s.Get(h)
+		require.NoError(t, err)
+		require.Equal(t, []byte("first"), actual)
+	})
+	t.Run("missing", func(t *testing.T) {
+		require.NoError(t, s.Delete(h))
+		_, err := s.Get(h)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		ok, err := s.Has(h)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.NoError(t, s.Delete(h))
+	})
+	t.Run("storage error", func(t *testing.T) {
+		s := New(failingKV{})
+		_, err := s.Put(data)
+		require.ErrorIs(t, err, errStorage)
+		_, err = s.Get(h)
+		require.ErrorIs(t, err, errStorage)
+		_, err = s.Has(h)
+		require.ErrorIs(t, err, errStorage)
+		require.ErrorIs(t, s.Delete(h), errStorage)
+	})
+}
+
+var errStorage = errors.New("storage error")
+
+type failingKV struct{}
+
+func (failingKV) Get([]byte) ([]byte, error) { return nil, errStorage }
+func (failingKV) Put(_, _ []byte) error      { return errStorage }
+func (failingKV) Delete([]byte) error        { return errStorage }
@@ -0,0 +1,198 @@
+// Package cdc implements FastCDC-style content-defined chunking with
+// Tillich-Zémor hashes of chunks.
+//
+// Chunk boundaries depend on the content only, so inserting or removing data
+// changes only the chunks around the edit, which makes chunks suitable for
+// deduplication. Because the hash is homomorphic, chunk hashes combined are
+// the hash of the whole stream, which Chunker computes along the way.
+//
+// Boundaries are determined by the gear table generated from a fixed seed
+// and chunk size parameters, so they are stable for the same parameters.
+package cdc
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Default chunk size parameters.
+const (
+	DefaultMinSize = 2 << 10
+	DefaultAvgSize = 8 << 10
+	DefaultMaxSize = 64 << 10
+)
+
+// Chunk is a content-defined chunk of the stream.
+type Chunk struct {
+	Offset int64
+	Length int
+	Hash   tz.Hash
+	// Data is valid until the next call to Next.
+	Data []byte
+}
+
+// Option configures Chunker.
+type Option func(*config)
+
+type config struct {
+	minSize, avgSize, maxSize int
+}
+
+// WithSizes sets minimal, average and maximal chunk sizes. Average size must
+// be a power of two, minSize <= avgSize <= maxSize must hold.
+func WithSizes(minSize, avgSize, maxSize int) Option {
+	return func(c *config) {
+		c.minSize, c.avgSize, c.maxSize = minSize, avgSize, maxSize
+	}
+}
+
+// Chunker splits the stream into chunks.
+type Chunker struct {
+	r   io.Reader
+	c   config
+	eof bool
+
+	// maskS is used before the average size to make small chunks less
+	// likely, maskL after it to make large chunks less likely.
+	maskS, maskL uint64
+
+	buf        []byte
+	start, end int
+
+	off int64
+	sum tz.Hash
+	err error
+}
+
+// NewChunker returns Chunker reading the stream from r.
+func NewChunker(r io.Reader, opts ...Option) (*Chunker, error) {
+	c := config{minSize: DefaultMinSize, avgSize: DefaultAvgSize, maxSize: DefaultMaxSize}
+	for _, o := range opts {
+		o(&c)
+	}
+	if c.minSize <= 0 || c.minSize > c.avgSize || c.avgSize > c.maxSize {
+		return nil, errors.New("chunk sizes must be positive and ordered")
+	}
+	if c.avgSize&(c.avgSize-1) != 0 || c.avgSize < 16 {
+		return nil, errors.New("average chunk size must be a power of two and at least 16")
+	}
+
+	// Normalized chunking with level 2.
+	n := bits.TrailingZeros(uint(c.avgSize))
+	return &Chunker{
+		r:     r,
+		c:     c,
+		maskS: mask(n + 2),
+		maskL: mask(n - 2),
+		buf:   make([]byte, 2*c.maxSize),
+		sum:   tz.Sum(nil),
+	}, nil
+}
+
+// mask returns mask with n most significant bits set, because these bits
+// of the gear hash depend on the largest number of preceding bytes.
+func mask(n int) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+// Next returns the next chunk. It returns io.EOF after the last chunk.
+func (c *Chunker) Next() (Chunk, error) {
+	if c.err != nil {
+		return Chunk{}, c.err
+	}
+	if err := c.fill(); err != nil {
+		c.err = err
+		return Chunk{}, err
+	}
+	if c.start == c.end {
+		c.err = io.EOF
+		return Chunk{}, io.EOF
+	}
+
+	data := c.buf[c.start:c.end]
+	data = data[:c.cut(data)]
+
+	ch := Chunk{Offset: c.off, Length: len(data), Hash: tz.Sum(data), Data: data}
+	s, _ := tz.Concat([][]byte{c.sum[:], ch.Hash[:]}) // both hashes are valid
+	copy(c.sum[:], s)
+
+	c.start += len(data)
+	c.off += int64(len(data))
+	return ch, nil
+}
+
+// Sum returns the hash of chunks returned so far, which is the hash
+// of the whole stream after Next returned io.EOF.
+func (c *Chunker) Sum() tz.Hash {
+	return c.sum
+}
+
+// fill makes the buffer contain at least maxSize bytes unless
+// the stream has ended.
+func (c *Chunker) fill() error {
+	if c.eof || c.end-c.start >= c.c.maxSize {
+		return nil
+	}
+
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	for c.end < c.c.maxSize {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cut returns the length of the chunk at the beginning of data.
+func (c *Chunker) cut(data []byte) int {
+	if len(data) <= c.c.minSize {
+		return len(data)
+	}
+	if len(data) > c.c.maxSize {
+		data = data[:c.c.maxSize]
+	}
+
+	normal := c.c.avgSize
+	if normal > len(data) {
+		normal = len(data)
+	}
+
+	var h uint64
+	i := c.c.minSize
+	for ; i < normal; i++ {
+		h = h<<1 + gear[data[i]]
+		if h&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < len(data); i++ {
+		h = h<<1 + gear[data[i]]
+		if h&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// gear is the table of random values for the rolling hash,
+// generated with SplitMix64 from the fixed seed.
+var gear = func() (t [256]uint64) {
+	x := uint64(0x545a434443000001)
+	for i := range t {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		t[i] = z ^ z>>31
+	}
+	return t
+}()
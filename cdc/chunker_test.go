@@ -0,0 +1,129 @@
+package cdc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkAll(t *testing.T, r io.Reader, opts ...Option) ([]Chunk, tz.Hash) {
+	c, err := NewChunker(r, opts...)
+	require.NoError(t, err)
+
+	var res []Chunk
+	for {
+		ch, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ch.Data = append([]byte{}, ch.Data...)
+		res = append(res, ch)
+	}
+	return res, c.Sum()
+}
+
+func TestChunker(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks, sum := chunkAll(t, bytes.NewReader(data))
+	require.Equal(t, tz.Hash(tz.Sum(data)), sum)
+
+	var off int64
+	for i, ch := range chunks {
+		require.Equal(t, off, ch.Offset)
+		require.Equal(t, data[off:off+int64(ch.Length)], ch.Data)
+		require.Equal(t, tz.Hash(tz.Sum(ch.Data)), ch.Hash)
+		require.LessOrEqual(t, ch.Length, DefaultMaxSize)
+		if i != len(chunks)-1 {
+			require.GreaterOrEqual(t, ch.Length, DefaultMinSize)
+		}
+		off += int64(ch.Length)
+	}
+	require.EqualValues(t, len(data), off)
+
+	// Average size is not exact, but must be of the right order.
+	avg := len(data) / len(chunks)
+	require.True(t, avg > DefaultAvgSize/2 && avg < DefaultAvgSize*2, "average chunk size %d", avg)
+
+	t.Run("small reads", func(t *testing.T) {
+		actual, _ := chunkAll(t, iotest.OneByteReader(bytes.NewReader(data[:100000])))
+		expected, _ := chunkAll(t, bytes.NewReader(data[:100000]))
+		require.Equal(t, expected, actual)
+	})
+	t.Run("content-defined", func(t *testing.T) {
+		shifted := append([]byte{0xFF, 0xFE, 0xFD}, data...)
+		actual, sum := chunkAll(t, bytes.NewReader(shifted))
+		require.Equal(t, tz.Hash(tz.Sum(shifted)), sum)
+
+		common := make(map[tz.Hash]bool, len(chunks))
+		for _, ch := range chunks {
+			common[ch.Hash] = true
+		}
+		var n int
+		for _, ch := range actual {
+			if common[ch.Hash] {
+				n++
+			}
+		}
+		require.Greater(t, n, len(chunks)-3)
+	})
+}
+
+func TestChunkerSmall(t *testing.T) {
+	chunks, sum := chunkAll(t, bytes.NewReader(nil))
+	require.Empty(t, chunks)
+	require.Equal(t, tz.Hash(tz.Sum(nil)), sum)
+
+	data := []byte("small data")
+	chunks, sum = chunkAll(t, bytes.NewReader(data))
+	require.Len(t, chunks, 1)
+	require.Equal(t, data, chunks[0].Data)
+	require.Equal(t, tz.Hash(tz.Sum(data)), sum)
+
+	big := make([]byte, 10000)
+	chunks, _ = chunkAll(t, bytes.NewReader(big), WithSizes(64, 256, 1024))
+	require.Len(t, chunks, 10)
+	for _, ch := range chunks[:9] {
+		require.Equal(t, 1024, ch.Length)
+	}
+}
+
+func TestChunkerErrors(t *testing.T) {
+	for _, sizes := range [][3]int{{0, 16, 32}, {32, 16, 64}, {16, 32, 16}, {8, 24, 64}, {4, 8, 16}} {
+		_, err := NewChunker(bytes.NewReader(nil), WithSizes(sizes[0], sizes[1], sizes[2]))
+		require.Error(t, err, "sizes %v", sizes)
+	}
+
+	errRead := errors.New("read error")
+	c, err := NewChunker(io.MultiReader(bytes.NewReader(make([]byte, 100)), iotest.ErrReader(errRead)))
+	require.NoError(t, err)
+	_, err = c.Next()
+	require.ErrorIs(t, err, errRead)
+	_, err = c.Next()
+	require.ErrorIs(t, err, errRead)
+
+}
+
+func BenchmarkChunker(b *testing.B) {
+	data := make([]byte, 16<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, _ := NewChunker(bytes.NewReader(data))
+		for {
+			if _, err := c.Next(); err != nil {
+				break
+			}
+		}
+	}
+}
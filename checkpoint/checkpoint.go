@@ -0,0 +1,361 @@
+// Package checkpoint implements checkpointing of long-running hash
+// computations.
+//
+// Hasher is a writer which snapshots digest state together with the number
+// of bytes hashed to Storage every time enough data was written or enough
+// time has passed. After restart it resumes from the last checkpoint, after
+// a read error it can be rewound to it. Hashes of all checkpoints are kept,
+// so data hashed so far can be verified segment by segment: the hash of data
+// between two checkpoints is obtained from their hashes with tz.SubtractL.
+package checkpoint
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/internal/dirstore"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+const (
+	// DefaultInterval is the default number of bytes between checkpoints.
+	DefaultInterval = 64 << 20
+	// DefaultHistory is the default number of checkpoints kept.
+	DefaultHistory = 16
+)
+
+var (
+	// ErrNoCheckpoint is returned by Storage when there is no saved state.
+	ErrNoCheckpoint = errors.New("no checkpoint")
+	// ErrMismatch is returned when data doesn't match checkpoints or
+	// checkpoints were saved for other data.
+	ErrMismatch = errors.New("checkpoint mismatch")
+)
+
+// Storage persists checkpoints.
+type Storage interface {
+	// Load returns state saved for the job or ErrNoCheckpoint.
+	Load(id string) ([]byte, error)
+	// Save replaces state of the job atomically.
+	Save(id string, state []byte) error
+	// Delete removes state of the job. Missing state is not an error.
+	Delete(id string) error
+}
+
+// Checkpoint is a point of the hashed stream.
+type Checkpoint struct {
+	// Offset is the number of bytes hashed.
+	Offset int64
+	// Hash is the hash of the first Offset bytes.
+	Hash tz.Hash
+}
+
+// Option configures Hasher.
+type Option func(*config)
+
+type config struct {
+	interval int64
+	period   time.Duration
+	history  int
+	meta     []byte
+}
+
+// WithInterval sets the number of bytes between checkpoints,
+// DefaultInterval by default. Zero disables checkpoints by size.
+func WithInterval(n int64) Option {
+	return func(c *config) {
+		c.interval = n
+	}
+}
+
+// WithPeriod makes Hasher save checkpoint when d has passed since
+// the previous one. Time is checked on writes only.
+func WithPeriod(d time.Duration) Option {
+	return func(c *config) {
+		c.period = d
+	}
+}
+
+// WithHistory sets the number of checkpoints kept, DefaultHistory by default.
+// The latest one is always kept, older ones are merged, so that verification
+// of the oldest segment requires reading more data.
+func WithHistory(n int) Option {
+	return func(c *config) {
+		c.history = n
+	}
+}
+
+// WithMeta sets opaque description of hashed data, e.g. file size and
+// modification time. Checkpoints saved with different meta are not resumed.
+func WithMeta(meta []byte) Option {
+	return func(c *config) {
+		c.meta = meta
+	}
+}
+
+// Hasher hashes data written to it saving checkpoints to Storage.
+// It is not safe for concurrent use.
+type Hasher struct {
+	id  string
+	s   Storage
+	cfg config
+
+	d      *tz.Digest
+	offset int64
+
+	// saved is the digest state of the last checkpoint.
+	saved       []byte
+	savedAt     time.Time
+	checkpoints []Checkpoint
+}
+
+type state struct {
+	Meta        []byte            `json:"meta,omitempty"`
+	Digest      []byte            `json:"digest"`
+	Checkpoints []checkpointState `json:"checkpoints"`
+}
+
+type checkpointState struct {
+	Offset int64  `json:"offset"`
+	Hash   string `json:"hash"`
+}
+
+// Open returns Hasher for the job restored from the last checkpoint saved
+// in s or a new one if there are no checkpoints. Offset returns the position
+// of data to continue from.
+func Open(s Storage, id string, opts ...Option) (*Hasher, error) {
+	h := &Hasher{
+		id:  id,
+		s:   s,
+		cfg: config{interval: DefaultInterval, history: DefaultHistory},
+		d:   tz.NewDigest(),
+	}
+	for _, o := range opts {
+		o(&h.cfg)
+	}
+	if h.cfg.interval < 0 {
+		return nil, errors.New("negative checkpoint interval")
+	}
+	if h.cfg.history < 1 {
+		return nil, errors.New("at least one checkpoint must be kept")
+	}
+	h.saved, _ = h.d.MarshalBinary()
+	h.savedAt = time.Now()
+
+	data, err := s.Load(id)
+	if errors.Is(err, ErrNoCheckpoint) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := h.restore(data); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Hasher) restore(data []byte) error {
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	if string(st.Meta) != string(h.cfg.meta) {
+		return fmt.Errorf("%w: saved for other data", ErrMismatch)
+	}
+	if err := h.d.UnmarshalBinary(st.Digest); err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+
+	var prev int64
+	for _, cs := range st.Checkpoints {
+		b, err := hex.DecodeString(cs.Hash)
+		if err != nil || len(b) != tz.Size || cs.Offset <= prev {
+			return fmt.Errorf("invalid checkpoint at %d", cs.Offset)
+		}
+		c := Checkpoint{Offset: cs.Offset}
+		copy(c.Hash[:], b)
+		h.checkpoints = append(h.checkpoints, c)
+		prev = cs.Offset
+	}
+	if len(h.checkpoints) == 0 || h.checkpoints[len(h.checkpoints)-1].Hash != tz.Hash(h.d.Checksum()) {
+		return errors.New("invalid checkpoint: digest doesn't match the last checkpoint")
+	}
+	h.offset = prev
+	h.saved = st.Digest
+	return nil
+}
+
+// Write implements io.Writer. Checkpoint saving errors are returned after
+// all data is hashed, so the caller can retry with Save.
+func (h *Hasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) != 0 {
+		chunk := p
+		if h.cfg.interval != 0 {
+			left := h.last() + h.cfg.interval - h.offset
+			if left < 0 {
+				left = 0 // previous save has failed
+			}
+			if int64(len(chunk)) > left {
+				chunk = chunk[:left]
+			}
+		}
+		_, _ = h.d.Write(chunk)
+		h.offset += int64(len(chunk))
+		p = p[len(chunk):]
+
+		if h.due() {
+			if err := h.Save(); err != nil {
+				_, _ = h.d.Write(p)
+				h.offset += int64(len(p))
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (h *Hasher) due() bool {
+	if h.offset == h.last() {
+		return false
+	}
+	if h.cfg.interval != 0 && h.offset-h.last() >= h.cfg.interval {
+		return true
+	}
+	return h.cfg.period != 0 && time.Since(h.savedAt) >= h.cfg.period
+}
+
+// last returns the offset of the last checkpoint.
+func (h *Hasher) last() int64 {
+	if len(h.checkpoints) == 0 {
+		return 0
+	}
+	return h.checkpoints[len(h.checkpoints)-1].Offset
+}
+
+// Save saves checkpoint at the current offset.
+func (h *Hasher) Save() error {
+	if h.offset == h.last() {
+		return nil
+	}
+
+	saved, _ := h.d.MarshalBinary()
+	checkpoints := append(h.checkpoints, Checkpoint{Offset: h.offset, Hash: h.d.Checksum()})
+	if len(checkpoints) > h.cfg.history {
+		// Keep the latest ones, the oldest segment starts from zero.
+		checkpoints = append(checkpoints[:0:0], checkpoints[len(checkpoints)-h.cfg.history:]...)
+	}
+
+	st := state{Meta: h.cfg.meta, Digest: saved}
+	for _, c := range checkpoints {
+		st.Checkpoints = append(st.Checkpoints, checkpointState{Offset: c.Offset, Hash: c.Hash.String()})
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := h.s.Save(h.id, data); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	h.saved, h.savedAt, h.checkpoints = saved, time.Now(), checkpoints
+	return nil
+}
+
+// Offset returns the number of bytes hashed.
+func (h *Hasher) Offset() int64 {
+	return h.offset
+}
+
+// Checkpoints returns saved checkpoints ordered by offset.
+func (h *Hasher) Checkpoints() []Checkpoint {
+	return append([]Checkpoint(nil), h.checkpoints...)
+}
+
+// Rewind discards data hashed after the last checkpoint and returns its
+// offset, data must be written again starting from it.
+func (h *Hasher) Rewind() int64 {
+	_ = h.d.UnmarshalBinary(h.saved)
+	h.offset = h.last()
+	return h.offset
+}
+
+// Sum returns the hash of data written so far.
+func (h *Hasher) Sum() tz.Hash {
+	return h.d.Checksum()
+}
+
+// Finish returns the hash of all data written and deletes checkpoints.
+func (h *Hasher) Finish() (tz.Hash, error) {
+	if err := h.s.Delete(h.id); err != nil {
+		return tz.Hash{}, err
+	}
+	return h.Sum(), nil
+}
+
+// Verify checks that data in r matches saved checkpoints. Segments between
+// checkpoints are verified independently, so a mismatch is reported
+// with the range of the first corrupted segment.
+func (h *Hasher) Verify(r io.ReaderAt) error {
+	return Verify(r, h.checkpoints)
+}
+
+// Verify checks that data in r matches checkpoints ordered by offset.
+// The first checkpoint covers data from the beginning.
+func Verify(r io.ReaderAt, checkpoints []Checkpoint) error {
+	var (
+		prev   int64
+		prevH  = tz.Hash(tz.Sum(nil))
+		digest = tz.NewDigest()
+	)
+	for _, c := range checkpoints {
+		expected, err := tz.SubtractL(c.Hash[:], prevH[:])
+		if err != nil {
+			return fmt.Errorf("checkpoint at %d: %w", c.Offset, err)
+		}
+
+		digest.Reset()
+		n, err := io.Copy(digest, io.NewSectionReader(r, prev, c.Offset-prev))
+		if err != nil {
+			return err
+		}
+		if n != c.Offset-prev || string(digest.Sum(nil)) != string(expected) {
+			return fmt.Errorf("%w: range [%d, %d)", ErrMismatch, prev, c.Offset)
+		}
+		prev, prevH = c.Offset, c.Hash
+	}
+	return nil
+}
+
+// DirStorage is Storage keeping state of every job in a separate file
+// of the directory. Job IDs are used as file names, so they must be
+// valid ones.
+type DirStorage string
+
+// Load implements Storage.
+func (d DirStorage) Load(id string) ([]byte, error) {
+	data, err := d.dir().Load(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCheckpoint
+	}
+	return data, err
+}
+
+// Save implements Storage. State is replaced atomically and synced to disk.
+func (d DirStorage) Save(id string, state []byte) error {
+	return d.dir().Save(id, state)
+}
+
+// Delete implements Storage.
+func (d DirStorage) Delete(id string) error {
+	return d.dir().Delete(id)
+}
+
+func (d DirStorage) dir() dirstore.Dir {
+	return dirstore.Dir{Path: string(d), Ext: ".checkpoint"}
+}
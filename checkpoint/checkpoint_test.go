@@ -0,0 +1,135 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStorage fails to save when fail is set.
+type failingStorage struct {
+	Storage
+	fail bool
+}
+
+func (s *failingStorage) Save(id string, state []byte) error {
+	if s.fail {
+		return errors.New("disk is full")
+	}
+	return s.Storage.Save(id, state)
+}
+
+func TestHasher(t *testing.T) {
+	data := make([]byte, 10000)
+	_, _ = rand.Read(data)
+
+	s := DirStorage(t.TempDir())
+	h, err := Open(s, "job", WithInterval(1000), WithMeta([]byte("v1")))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, h.Offset())
+
+	// Interrupted in the middle of the fourth interval.
+	_, err = h.Write(data[:1500])
+	require.NoError(t, err)
+	_, err = h.Write(data[1500:3700])
+	require.NoError(t, err)
+	require.EqualValues(t, 3700, h.Offset())
+	require.Equal(t, tz.Hash(tz.Sum(data[:3700])), h.Sum())
+
+	cs := h.Checkpoints()
+	require.Len(t, cs, 3)
+	for i, c := range cs {
+		require.EqualValues(t, (i+1)*1000, c.Offset)
+		require.Equal(t, tz.Hash(tz.Sum(data[:c.Offset])), c.Hash)
+	}
+
+	t.Run("other data", func(t *testing.T) {
+		_, err := Open(s, "job", WithMeta([]byte("v2")))
+		require.ErrorIs(t, err, ErrMismatch)
+	})
+
+	// Restart.
+	h, err = Open(s, "job", WithInterval(1000), WithMeta([]byte("v1")))
+	require.NoError(t, err)
+	require.EqualValues(t, 3000, h.Offset())
+	require.Equal(t, cs, h.Checkpoints())
+	require.NoError(t, h.Verify(bytes.NewReader(data)))
+
+	// Bad data is rewound.
+	_, err = h.Write(make([]byte, 500))
+	require.NoError(t, err)
+	require.EqualValues(t, 3000, h.Rewind())
+
+	_, err = h.Write(data[3000:])
+	require.NoError(t, err)
+	require.Len(t, h.Checkpoints(), 10)
+
+	sum, err := h.Finish()
+	require.NoError(t, err)
+	require.Equal(t, tz.Hash(tz.Sum(data)), sum)
+
+	_, err = s.Load("job")
+	require.ErrorIs(t, err, ErrNoCheckpoint)
+}
+
+func TestHistory(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	h, err := Open(DirStorage(t.TempDir()), "job", WithInterval(100), WithHistory(3))
+	require.NoError(t, err)
+	_, err = h.Write(data)
+	require.NoError(t, err)
+
+	cs := h.Checkpoints()
+	require.Len(t, cs, 3)
+	require.EqualValues(t, 800, cs[0].Offset)
+	require.EqualValues(t, 1000, cs[2].Offset)
+	require.NoError(t, h.Verify(bytes.NewReader(data)))
+
+	_, err = Open(DirStorage(t.TempDir()), "job", WithHistory(0))
+	require.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	h, err := Open(DirStorage(t.TempDir()), "job", WithInterval(100))
+	require.NoError(t, err)
+	_, err = h.Write(data)
+	require.NoError(t, err)
+
+	data[550] ^= 1
+	err = h.Verify(bytes.NewReader(data))
+	require.ErrorIs(t, err, ErrMismatch)
+	require.Contains(t, err.Error(), "[500, 600)")
+
+	err = h.Verify(bytes.NewReader(data[:950]))
+	require.ErrorIs(t, err, ErrMismatch)
+}
+
+func TestSaveError(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	s := &failingStorage{Storage: DirStorage(t.TempDir())}
+	h, err := Open(s, "job", WithInterval(100))
+	require.NoError(t, err)
+
+	s.fail = true
+	n, err := h.Write(data[:250])
+	require.Error(t, err)
+	require.Equal(t, 250, n)
+	require.EqualValues(t, 250, h.Offset())
+
+	s.fail = false
+	_, err = h.Write(data[250:])
+	require.NoError(t, err)
+	require.Equal(t, tz.Hash(tz.Sum(data)), h.Sum())
+	require.NoError(t, h.Verify(bytes.NewReader(data)))
+}
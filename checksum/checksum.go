@@ -0,0 +1,304 @@
+// Package checksum implements detached checksum files for Tillich-Zémor hashes.
+//
+// A checksum file records format version, prefix, chunk size, data size and
+// per-chunk hashes. Prefix is logically prepended to the data, so root hash
+// is the hash of prefix followed by the data, which is the combination of
+// the prefix hash and all chunk hashes:
+//
+//	tzsum detached v1
+//	prefix: 0102
+//	chunk-size: 1048576
+//	size: 3145728
+//	root: <hex>
+//	<hex hash of the chunk 0>
+//	<hex hash of the chunk 1>
+//	<hex hash of the chunk 2>
+//
+// Prefix is not a salt in the sense used by tzsum -salt, rangehash and
+// tz.URI, which XOR the data with the repeated salt like NeoFS does.
+package checksum
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Version is the current version of checksum file format.
+const Version = 1
+
+// header starts every checksum file, it is followed by the version.
+const header = "tzsum detached v"
+
+// File is a detached checksum of some data.
+type File struct {
+	// Prefix is prepended to the data when root hash is computed,
+	// chunk hashes don't depend on it.
+	Prefix []byte
+	// ChunkSize is the size of all chunks except the last one.
+	ChunkSize int64
+	// Size is the size of the data.
+	Size int64
+	// Chunks contains hashes of data chunks.
+	Chunks [][tz.Size]byte
+}
+
+// MismatchError is returned by Verify when the data doesn't match the checksum.
+type MismatchError struct {
+	// Chunk is the index of the first mismatched chunk.
+	Chunk int
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("chunk %d doesn't match", e.Chunk)
+}
+
+// Create reads r until EOF and returns checksum with the specified
+// chunk size and prefix.
+func Create(r io.Reader, chunkSize int64, prefix []byte) (*File, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+
+	f := &File{
+		Prefix:    append([]byte(nil), prefix...),
+		ChunkSize: chunkSize,
+	}
+	c := newChunker(r)
+	for {
+		h, n, err := c.next(chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return f, nil
+		}
+		f.Size += n
+		f.Chunks = append(f.Chunks, h)
+		if n < chunkSize {
+			return f, nil
+		}
+	}
+}
+
+// Verify reads r until EOF and checks that it matches the checksum.
+// *MismatchError is returned if any chunk doesn't match.
+func (f *File) Verify(r io.Reader) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+
+	var (
+		c    = newChunker(r)
+		size int64
+	)
+	for i := 0; ; i++ {
+		h, n, err := c.next(f.ChunkSize)
+		if err != nil {
+			return err
+		}
+		size += n
+		if n == 0 {
+			if i != len(f.Chunks) {
+				return &MismatchError{Chunk: i}
+			}
+			break
+		}
+		if i >= len(f.Chunks) || h != f.Chunks[i] {
+			return &MismatchError{Chunk: i}
+		}
+		if n < f.ChunkSize {
+			if i != len(f.Chunks)-1 {
+				return &MismatchError{Chunk: i + 1}
+			}
+			break
+		}
+	}
+	if size != f.Size {
+		return fmt.Errorf("size mismatch: expected %d, got %d", f.Size, size)
+	}
+	return nil
+}
+
+// Root returns hash of the prefix followed by the data.
+func (f *File) Root() [tz.Size]byte {
+	hs := make([][]byte, 0, len(f.Chunks)+1)
+	prefix := tz.Sum(f.Prefix)
+	hs = append(hs, prefix[:])
+	for i := range f.Chunks {
+		hs = append(hs, f.Chunks[i][:])
+	}
+
+	var r [tz.Size]byte
+	b, _ := tz.Concat(hs) // all hashes are valid
+	copy(r[:], b)
+	return r
+}
+
+// WriteTo implements io.WriterTo, it writes checksum file to w.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s%d\n", header, Version)
+	fmt.Fprintf(&sb, "prefix: %x\n", f.Prefix)
+	fmt.Fprintf(&sb, "chunk-size: %d\n", f.ChunkSize)
+	fmt.Fprintf(&sb, "size: %d\n", f.Size)
+	fmt.Fprintf(&sb, "root: %x\n", f.Root())
+	for i := range f.Chunks {
+		fmt.Fprintf(&sb, "%x\n", f.Chunks[i])
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// Parse reads checksum file from r. Root hash and the number of chunks
+// are checked to be consistent with other parameters.
+func Parse(r io.Reader) (*File, error) {
+	var (
+		f    = new(File)
+		root [tz.Size]byte
+		s    = bufio.NewScanner(r)
+		line int
+	)
+
+	next := func() (string, error) {
+		line++
+		if !s.Scan() {
+			if err := s.Err(); err != nil {
+				return "", err
+			}
+			return "", io.ErrUnexpectedEOF
+		}
+		return s.Text(), nil
+	}
+	field := func(name string) (string, error) {
+		l, err := next()
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(l, name+": ") {
+			return "", fmt.Errorf("line %d: %s expected", line, name)
+		}
+		return l[len(name)+2:], nil
+	}
+
+	l, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(l, header) {
+		return nil, errors.New("not a checksum file")
+	}
+	if v, err := strconv.Atoi(l[len(header):]); err != nil || v != Version {
+		return nil, fmt.Errorf("unsupported version: %s", l[len(header):])
+	}
+
+	if l, err = field("prefix"); err != nil {
+		return nil, err
+	}
+	if f.Prefix, err = hex.DecodeString(l); err != nil {
+		return nil, fmt.Errorf("line %d: invalid prefix: %w", line, err)
+	}
+
+	if l, err = field("chunk-size"); err != nil {
+		return nil, err
+	}
+	if f.ChunkSize, err = strconv.ParseInt(l, 10, 64); err != nil {
+		return nil, fmt.Errorf("line %d: invalid chunk size: %w", line, err)
+	}
+
+	if l, err = field("size"); err != nil {
+		return nil, err
+	}
+	if f.Size, err = strconv.ParseInt(l, 10, 64); err != nil {
+		return nil, fmt.Errorf("line %d: invalid size: %w", line, err)
+	}
+
+	if l, err = field("root"); err != nil {
+		return nil, err
+	}
+	if root, err = parseHash(l); err != nil {
+		return nil, fmt.Errorf("line %d: invalid root hash: %w", line, err)
+	}
+
+	for s.Scan() {
+		line++
+		h, err := parseHash(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid chunk hash: %w", line, err)
+		}
+		f.Chunks = append(f.Chunks, h)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	if f.Root() != root {
+		return nil, errors.New("root hash doesn't match chunk hashes")
+	}
+	return f, nil
+}
+
+// validate checks that parameters are consistent.
+func (f *File) validate() error {
+	if f.ChunkSize <= 0 {
+		return errors.New("chunk size must be positive")
+	}
+	if f.Size < 0 {
+		return errors.New("size must not be negative")
+	}
+	if n := (f.Size + f.ChunkSize - 1) / f.ChunkSize; n != int64(len(f.Chunks)) {
+		return fmt.Errorf("expected %d chunks, got %d", n, len(f.Chunks))
+	}
+	return nil
+}
+
+// chunkBufferSize is the size of the buffer used to read chunks.
+const chunkBufferSize = 32 << 10
+
+// chunker hashes consecutive chunks of the reader reusing
+// the same digest and buffer.
+type chunker struct {
+	r   io.Reader
+	d   *tz.Digest
+	buf []byte
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{
+		r:   r,
+		d:   tz.NewDigest(),
+		buf: make([]byte, chunkBufferSize),
+	}
+}
+
+// next hashes at most size bytes read from the reader.
+func (c *chunker) next(size int64) ([tz.Size]byte, int64, error) {
+	c.d.Reset()
+	n, err := io.CopyBuffer(c.d, io.LimitReader(c.r, size), c.buf)
+	return c.d.Checksum(), n, err
+}
+
+func parseHash(s string) ([tz.Size]byte, error) {
+	var h [tz.Size]byte
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != tz.Size {
+		return h, fmt.Errorf("expected %d bytes, got %d", tz.Size, len(b))
+	}
+	copy(h[:], b)
+	return h, nil
+}
@@ -0,0 +1,92 @@
+package checksum
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	data := make([]byte, 10000)
+	_, _ = rand.Read(data)
+	prefix := []byte{1, 2, 3}
+
+	for _, size := range []int{0, 1, 999, 1000, 1001, len(data)} {
+		f, err := Create(bytes.NewReader(data[:size]), 1000, prefix)
+		require.NoError(t, err)
+		require.Equal(t, int64(size), f.Size)
+		require.Len(t, f.Chunks, (size+999)/1000)
+		require.Equal(t, tz.Sum(append(prefix, data[:size]...)), f.Root(), "size %d", size)
+		require.NoError(t, f.Verify(bytes.NewReader(data[:size])))
+
+		var buf bytes.Buffer
+		_, err = f.WriteTo(&buf)
+		require.NoError(t, err)
+
+		actual, err := Parse(&buf)
+		require.NoError(t, err)
+		require.Equal(t, f.Chunks, actual.Chunks)
+		require.Equal(t, f.Prefix, actual.Prefix)
+		require.Equal(t, f.Size, actual.Size)
+		require.Equal(t, f.ChunkSize, actual.ChunkSize)
+	}
+
+	_, err := Create(bytes.NewReader(data), 0, nil)
+	require.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	data := make([]byte, 3500)
+	_, _ = rand.Read(data)
+
+	f, err := Create(bytes.NewReader(data), 1000, nil)
+	require.NoError(t, err)
+
+	check := func(t *testing.T, data []byte, chunk int) {
+		var e *MismatchError
+		err := f.Verify(bytes.NewReader(data))
+		require.True(t, errors.As(err, &e), "got %v", err)
+		require.Equal(t, chunk, e.Chunk)
+	}
+
+	t.Run("corrupted", func(t *testing.T) {
+		bad := append([]byte(nil), data...)
+		bad[2500] ^= 1
+		check(t, bad, 2)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		check(t, data[:3000], 3)
+		check(t, data[:2999], 2)
+	})
+	t.Run("extended", func(t *testing.T) {
+		check(t, append(data, 1), 3)
+	})
+}
+
+func TestParse(t *testing.T) {
+	f, err := Create(strings.NewReader("some data"), 4, []byte{42})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, _ = f.WriteTo(&buf)
+	valid := buf.String()
+
+	for name, s := range map[string]string{
+		"empty":     "",
+		"header":    strings.Replace(valid, "tzsum detached", "tzsum", 1),
+		"version":   strings.Replace(valid, "v1", "v2", 1),
+		"prefix":    strings.Replace(valid, "prefix: 2a", "prefix: zz", 1),
+		"size":      strings.Replace(valid, "size: 9", "size: 5", 1),
+		"no chunk":  valid[:strings.LastIndex(valid[:len(valid)-1], "\n")+1],
+		"bad chunk": valid + "00\n",
+		"root":      strings.Replace(valid, "root: 0", "root: 1", 1),
+	} {
+		_, err := Parse(strings.NewReader(s))
+		require.Error(t, err, name)
+	}
+}
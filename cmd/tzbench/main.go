@@ -0,0 +1,139 @@
+// tzbench measures performance of Tillich-Zémor hashing on the local machine.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/gf127"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+var (
+	size     = flag.Int("size", 1<<20, "size of the hashed buffer in `bytes`")
+	duration = flag.Duration("duration", time.Second, "time spent measuring every backend or kernel")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("tzbench: ")
+
+	if *size <= 0 {
+		log.Fatal("size must be positive")
+	}
+
+	fmt.Printf("GOOS: %s, GOARCH: %s, CPUs: %d\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Printf("CPU features: %s\n", strings.Join(tz.CPUFeatures(), " "))
+	fmt.Printf("Default backend: %s\n\n", tz.ActiveBackend())
+
+	benchBackends()
+	fmt.Println()
+	benchKernels()
+}
+
+// benchBackends prints throughput of every available backend. Buffer contains
+// random data, so that zero-run fast path doesn't affect the result.
+func benchBackends() {
+	data := make([]byte, *size)
+	_, _ = rand.New(rand.NewSource(0)).Read(data)
+
+	type result struct {
+		name  string
+		speed float64
+	}
+
+	var (
+		results []result
+		best    float64
+	)
+
+	def := tz.ActiveBackend()
+	for _, name := range tz.Backends() {
+		if err := tz.SetBackend(name); err != nil {
+			log.Fatalf("can't use backend %s: %v", name, err)
+		}
+
+		n, elapsed := measure(func() { _ = tz.Sum(data) })
+		speed := float64(n) * float64(len(data)) / elapsed.Seconds() / 1e6
+		if speed > best {
+			best = speed
+		}
+		results = append(results, result{name, speed})
+	}
+	_ = tz.SetBackend("")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "BACKEND\tMB/s\tRELATIVE\t")
+	for _, r := range results {
+		name := r.name
+		if name == def {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%.2fx\t\n", name, r.speed, r.speed/best)
+	}
+	_ = w.Flush()
+}
+
+// benchKernels prints latency of GF(2^127) operations.
+func benchKernels() {
+	var (
+		a, b, c = gf127.Random(), gf127.Random(), new(gf127.GF127)
+		x, y    gf127.GF127x2
+	)
+	gf127.CombineTo(a, b, &x)
+
+	kernels := []struct {
+		name string
+		f    func()
+	}{
+		{"Add", func() { gf127.Add(a, b, c) }},
+		{"Mul", func() { gf127.Mul(a, b, c) }},
+		{"Mul10", func() { gf127.Mul10(a, c) }},
+		{"Mul11", func() { gf127.Mul11(a, c) }},
+		{"Inv", func() { gf127.Inv(a, c) }},
+		{"Mul10x2", func() { gf127.Mul10x2(&x, &y) }},
+		{"Mul11x2", func() { gf127.Mul11x2(&x, &y) }},
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "KERNEL\tns/op\t")
+	for _, k := range kernels {
+		n, elapsed := measure(k.f)
+		fmt.Fprintf(w, "%s\t%.2f\t\n", k.name, float64(elapsed.Nanoseconds())/float64(n))
+	}
+	_ = w.Flush()
+}
+
+// measure runs f repeatedly for at least the specified duration and returns
+// the number of runs and time spent.
+func measure(f func()) (int, time.Duration) {
+	var (
+		n     int
+		batch = 1
+		start = time.Now()
+	)
+
+	for {
+		for i := 0; i < batch; i++ {
+			f()
+		}
+		n += batch
+
+		elapsed := time.Since(start)
+		if elapsed >= *duration {
+			return n, elapsed
+		}
+		if batch < 1<<20 {
+			batch *= 2
+		}
+	}
+}
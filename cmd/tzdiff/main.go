@@ -0,0 +1,245 @@
+// tzdiff locates byte ranges which differ between two files using
+// Tillich-Zémor hashes of their chunks.
+//
+// Comparing two files reads both of them entirely once: chunk hashes are
+// computed concurrently, then differing chunks are bisected with range hashes
+// until the -min precision is reached, so only differing chunks are reread.
+// Only comparison with a detached checksum of the second file (-checksum)
+// avoids reading it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nspcc-dev/tzhash/checksum"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+var (
+	chunkSize = flag.Int64("chunk", 1<<20, "chunk `size` in bytes used for the first comparison pass")
+	minSize   = flag.Int64("min", 4096, "report differences with the precision of `size` bytes")
+	sumFile   = flag.String("checksum", "", "compare FILE with detached `CHECKSUM` instead of another file")
+)
+
+// span is a byte range [start, end).
+type span struct {
+	start, end int64
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTION]... FILE1 FILE2\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s -checksum CHECKSUM FILE\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Print byte ranges which differ between two files, one per line.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("tzdiff: ")
+
+	if *chunkSize <= 0 || *minSize <= 0 {
+		log.Fatal("chunk and min sizes must be positive")
+	}
+
+	var (
+		diff []span
+		err  error
+	)
+	switch {
+	case *sumFile != "" && flag.NArg() == 1:
+		diff, err = diffChecksum(*sumFile, flag.Arg(0))
+	case *sumFile == "" && flag.NArg() == 2:
+		diff, err = diffFiles(flag.Arg(0), flag.Arg(1))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, s := range diff {
+		fmt.Printf("%d-%d\n", s.start, s.end)
+	}
+	if len(diff) != 0 {
+		os.Exit(1)
+	}
+}
+
+// diffChecksum compares file with the detached checksum. Only the file is read,
+// so differences are reported with the precision of the checksum chunk size.
+func diffChecksum(sumName, name string) ([]span, error) {
+	sf, err := os.Open(sumName)
+	if err != nil {
+		return nil, err
+	}
+	defer sf.Close()
+
+	expected, err := checksum.Parse(sf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sumName, err)
+	}
+
+	actual, err := createChecksum(name, expected.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff []span
+	for _, i := range diffChunks(expected, actual) {
+		diff = appendSpan(diff, chunkSpan(expected, actual, i))
+	}
+	return appendTail(diff, expected, actual), nil
+}
+
+// diffFiles compares two files. Chunk hashes of both files are computed
+// concurrently, then differing chunks are bisected down to minSize precision.
+func diffFiles(name1, name2 string) ([]span, error) {
+	f1, err := os.Open(name1)
+	if err != nil {
+		return nil, err
+	}
+	defer f1.Close()
+
+	f2, err := os.Open(name2)
+	if err != nil {
+		return nil, err
+	}
+	defer f2.Close()
+
+	var (
+		c2   *checksum.File
+		err2 error
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		c2, err2 = checksum.Create(f2, *chunkSize, nil)
+	}()
+	c1, err := checksum.Create(f1, *chunkSize, nil)
+	<-done
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name1, err)
+	}
+	if err2 != nil {
+		return nil, fmt.Errorf("%s: %w", name2, err2)
+	}
+
+	var diff []span
+	for _, i := range diffChunks(c1, c2) {
+		s := chunkSpan(c1, c2, i)
+		if i >= len(c1.Chunks) || i >= len(c2.Chunks) {
+			// Only one file has data in this chunk.
+			diff = appendSpan(diff, s)
+			continue
+		}
+		if diff, err = bisectRange(name1, name2, s, *minSize, diff); err != nil {
+			return nil, err
+		}
+	}
+
+	return appendTail(diff, c1, c2), nil
+}
+
+// appendTail appends to diff the tail of the longer file.
+func appendTail(diff []span, c1, c2 *checksum.File) []span {
+	if c1.Size != c2.Size {
+		diff = appendSpan(diff, span{min(c1.Size, c2.Size), max(c1.Size, c2.Size)})
+	}
+	return diff
+}
+
+// bisectRange appends to diff blocks of precision bytes in s which differ
+// between the named files. s is known to differ, so it is split in halves
+// aligned to precision and only the halves with different hashes are
+// bisected further.
+func bisectRange(name1, name2 string, s span, precision int64, diff []span) ([]span, error) {
+	blocks := (s.end - s.start + precision - 1) / precision
+	if blocks <= 1 {
+		return appendSpan(diff, s), nil
+	}
+
+	mid := s.start + blocks/2*precision
+	for _, h := range []span{{s.start, mid}, {mid, s.end}} {
+		h1, err := tz.SumFileRange(name1, h.start, h.end-h.start)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name1, err)
+		}
+		h2, err := tz.SumFileRange(name2, h.start, h.end-h.start)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name2, err)
+		}
+		if h1 != h2 {
+			if diff, err = bisectRange(name1, name2, h, precision, diff); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return diff, nil
+}
+
+// diffChunks returns indices of chunks which differ.
+func diffChunks(c1, c2 *checksum.File) []int {
+	var res []int
+	for i := 0; i < len(c1.Chunks) || i < len(c2.Chunks); i++ {
+		if i >= len(c1.Chunks) || i >= len(c2.Chunks) || c1.Chunks[i] != c2.Chunks[i] {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// chunkSpan returns byte range of the i-th chunk in the shorter file
+// or in the longer one if the shorter has no such chunk.
+func chunkSpan(c1, c2 *checksum.File, i int) span {
+	start := int64(i) * c1.ChunkSize
+	end := start + c1.ChunkSize
+	if size := max(c1.Size, c2.Size); end > size {
+		end = size
+	}
+	if i < len(c1.Chunks) && i < len(c2.Chunks) {
+		if size := min(c1.Size, c2.Size); end > size {
+			end = size
+		}
+	}
+	return span{start, end}
+}
+
+// appendSpan appends s to diff merging adjacent ranges.
+func appendSpan(diff []span, s span) []span {
+	if n := len(diff); n != 0 && diff[n-1].end >= s.start {
+		if s.end > diff[n-1].end {
+			diff[n-1].end = s.end
+		}
+		return diff
+	}
+	return append(diff, s)
+}
+
+func createChecksum(name string, chunk int64) (*checksum.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return checksum.Create(f, chunk, nil)
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
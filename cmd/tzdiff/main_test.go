@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/checksum"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendSpan(t *testing.T) {
+	testCases := []struct {
+		name     string
+		diff     []span
+		s        span
+		expected []span
+	}{
+		{"empty", nil, span{0, 10}, []span{{0, 10}}},
+		{"adjacent", []span{{0, 10}}, span{10, 20}, []span{{0, 20}}},
+		{"overlapping", []span{{0, 10}}, span{5, 8}, []span{{0, 10}}},
+		{"extending", []span{{0, 10}}, span{5, 15}, []span{{0, 15}}},
+		{"gap", []span{{0, 10}}, span{11, 20}, []span{{0, 10}, {11, 20}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, appendSpan(tc.diff, tc.s))
+		})
+	}
+}
+
+func TestChunkSpan(t *testing.T) {
+	file := func(size int64) *checksum.File {
+		return &checksum.File{ChunkSize: 100, Size: size, Chunks: make([][tz.Size]byte, (size+99)/100)}
+	}
+
+	testCases := []struct {
+		name     string
+		c1, c2   *checksum.File
+		i        int
+		expected span
+	}{
+		{"full", file(1000), file(1000), 3, span{300, 400}},
+		{"last", file(950), file(950), 9, span{900, 950}},
+		{"shorter last", file(950), file(1000), 9, span{900, 950}},
+		{"only longer", file(250), file(1000), 5, span{500, 600}},
+		{"only longer last", file(250), file(950), 9, span{900, 950}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, chunkSpan(tc.c1, tc.c2, tc.i))
+		})
+	}
+}
+
+func TestBisectRange(t *testing.T) {
+	a := make([]byte, 1000)
+	_, _ = rand.Read(a)
+
+	dir := t.TempDir()
+	name1, name2 := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(name1, a, 0o644))
+
+	testCases := []struct {
+		name     string
+		changed  []int
+		s        span
+		expected []span
+	}{
+		{"single", []int{150}, span{0, 1000}, []span{{100, 200}}},
+		{"adjacent", []int{150, 250}, span{0, 1000}, []span{{100, 300}}},
+		{"separate", []int{5, 999}, span{0, 1000}, []span{{0, 100}, {900, 1000}}},
+		{"unaligned", []int{349}, span{250, 450}, []span{{250, 350}}},
+		{"short tail", []int{440}, span{250, 450}, []span{{350, 450}}},
+		{"single block", []int{260}, span{250, 300}, []span{{250, 300}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := append([]byte(nil), a...)
+			for _, i := range tc.changed {
+				b[i] ^= 0xFF
+			}
+			require.NoError(t, os.WriteFile(name2, b, 0o644))
+
+			diff, err := bisectRange(name1, name2, tc.s, 100, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, diff)
+		})
+	}
+
+	_, err := bisectRange(name1, filepath.Join(dir, "missing"), span{0, 1000}, 100, nil)
+	require.Error(t, err)
+}
+
+func TestDiffFiles(t *testing.T) {
+	*chunkSize, *minSize = 1000, 100
+
+	a := make([]byte, 5500)
+	_, _ = rand.Read(a)
+	b := append([]byte(nil), a[:5000]...)
+	b = append(b, make([]byte, 1000)...)
+	b[2345] ^= 1
+
+	dir := t.TempDir()
+	name1, name2 := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(name1, a, 0o644))
+	require.NoError(t, os.WriteFile(name2, b, 0o644))
+
+	diff, err := diffFiles(name1, name2)
+	require.NoError(t, err)
+	require.Equal(t, []span{{2300, 2400}, {5000, 6000}}, diff)
+
+	diff, err = diffFiles(name1, name1)
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	_, err = diffFiles(name1, filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
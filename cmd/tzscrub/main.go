@@ -0,0 +1,84 @@
+// tzscrub periodically re-verifies tzsum manifests in the background and
+// reports corrupted files.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/internal/iolimit"
+)
+
+// dirList is a flag.Value collecting repeated -dir flags.
+type dirList []string
+
+func (d *dirList) String() string { return strings.Join(*d, ",") }
+
+func (d *dirList) Set(s string) error {
+	*d = append(*d, s)
+	return nil
+}
+
+var (
+	dirs     dirList
+	name     = flag.String("manifest", "TZSUMS", "`name` of manifest files produced with tzsum -r")
+	rate     = flag.String("rate", "", "limit read throughput to `RATE` bytes per second, K, M and G suffixes are allowed")
+	interval = flag.Duration("interval", 24*time.Hour, "pause between verification passes")
+	metrics  = flag.String("metrics", "", "serve Prometheus metrics on `address`")
+	once     = flag.Bool("once", false, "make a single pass and exit, non-zero status means problems were found")
+)
+
+func main() {
+	flag.Var(&dirs, "dir", "search manifests in `DIR` recursively (may be repeated)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -dir DIR [-dir DIR]... [OPTION]...\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Verify manifests found in DIRs and print JSON reports about problems to standard output.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("tzscrub: ")
+
+	if len(dirs) == 0 || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s := &scrubber{
+		dirs:     dirs,
+		manifest: *name,
+		report:   newReporter(os.Stdout),
+	}
+	if *rate != "" {
+		n, err := iolimit.ParseSize(*rate)
+		if err != nil {
+			log.Fatalf("invalid rate: %v", err)
+		}
+		s.limiter = iolimit.NewLimiter(n)
+	}
+
+	if *metrics != "" {
+		srv := &http.Server{
+			Addr:              *metrics,
+			Handler:           s.report.stats,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() { log.Fatal(srv.ListenAndServe()) }()
+	}
+
+	for {
+		if ok := s.pass(); *once {
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Event types of the reports.
+const (
+	eventCorrupted  = "corrupted"
+	eventUnreadable = "unreadable"
+	eventMalformed  = "malformed"
+	eventPass       = "pass"
+)
+
+// event is a JSON report about a single problem.
+type event struct {
+	Time     string `json:"time"`
+	Event    string `json:"event"`
+	Manifest string `json:"manifest,omitempty"`
+	File     string `json:"file,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// passSummary is a JSON report about a completed pass.
+type passSummary struct {
+	Time      string `json:"time"`
+	Event     string `json:"event"`
+	Manifests int    `json:"manifests"`
+	Files     int    `json:"files"`
+	Bytes     int64  `json:"bytes"`
+	Problems  int    `json:"problems"`
+	Duration  string `json:"duration"`
+}
+
+// reporter writes JSON lines reports and updates metrics.
+type reporter struct {
+	mtx   sync.Mutex
+	enc   *json.Encoder
+	stats *stats
+}
+
+func newReporter(w io.Writer) *reporter {
+	return &reporter{enc: json.NewEncoder(w), stats: new(stats)}
+}
+
+func (r *reporter) problem(e event) {
+	e.Time = time.Now().UTC().Format(time.RFC3339)
+	switch e.Event {
+	case eventCorrupted:
+		atomic.AddUint64(&r.stats.corrupted, 1)
+	case eventUnreadable:
+		atomic.AddUint64(&r.stats.unreadable, 1)
+	case eventMalformed:
+		atomic.AddUint64(&r.stats.malformed, 1)
+	}
+	r.write(e)
+}
+
+func (r *reporter) pass(s passSummary) {
+	now := time.Now()
+	s.Time = now.UTC().Format(time.RFC3339)
+	s.Event = eventPass
+	atomic.AddUint64(&r.stats.passes, 1)
+	atomic.StoreInt64(&r.stats.lastPass, now.Unix())
+	atomic.StoreInt64(&r.stats.lastProblems, int64(s.Problems))
+	r.write(s)
+}
+
+func (r *reporter) write(v interface{}) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if err := r.enc.Encode(v); err != nil {
+		log.Printf("can't write report: %v", err)
+	}
+}
+
+// stats contains metrics exported in Prometheus text format.
+// 64-bit fields go first to be aligned on 32-bit platforms.
+type stats struct {
+	verified     uint64
+	corrupted    uint64
+	unreadable   uint64
+	malformed    uint64
+	bytes        uint64
+	passes       uint64
+	lastPass     int64
+	lastProblems int64
+}
+
+func (s *stats) addVerified() { atomic.AddUint64(&s.verified, 1) }
+
+func (s *stats) addBytes(n int64) { atomic.AddUint64(&s.bytes, uint64(n)) }
+
+// ServeHTTP implements http.Handler.
+func (s *stats) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metric(w, "tzscrub_files_verified_total", "counter", "Files which matched their manifest.", atomic.LoadUint64(&s.verified))
+	metric(w, "tzscrub_files_corrupted_total", "counter", "Files which did not match their manifest.", atomic.LoadUint64(&s.corrupted))
+	metric(w, "tzscrub_files_unreadable_total", "counter", "Files and manifests which could not be read.", atomic.LoadUint64(&s.unreadable))
+	metric(w, "tzscrub_manifests_malformed_total", "counter", "Manifests which could not be parsed.", atomic.LoadUint64(&s.malformed))
+	metric(w, "tzscrub_read_bytes_total", "counter", "Bytes read while verifying files.", atomic.LoadUint64(&s.bytes))
+	metric(w, "tzscrub_passes_total", "counter", "Completed verification passes.", atomic.LoadUint64(&s.passes))
+	metric(w, "tzscrub_last_pass_timestamp_seconds", "gauge", "Time of the last completed pass.", atomic.LoadInt64(&s.lastPass))
+	metric(w, "tzscrub_last_pass_problems", "gauge", "Problems found during the last completed pass.", atomic.LoadInt64(&s.lastProblems))
+}
+
+func metric(w io.Writer, name, typ, help string, value interface{}) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, typ, name, value)
+}
+
+func hexHash(h [tz.Size]byte) string {
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/internal/iolimit"
+	"github.com/nspcc-dev/tzhash/manifest"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// scrubber verifies all manifests found in the configured directories.
+type scrubber struct {
+	dirs     []string
+	manifest string
+	limiter  *iolimit.Limiter
+	report   *reporter
+}
+
+// pass walks all directories once and verifies every manifest found.
+// It returns false if any problem was reported.
+func (s *scrubber) pass() bool {
+	var (
+		start = time.Now()
+		sum   passSummary
+	)
+	for _, dir := range s.dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				s.report.problem(event{Event: eventUnreadable, File: path, Error: err.Error()})
+				sum.Problems++
+				return nil
+			}
+			if info.Mode().IsRegular() && info.Name() == s.manifest {
+				s.verify(path, &sum)
+			}
+			return nil
+		})
+		if err != nil {
+			s.report.problem(event{Event: eventUnreadable, File: dir, Error: err.Error()})
+			sum.Problems++
+		}
+	}
+	sum.Duration = time.Since(start).String()
+	s.report.pass(sum)
+	return sum.Problems == 0
+}
+
+// verify checks files listed in the manifest relative to its directory.
+// The root hash is checked against file hashes by manifest.Read, so it
+// matches the files if all of them are intact.
+func (s *scrubber) verify(name string, sum *passSummary) {
+	f, err := os.Open(name)
+	if err != nil {
+		s.report.problem(event{Event: eventUnreadable, Manifest: name, Error: err.Error()})
+		sum.Problems++
+		return
+	}
+	defer f.Close()
+
+	sum.Manifests++
+	m, err := manifest.Read(f)
+	if err != nil {
+		s.report.problem(event{Event: eventMalformed, Manifest: name, Error: err.Error()})
+		sum.Problems++
+		return
+	}
+
+	dir := filepath.Dir(name)
+	for _, e := range m.Entries {
+		sum.Files++
+		h, n, err := s.sumFile(filepath.Join(dir, filepath.FromSlash(e.Path)))
+		sum.Bytes += n
+		s.report.stats.addBytes(n)
+		switch {
+		case err != nil:
+			s.report.problem(event{Event: eventUnreadable, Manifest: name, File: e.Path, Error: err.Error()})
+			sum.Problems++
+		case h != e.Hash:
+			s.report.problem(event{Event: eventCorrupted, Manifest: name, File: e.Path,
+				Expected: hexHash(e.Hash), Actual: hexHash(h)})
+			sum.Problems++
+		default:
+			s.report.stats.addVerified()
+		}
+	}
+}
+
+// sumFile returns the hash of the named file and the number of bytes read.
+func (s *scrubber) sumFile(name string) ([tz.Size]byte, int64, error) {
+	var h [tz.Size]byte
+
+	f, err := os.Open(name)
+	if err != nil {
+		return h, 0, err
+	}
+	defer f.Close()
+
+	d := tz.Get()
+	defer tz.Put(d)
+
+	n, err := io.Copy(d, s.limiter.Reader(f))
+	if err != nil {
+		return h, n, err
+	}
+	return d.Checksum(), n, nil
+}
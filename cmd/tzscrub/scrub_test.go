@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubber(t *testing.T) {
+	var (
+		tmp = t.TempDir()
+		a   = []byte("first file")
+		b   = []byte("second file")
+		ha  = tz.Sum(a)
+		hb  = tz.Sum(b)
+		hr  = tz.Sum(append(append([]byte(nil), a...), b...))
+	)
+
+	write := func(name string, data []byte) {
+		name = filepath.Join(tmp, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, data, 0o644))
+	}
+	write("good/a", a)
+	write("good/sub/b", b)
+	write("good/TZSUMS", []byte(fmt.Sprintf("%x  a\n%x  sub/b\n%s%x\n", ha, hb, manifest.RootPrefix, hr)))
+
+	var out bytes.Buffer
+	s := &scrubber{
+		dirs:     []string{tmp},
+		manifest: "TZSUMS",
+		report:   newReporter(&out),
+	}
+
+	events := func() []event {
+		var es []event
+		sc := bufio.NewScanner(&out)
+		for sc.Scan() {
+			var e event
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+			e.Time = ""
+			es = append(es, e)
+		}
+		return es
+	}
+
+	require.True(t, s.pass())
+	es := events()
+	require.Len(t, es, 1)
+	require.Equal(t, eventPass, es[0].Event)
+
+	write("bad/a", []byte("corrupted"))
+	write("bad/TZSUMS", []byte(fmt.Sprintf("%x  a\n%x  missing\n", ha, hb)))
+	write("broken/a", a)
+	write("broken/TZSUMS", []byte(fmt.Sprintf("%x  a\nmalformed\n", ha)))
+
+	require.False(t, s.pass())
+	bad := filepath.Join(tmp, "bad", "TZSUMS")
+	broken := filepath.Join(tmp, "broken", "TZSUMS")
+	got := events()
+	require.Equal(t, []event{
+		{Event: eventCorrupted, Manifest: bad, File: "a", Expected: hexHash(ha), Actual: hexHash(tz.Sum([]byte("corrupted")))},
+		{Event: eventUnreadable, Manifest: bad, File: "missing", Error: got[1].Error},
+		{Event: eventMalformed, Manifest: broken, Error: "line 2: malformed checksum line"},
+		{Event: eventPass},
+	}, got)
+
+	t.Run("root", func(t *testing.T) {
+		// Root doesn't match the order of files.
+		write("good/TZSUMS", []byte(fmt.Sprintf("%x  sub/b\n%x  a\n%s%x\n", hb, ha, manifest.RootPrefix, hr)))
+		require.NoError(t, os.RemoveAll(filepath.Join(tmp, "bad")))
+		require.NoError(t, os.RemoveAll(filepath.Join(tmp, "broken")))
+
+		require.False(t, s.pass())
+		es := events()
+		require.Len(t, es, 2)
+		require.Equal(t, eventMalformed, es[0].Event)
+		require.Equal(t, filepath.Join(tmp, "good", "TZSUMS"), es[0].Manifest)
+	})
+	t.Run("metrics", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.report.stats.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		metrics := w.Body.String()
+		for _, m := range []string{
+			"tzscrub_files_verified_total 4\n",
+			"tzscrub_files_corrupted_total 1\n",
+			"tzscrub_files_unreadable_total 1\n",
+			"tzscrub_manifests_malformed_total 2\n",
+			"tzscrub_passes_total 3\n",
+			"tzscrub_last_pass_problems 1\n",
+		} {
+			require.True(t, strings.Contains(metrics, m), m)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// hashResponse is returned by /sum and /concat endpoints.
+type hashResponse struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// concatRequest is the body of /concat request.
+type concatRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// validateRequest is the body of /validate request.
+type validateRequest struct {
+	Hash  string   `json:"hash"`
+	Parts []string `json:"parts"`
+}
+
+// validateResponse is returned by /validate endpoint.
+type validateResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// errorResponse is returned on any error.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newHandler returns handler serving all endpoints.
+// JSON request bodies are limited to maxJSON bytes.
+func newHandler(maxJSON int64) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sum", post(handleSum))
+	mux.HandleFunc("/concat", post(jsonBody(maxJSON, handleConcat)))
+	mux.HandleFunc("/validate", post(jsonBody(maxJSON, handleValidate)))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// handleSum hashes request body, which is streamed rather than buffered.
+func handleSum(w http.ResponseWriter, r *http.Request) {
+	cr := &countingReader{r: r.Body}
+	h, err := tz.SumReader(cr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hashResponse{Hash: hex.EncodeToString(h[:]), Size: cr.n})
+}
+
+// handleConcat combines part hashes.
+func handleConcat(w http.ResponseWriter, r *http.Request) {
+	var req concatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hs, err := decodeHashes(req.Hashes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h, err := tz.Concat(hs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hashResponse{Hash: hex.EncodeToString(h)})
+}
+
+// handleValidate checks that combination of parts equals the expected hash.
+func handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req validateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	expected, err := decodeHash(req.Hash)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	hs, err := decodeHashes(req.Parts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ok, err := tz.Validate(expected, hs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, validateResponse{Valid: ok})
+}
+
+// post allows only POST requests to h.
+func post(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// jsonBody limits request body size to max bytes.
+func jsonBody(max int64, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		h(w, r)
+	}
+}
+
+func decodeHashes(ss []string) ([][]byte, error) {
+	hs := make([][]byte, len(ss))
+	for i := range ss {
+		h, err := decodeHash(ss[i])
+		if err != nil {
+			return nil, err
+		}
+		hs[i] = h
+	}
+	return hs, nil
+}
+
+func decodeHash(s string) ([]byte, error) {
+	h, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash %q: %w", s, err)
+	}
+	if len(h) != tz.Size {
+		return nil, fmt.Errorf("invalid hash %q: expected %d bytes, got %d", s, tz.Size, len(h))
+	}
+	return h, nil
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("can't write response: %v", err)
+	}
+}
+
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
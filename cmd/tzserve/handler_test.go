@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(newHandler(1 << 10))
+	defer srv.Close()
+
+	data := []byte("some data to hash")
+	parts := [][]byte{data[:4], data[4:11], data[11:]}
+	h := tz.Sum(data)
+	enc := hex.EncodeToString
+
+	var partHashes []string
+	for _, p := range parts {
+		h := tz.Sum(p)
+		partHashes = append(partHashes, enc(h[:]))
+	}
+
+	do := func(t *testing.T, method, path, body string, code int, resp interface{}) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+
+		r, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer r.Body.Close()
+
+		require.Equal(t, code, r.StatusCode)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(resp))
+	}
+
+	t.Run("sum", func(t *testing.T) {
+		var resp hashResponse
+		do(t, http.MethodPost, "/sum", string(data), http.StatusOK, &resp)
+		require.Equal(t, hashResponse{Hash: enc(h[:]), Size: int64(len(data))}, resp)
+	})
+	t.Run("sum large", func(t *testing.T) {
+		// JSON limit doesn't apply to /sum.
+		large := bytes.Repeat([]byte{1}, 4<<10)
+		lh := tz.Sum(large)
+
+		var resp hashResponse
+		do(t, http.MethodPost, "/sum", string(large), http.StatusOK, &resp)
+		require.Equal(t, hashResponse{Hash: enc(lh[:]), Size: int64(len(large))}, resp)
+	})
+	t.Run("concat", func(t *testing.T) {
+		body, _ := json.Marshal(concatRequest{Hashes: partHashes})
+
+		var resp hashResponse
+		do(t, http.MethodPost, "/concat", string(body), http.StatusOK, &resp)
+		require.Equal(t, hashResponse{Hash: enc(h[:])}, resp)
+	})
+	t.Run("validate", func(t *testing.T) {
+		body, _ := json.Marshal(validateRequest{Hash: enc(h[:]), Parts: partHashes})
+
+		var resp validateResponse
+		do(t, http.MethodPost, "/validate", string(body), http.StatusOK, &resp)
+		require.True(t, resp.Valid)
+
+		swapped := []string{partHashes[1], partHashes[0], partHashes[2]}
+		body, _ = json.Marshal(validateRequest{Hash: enc(h[:]), Parts: swapped})
+		do(t, http.MethodPost, "/validate", string(body), http.StatusOK, &resp)
+		require.False(t, resp.Valid)
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		for _, path := range []string{"/sum", "/concat", "/validate"} {
+			var resp errorResponse
+			do(t, http.MethodGet, path, "", http.StatusMethodNotAllowed, &resp)
+			require.NotEmpty(t, resp.Error, path)
+		}
+	})
+	t.Run("bad request", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			path string
+			body string
+		}{
+			"invalid JSON":      {"/concat", "{"},
+			"invalid hex":       {"/concat", `{"hashes":["zz"]}`},
+			"short hash":        {"/concat", `{"hashes":["0102"]}`},
+			"too large":         {"/concat", `{"hashes":["` + strings.Repeat("0", 2<<10) + `"]}`},
+			"invalid expected":  {"/validate", `{"hash":"01","parts":[]}`},
+			"invalid part":      {"/validate", `{"hash":"` + enc(h[:]) + `","parts":["01"]}`},
+			"validate bad JSON": {"/validate", "["},
+		} {
+			var resp errorResponse
+			do(t, http.MethodPost, tc.path, tc.body, http.StatusBadRequest, &resp)
+			require.NotEmpty(t, resp.Error, name)
+		}
+	})
+	t.Run("healthz", func(t *testing.T) {
+		r, err := http.Get(srv.URL + "/healthz")
+		require.NoError(t, err)
+		r.Body.Close()
+		require.Equal(t, http.StatusOK, r.StatusCode)
+	})
+}
@@ -0,0 +1,30 @@
+// tzserve is an HTTP service computing and verifying Tillich-Zémor hashes.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	listen  = flag.String("listen", ":8080", "`address` to listen on")
+	maxJSON = flag.Int64("max-json", 16<<20, "maximum size of JSON request body in `bytes`")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("tzserve: ")
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           newHandler(*maxJSON),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("listening on %s", *listen)
+	log.Fatal(srv.ListenAndServe())
+}
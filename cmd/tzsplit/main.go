@@ -0,0 +1,122 @@
+// tzsplit splits a file into fixed-size parts and prints Tillich-Zémor
+// checksums of every part along with their combination.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/nspcc-dev/tzhash/internal/iolimit"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+var (
+	partSize = flag.String("size", "64M", "part `size` in bytes, K, M and G suffixes are allowed")
+	outDir   = flag.String("out", "", "write parts to `DIR`")
+	verify   = flag.Bool("verify", false, "check that combined hash equals the hash of the whole file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTION]... FILE\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Print checksums of FILE parts followed by their combination.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("tzsplit: ")
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	size, err := iolimit.ParseSize(*partSize)
+	if err != nil {
+		log.Fatalf("invalid part size: %v", err)
+	}
+
+	if err := split(flag.Arg(0), size); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// split prints hashes of parts of the named file and their combination.
+func split(name string, size int64) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var hs [][]byte
+	for i := 0; ; i++ {
+		partName := fmt.Sprintf("%s.%03d", filepath.Base(name), i)
+
+		h, n, err := sumPart(f, size, partName, i != 0)
+		if err != nil {
+			return err
+		}
+		if n == 0 && i != 0 {
+			break
+		}
+
+		hs = append(hs, h[:])
+		fmt.Printf("%x  %s\n", h, partName)
+		if n < size {
+			break
+		}
+	}
+
+	combined, err := tz.Concat(hs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x  %s\n", combined, name)
+
+	if *verify {
+		h, err := tz.SumFile(name)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(h[:], combined) {
+			return fmt.Errorf("verification FAILED: direct hash is %x", h)
+		}
+		fmt.Println("verification: OK")
+	}
+	return nil
+}
+
+// sumPart hashes at most size bytes from r, writing them to the part file
+// in the output directory if needed. It returns number of bytes processed.
+// Empty part file is removed if tail is true.
+func sumPart(r io.Reader, size int64, partName string, tail bool) ([tz.Size]byte, int64, error) {
+	lr := &io.LimitedReader{R: r, N: size}
+	if *outDir == "" {
+		h, err := tz.SumReader(lr)
+		return h, size - lr.N, err
+	}
+
+	path := filepath.Join(*outDir, partName)
+	out, err := os.Create(path)
+	if err != nil {
+		return [tz.Size]byte{}, 0, err
+	}
+
+	h, err := tz.SumReader(io.TeeReader(lr, out))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	n := size - lr.N
+	if err == nil && n == 0 && tail {
+		err = os.Remove(path)
+	}
+	return h, n, err
+}
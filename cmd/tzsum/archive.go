@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+)
+
+// sumArchive prints manifest of regular file members of tar, gzip-compressed
+// tar or zip archive like sumDir does for directories.
+func sumArchive(name string, root bool) bool {
+	m, err := archiveManifest(name)
+	if err != nil {
+		log.Printf("%s: %v", name, err)
+		return false
+	}
+
+	for _, e := range m.Entries {
+		printSum(e.Path, fileSum{hash: e.Hash, size: e.Size, mtime: e.ModTime})
+	}
+	if root {
+		printRoot(m.Root[:])
+	}
+	return true
+}
+
+// archiveManifest detects archive format by its signature.
+func archiveManifest(name string) (*manifest.Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	wrap := manifest.WithReader(func(_ string, _ int64, r io.Reader) io.Reader {
+		return salted(r, 0)
+	})
+
+	br := bufio.NewReader(throttle(track(f)))
+	sig, _ := br.Peek(4)
+	if bytes.Equal(sig, []byte("PK\x03\x04")) || bytes.Equal(sig, []byte("PK\x05\x06")) {
+		// Zip needs random access, so progress and bandwidth limit are not applied.
+		return manifest.FromZip(f, fi.Size(), wrap)
+	}
+
+	prog.addTotal(fi.Size())
+	if !bytes.HasPrefix(sig, []byte{0x1f, 0x8b}) {
+		return manifest.FromTar(br, wrap)
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.FromTar(zr, wrap)
+}
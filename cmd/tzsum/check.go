@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+)
+
+// checkStats contains numbers of problems found while checking manifests.
+type checkStats struct {
+	unreadable int
+	mismatched int
+	unlisted   int
+}
+
+// ok returns true if all files were successfully verified.
+func (s checkStats) ok() bool {
+	return s.unreadable == 0 && s.mismatched == 0 && s.unlisted == 0
+}
+
+// report prints warnings in the same format as coreutils do.
+func (s checkStats) report() {
+	if s.unreadable != 0 {
+		log.Printf("WARNING: %d listed %s could not be read", s.unreadable, plural(s.unreadable, "file", "files"))
+	}
+	if s.mismatched != 0 {
+		log.Printf("WARNING: %d computed %s did NOT match", s.mismatched, plural(s.mismatched, "checksum", "checksums"))
+	}
+	if s.unlisted != 0 {
+		log.Printf("WARNING: %d %s not listed in the manifest", s.unlisted, plural(s.unlisted, "file is", "files are"))
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// checkManifest verifies files listed in the named manifest,
+// "-" denotes standard input. If dir is not empty, paths are relative to it,
+// and all files in dir must be listed in the manifest. The root hash is checked
+// against file hashes by manifest.Read, so it matches the files if all of them
+// match.
+func checkManifest(name string, dir string, stats *checkStats) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	m, err := manifest.Read(r)
+	if err != nil {
+		return err
+	}
+	if len(m.Entries) == 0 {
+		return errors.New("no properly formatted checksum lines found")
+	}
+
+	listed := make(map[string]bool, len(m.Entries))
+	for _, e := range m.Entries {
+		listed[e.Path] = true
+
+		fs, err := sum(dirPath(dir, e.Path), *jobs)
+		switch {
+		case err != nil:
+			stats.unreadable++
+			log.Printf("%s: %v", e.Path, err)
+			printf("%s: FAILED open or read\n", e.Path)
+		case fs.hash != e.Hash:
+			stats.mismatched++
+			printf("%s: FAILED\n", e.Path)
+		default:
+			printf("%s: OK\n", e.Path)
+		}
+	}
+
+	if dir != "" {
+		files, err := listFiles(dir)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !listed[file] {
+				stats.unlisted++
+				printf("%s: FAILED not listed\n", file)
+			}
+		}
+	}
+	return nil
+}
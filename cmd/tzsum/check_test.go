@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFiles(t *testing.T) {
+	var (
+		tmp   = t.TempDir()
+		data  = filepath.Join(tmp, "data")
+		files = map[string][]byte{
+			"a":     []byte("first file"),
+			"sub/b": []byte("second file"),
+		}
+	)
+	require.NoError(t, os.MkdirAll(filepath.Join(data, "sub"), 0o755))
+	for name, b := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(data, filepath.FromSlash(name)), b, 0o644))
+	}
+
+	var (
+		ha = tz.Sum(files["a"])
+		hb = tz.Sum(files["sub/b"])
+		hr = tz.Sum(append(append([]byte(nil), files["a"]...), files["sub/b"]...))
+		hx = tz.Sum([]byte("other data"))
+		hc = tz.Sum(append(append([]byte(nil), files["a"]...), "other data"...))
+	)
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	defer func() { stdout = os.Stdout }()
+	defer func(d string) { *dir = d }(*dir)
+
+	testCases := []struct {
+		name     string
+		manifest string
+		dir      bool
+		ok       bool
+		output   string
+	}{
+		{
+			name:     "ok",
+			manifest: fmt.Sprintf("%x  a\n%x  sub/b\n", ha, hb),
+			ok:       true,
+			output:   "a: OK\nsub/b: OK\n",
+		},
+		{
+			name:     "tag and binary mode",
+			manifest: fmt.Sprintf("%s (a) = %x\n%x *sub/b\n", manifest.TagName, ha, hb),
+			ok:       true,
+			output:   "a: OK\nsub/b: OK\n",
+		},
+		{
+			name:     "comments",
+			manifest: fmt.Sprintf("# comment\n\n%x  a\n", ha),
+			ok:       true,
+			output:   "a: OK\n",
+		},
+		{
+			name:     "malformed line",
+			manifest: fmt.Sprintf("%x  a\nnot a checksum line\n", ha),
+		},
+		{
+			name:     "mismatch",
+			manifest: fmt.Sprintf("%x  a\n%x  sub/b\n", hx, hb),
+			output:   "a: FAILED\nsub/b: OK\n",
+		},
+		{
+			name:     "missing file",
+			manifest: fmt.Sprintf("%x  a\n%x  c\n", ha, hx),
+			output:   "a: OK\nc: FAILED open or read\n",
+		},
+		{
+			name:     "no checksum lines",
+			manifest: "# comment\n",
+		},
+		{
+			name:     "root",
+			manifest: fmt.Sprintf("%x  a\n%x  sub/b\n%s%x\n", ha, hb, manifest.RootPrefix, hr),
+			ok:       true,
+			output:   "a: OK\nsub/b: OK\n",
+		},
+		{
+			// Root doesn't match the order of files.
+			name:     "root mismatch",
+			manifest: fmt.Sprintf("%x  sub/b\n%x  a\n%s%x\n", hb, ha, manifest.RootPrefix, hr),
+		},
+		{
+			name:     "root with missing file",
+			manifest: fmt.Sprintf("%x  a\n%x  c\n%s%x\n", ha, hx, manifest.RootPrefix, hc),
+			output:   "a: OK\nc: FAILED open or read\n",
+		},
+		{
+			name:     "dir",
+			manifest: fmt.Sprintf("%x  a\n%x  sub/b\n", ha, hb),
+			dir:      true,
+			ok:       true,
+			output:   "a: OK\nsub/b: OK\n",
+		},
+		{
+			name:     "dir with unlisted file",
+			manifest: fmt.Sprintf("%x  a\n", ha),
+			dir:      true,
+			output:   "a: OK\nsub/b: FAILED not listed\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := filepath.Join(tmp, "manifest")
+			require.NoError(t, os.WriteFile(m, []byte(tc.manifest), 0o644))
+
+			*dir = ""
+			if tc.dir {
+				*dir = data
+			} else {
+				wd, err := os.Getwd()
+				require.NoError(t, err)
+				require.NoError(t, os.Chdir(data))
+				defer func() { require.NoError(t, os.Chdir(wd)) }()
+			}
+
+			var out bytes.Buffer
+			stdout = &out
+			require.Equal(t, tc.ok, checkFiles([]string{m}))
+			require.Equal(t, tc.output, out.String())
+		})
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		*dir = ""
+		require.False(t, checkFiles([]string{filepath.Join(tmp, "missing")}))
+	})
+}
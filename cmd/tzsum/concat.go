@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// runConcat prints homomorphic combination of part hashes given as arguments
+// or read from standard input, one per line.
+func runConcat(args []string) error {
+	fs := flag.NewFlagSet("concat", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s concat [HASH]...\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Print combined hash of the parts. With no HASH, read hashes from standard input, one per line.")
+	}
+	_ = fs.Parse(args)
+
+	var (
+		hs  [][]byte
+		err error
+	)
+	if fs.NArg() != 0 {
+		hs, err = decodeHashes(fs.Args())
+	} else {
+		hs, err = readHashes(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	if len(hs) == 0 {
+		return errors.New("no hashes to combine")
+	}
+
+	h, err := tz.Concat(hs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", h)
+	return nil
+}
+
+// readHashes reads hex-encoded hashes from r, one per line.
+// Empty lines are ignored.
+func readHashes(r io.Reader) ([][]byte, error) {
+	var lines []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return decodeHashes(lines)
+}
+
+// decodeHashes decodes and validates hex-encoded hashes.
+func decodeHashes(ss []string) ([][]byte, error) {
+	hs := make([][]byte, len(ss))
+	for i := range ss {
+		h, err := decodeHash(ss[i])
+		if err != nil {
+			return nil, err
+		}
+		hs[i] = h
+	}
+	return hs, nil
+}
+
+// decodeHash decodes hex-encoded hash.
+func decodeHash(s string) ([]byte, error) {
+	h, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash %q: %w", s, err)
+	}
+	if len(h) != tz.Size {
+		return nil, fmt.Errorf("invalid hash %q: expected %d bytes, got %d", s, tz.Size, len(h))
+	}
+	return h, nil
+}
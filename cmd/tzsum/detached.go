@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nspcc-dev/tzhash/checksum"
+)
+
+// runDetached creates or verifies detached checksum files.
+func runDetached(args []string) error {
+	if len(args) != 0 {
+		switch args[0] {
+		case "create":
+			return runDetachedCreate(args[1:])
+		case "verify":
+			return runDetachedVerify(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s detached create [-chunk SIZE] [-prefix HEX] FILE\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   or: %s detached verify CHECKSUM FILE\n", os.Args[0])
+	return errors.New("create or verify command expected")
+}
+
+func runDetachedCreate(args []string) error {
+	fs := flag.NewFlagSet("detached create", flag.ExitOnError)
+	chunk := fs.Int64("chunk", 64<<20, "chunk `size` in bytes")
+	prefix := fs.String("prefix", "", "hex-encoded `prefix` prepended to the data")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s detached create [-chunk SIZE] [-prefix HEX] FILE\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Print detached checksum of FILE.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("FILE is required")
+	}
+
+	p, err := hex.DecodeString(*prefix)
+	if err != nil {
+		return fmt.Errorf("invalid prefix: %w", err)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c, err := checksum.Create(f, *chunk, p)
+	if err != nil {
+		return err
+	}
+	_, err = c.WriteTo(os.Stdout)
+	return err
+}
+
+func runDetachedVerify(args []string) error {
+	fs := flag.NewFlagSet("detached verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s detached verify CHECKSUM FILE\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Check FILE against detached CHECKSUM.")
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("CHECKSUM and FILE are required")
+	}
+
+	c, err := readChecksum(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.Verify(f); err != nil {
+		fmt.Printf("%s: FAILED\n", fs.Arg(1))
+		return err
+	}
+	fmt.Printf("%s: OK\n", fs.Arg(1))
+	return nil
+}
+
+func readChecksum(name string) (*checksum.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := checksum.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
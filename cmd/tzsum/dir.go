@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+)
+
+// listFiles returns sorted slash-separated paths of all regular files
+// in dir relative to it.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// dirPath returns path of the file from manifest of dir.
+func dirPath(dir, file string) string {
+	if dir == "" {
+		return file
+	}
+	return filepath.Join(dir, filepath.FromSlash(file))
+}
+
+// sumDir prints manifest of dir, files are listed in lexicographical order
+// of their relative paths. If root is true, combined hash of all files
+// in the same order is printed as the last line.
+func sumDir(dir string, root bool) bool {
+	m, err := manifest.Build(os.DirFS(dir),
+		manifest.WithWorkers(*jobs),
+		manifest.WithReader(func(_ string, size int64, r io.Reader) io.Reader {
+			prog.addTotal(size)
+			return salted(throttle(track(r)), 0)
+		}))
+	if err != nil {
+		log.Printf("%s: %v", dir, err)
+		return false
+	}
+
+	for _, e := range m.Entries {
+		printSum(e.Path, fileSum{hash: e.Hash, size: e.Size, mtime: e.ModTime})
+	}
+	if root {
+		printRoot(m.Root[:])
+	}
+	return true
+}
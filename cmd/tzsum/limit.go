@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+
+	"github.com/nspcc-dev/tzhash/internal/iolimit"
+)
+
+// bandwidth is the global limiter set by -bwlimit flag.
+var bandwidth *iolimit.Limiter
+
+// throttle returns r limited by the global bandwidth limit if it is set.
+func throttle(r io.Reader) io.Reader {
+	return bandwidth.Reader(r)
+}
+
+// setBandwidthLimit sets global limiter, empty rate means no limit.
+func setBandwidthLimit(rate string) error {
+	if rate == "" {
+		return nil
+	}
+
+	n, err := iolimit.ParseSize(rate)
+	if err != nil {
+		return err
+	}
+	bandwidth = iolimit.NewLimiter(n)
+	return nil
+}
@@ -1,33 +1,74 @@
+// tzsum prints Tillich-Zémor checksums of files in the format of sha256sum.
 package main
 
 import (
 	"flag"
 	"fmt"
-	"hash"
 	"io"
 	"log"
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"time"
 
 	"github.com/nspcc-dev/tzhash/tz"
-	"golang.org/x/sys/cpu"
 )
 
 var (
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
-	filename   = flag.String("name", "-", "file to use")
-	hashimpl   = flag.String("impl", "", "implementation to use")
+	filename   = flag.String("name", "", "file to use (deprecated, pass FILE as an argument)")
+	hashimpl   = flag.String("impl", "", "implementation to use (\"auto\" picks the fastest one), "+tz.BackendEnv+" or the most capable one by default")
+	check      = flag.Bool("c", false, "read checksums from the FILEs and check them")
+	dir        = flag.String("r", "", "print manifest of all files in `DIR` or check manifest of it with -c")
+	archive    = flag.String("archive", "", "print manifest of regular file members of tar, tar.gz or zip `ARCHIVE`")
+	root       = flag.Bool("root", false, "print combined hash of all files in the manifest produced with -r or -archive")
+	zeros      = flag.Uint64("zeros", 0, "print hash of `N` zero bytes")
+	jsonOutput = flag.Bool("json", false, "print results as JSON objects including file size, mtime and backend")
+	tag        = flag.Bool("tag", false, "create a BSD-style checksum")
+	zeroTerm   = flag.Bool("z", false, "end each output line with NUL, not newline")
+	stateFile  = flag.String("state", "", "save hashing progress of a single FILE to `STATE` and resume from it")
+	bwlimit    = flag.String("bwlimit", "", "limit total read throughput to `RATE` bytes per second, K, M and G suffixes are allowed")
+	saltHex    = flag.String("salt", "", "XOR input with hex-encoded `SALT` repeated, as NeoFS does for salted range hashes")
+	quiet      = flag.Bool("quiet", false, "don't print progress")
+	jobs       = flag.Int("jobs", 1, "hash up to `N` files concurrently, a single file is split into N parts (0 means number of CPUs)")
 )
 
+// commands contains subcommands operating on hashes instead of files.
+var commands = map[string]func(args []string) error{
+	"concat":   runConcat,
+	"detached": runDetached,
+	"split":    runSplit,
+	"subtract": runSubtract,
+}
+
 func main() {
-	var (
-		f   io.Reader
-		err error
-	)
+	log.SetFlags(0)
+	log.SetPrefix("tzsum: ")
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTION]... [FILE]...\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s -r DIR [-root] [-c MANIFEST]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s -archive ARCHIVE [-root]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s -zeros N\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [OPTION]... concat [HASH]...\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [OPTION]... subtract -left|-right WHOLE PART\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [OPTION]... split [-hashes] PARENT PART...\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [OPTION]... detached create|verify ...\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Print or check Tillich-Zémor checksums. With no FILE, or when FILE is -, read standard input.")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	if *jobs <= 0 {
+		*jobs = runtime.GOMAXPROCS(0)
+	}
+	if err := setBandwidthLimit(*bwlimit); err != nil {
+		log.Fatalf("invalid bandwidth limit: %v", err)
+	}
+	if err := setSalt(*saltHex); err != nil {
+		log.Fatalf("invalid salt: %v", err)
+	}
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -39,37 +80,68 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	if *filename != "-" {
-		if f, err = os.Open(*filename); err != nil {
-			log.Fatal("could not open file: ", err)
+	if *hashimpl != "" {
+		if err := tz.SetBackend(*hashimpl); err != nil {
+			log.Fatalf("invalid backend: %v", err)
 		}
-	} else {
-		f = os.Stdin
-	}
-
-	// Override CPU feature flags to make sure a proper backend is used.
-	var h hash.Hash
-	switch *hashimpl {
-	case "avx":
-		cpu.X86.HasAVX = true
-		cpu.X86.HasAVX2 = false
-		h = tz.New()
-	case "avx2":
-		cpu.X86.HasAVX = true
-		cpu.X86.HasAVX2 = true
-		h = tz.New()
-	case "generic":
-		cpu.X86.HasAVX = false
-		cpu.X86.HasAVX2 = false
-		h = tz.New()
-	default:
-		log.Fatalf("Invalid backend: %s", *hashimpl)
 	}
 
-	if _, err := io.Copy(h, f); err != nil {
-		log.Fatal("error while reading file: ", err)
+	// Subcommands follow global flags, so that they apply to subcommands too.
+	if cmd, ok := commands[flag.Arg(0)]; ok {
+		if err := cmd(flag.Args()[1:]); err != nil {
+			pprof.StopCPUProfile()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	files := flag.Args()
+	if *filename != "" {
+		files = append([]string{*filename}, files...)
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	if !*quiet {
+		startProgress()
+	}
+
+	var zerosSet bool
+	flag.Visit(func(f *flag.Flag) { zerosSet = zerosSet || f.Name == "zeros" })
+
+	var ok bool
+	switch {
+	case zerosSet:
+		fmt.Printf("%x\n", tz.SumZeros(*zeros))
+		ok = true
+	case *stateFile != "":
+		if len(files) != 1 || files[0] == "-" || *check || *dir != "" {
+			log.Fatal("-state requires a single FILE")
+		}
+		s, err := sumResumable(files[0], *stateFile)
+		if err != nil {
+			log.Fatalf("%s: %v", files[0], err)
+		}
+		printSum(files[0], s)
+		ok = true
+	case *check:
+		ok = checkFiles(files)
+	case *dir != "":
+		if flag.NArg() != 0 {
+			log.Fatal("no FILE arguments are allowed with -r")
+		}
+		ok = sumDir(*dir, *root)
+	case *archive != "":
+		if flag.NArg() != 0 {
+			log.Fatal("no FILE arguments are allowed with -archive")
+		}
+		ok = sumArchive(*archive, *root)
+	default:
+		ok = sumFiles(files)
 	}
-	fmt.Printf("%x\t%s\n", h.Sum(nil), *filename)
+
+	stopProgress()
 
 	if *memprofile != "" {
 		f, err := os.Create(*memprofile)
@@ -82,4 +154,137 @@ func main() {
 		}
 		f.Close()
 	}
+
+	if !ok {
+		pprof.StopCPUProfile()
+		os.Exit(1)
+	}
+}
+
+// sumFiles prints checksums of files and returns false if any of them
+// couldn't be read.
+func sumFiles(files []string) bool {
+	parts := 1
+	if len(files) == 1 {
+		parts = *jobs
+	}
+
+	ok := true
+	sumEach(files, parts, func(i int, s fileSum, err error) {
+		if err != nil {
+			log.Printf("%s: %v", files[i], err)
+			ok = false
+			return
+		}
+		printSum(files[i], s)
+	})
+	return ok
+}
+
+// sumEach hashes up to jobs files concurrently, every file is split into
+// at most parts shards. f is called for every file in order.
+func sumEach(files []string, parts int, f func(i int, s fileSum, err error)) {
+	type result struct {
+		s   fileSum
+		err error
+	}
+
+	var (
+		sem     = make(chan struct{}, *jobs)
+		results = make([]chan result, len(files))
+	)
+
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	go func() {
+		for i, name := range files {
+			sem <- struct{}{}
+			go func(i int, name string) {
+				s, err := sum(name, parts)
+				results[i] <- result{s, err}
+				<-sem
+			}(i, name)
+		}
+	}()
+
+	for i := range files {
+		r := <-results[i]
+		f(i, r.s, r.err)
+	}
+}
+
+// checkFiles verifies checksums listed in manifests and returns false
+// if any of them doesn't match or couldn't be verified.
+func checkFiles(manifests []string) bool {
+	var (
+		stats checkStats
+		ok    = true
+	)
+	for _, name := range manifests {
+		if err := checkManifest(name, *dir, &stats); err != nil {
+			log.Printf("%s: %v", name, err)
+			ok = false
+		}
+	}
+	stats.report()
+	return ok && stats.ok()
+}
+
+// fileSum is a checksum of a file along with its metadata.
+type fileSum struct {
+	hash  [tz.Size]byte
+	size  int64
+	mtime time.Time // zero for standard input
+}
+
+// sum returns checksum of the named file, "-" denotes standard input.
+// The file is split into at most parts shards hashed in parallel
+// unless bandwidth is limited or salt is used.
+func sum(name string, parts int) (fileSum, error) {
+	var (
+		s   fileSum
+		err error
+	)
+
+	if name == "-" {
+		r := &countingReader{r: salted(throttle(track(os.Stdin)), 0)}
+		s.hash, err = tz.SumReader(r)
+		s.size = r.n
+		return s, err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return s, err
+	}
+	s.size, s.mtime = fi.Size(), fi.ModTime()
+	prog.addTotal(s.size)
+
+	if parts > 1 && bandwidth == nil && salt == nil {
+		s.hash, err = tz.SumFileParallel(name, parts)
+		prog.addDone(s.size)
+	} else {
+		s.hash, err = tz.SumReader(salted(throttle(track(f)), 0))
+	}
+	return s, err
+}
+
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
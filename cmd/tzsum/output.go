@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// jsonSum is a JSON representation of the file checksum.
+type jsonSum struct {
+	File    string `json:"file"`
+	Hash    string `json:"hash"`
+	Size    int64  `json:"size"`
+	Mtime   string `json:"mtime,omitempty"`
+	Backend string `json:"backend"`
+}
+
+// jsonRoot is a JSON representation of the combined hash of directory files.
+type jsonRoot struct {
+	Root string `json:"root"`
+}
+
+// lineEnd returns output line terminator.
+func lineEnd() string {
+	if *zeroTerm {
+		return "\x00"
+	}
+	return "\n"
+}
+
+// printSum prints checksum of the named file in the selected format.
+func printSum(name string, s fileSum) {
+	switch {
+	case *tag && !*jsonOutput:
+		printf("%s (%s) = %x%s", manifest.TagName, name, s.hash, lineEnd())
+		return
+	case !*jsonOutput:
+		printf("%x  %s%s", s.hash, name, lineEnd())
+		return
+	}
+
+	v := jsonSum{
+		File:    name,
+		Hash:    hex.EncodeToString(s.hash[:]),
+		Size:    s.size,
+		Backend: tz.ActiveBackend(),
+	}
+	if !s.mtime.IsZero() {
+		v.Mtime = s.mtime.UTC().Format(time.RFC3339Nano)
+	}
+	printJSON(v)
+}
+
+// printRoot prints combined hash of directory files in the selected format.
+func printRoot(h []byte) {
+	if !*jsonOutput {
+		printf("%s%x%s", manifest.RootPrefix, h, lineEnd())
+		return
+	}
+	printJSON(jsonRoot{Root: hex.EncodeToString(h)})
+}
+
+func printJSON(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("can't encode result: %v", err)
+	}
+	printf("%s%s", b, lineEnd())
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// progressInterval is the interval between status line updates.
+const progressInterval = 500 * time.Millisecond
+
+// progress prints periodic status line to stderr.
+type progress struct {
+	done  int64 // accessed atomically
+	total int64 // accessed atomically
+
+	mtx   sync.Mutex // protects terminal output
+	width int        // width of the status line currently displayed
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// prog is the global progress reporter, nil if reporting is disabled.
+var prog *progress
+
+// isTerminal returns true if f is a character device.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress starts reporting unless stdout is not a terminal.
+func startProgress() {
+	if !isTerminal(os.Stdout) {
+		return
+	}
+
+	prog = &progress{stop: make(chan struct{})}
+	prog.wg.Add(1)
+	go prog.run()
+}
+
+// stopProgress stops reporting and clears the status line.
+func stopProgress() {
+	if prog == nil {
+		return
+	}
+	close(prog.stop)
+	prog.wg.Wait()
+	prog = nil
+}
+
+func (p *progress) run() {
+	defer p.wg.Done()
+
+	var (
+		t    = time.NewTicker(progressInterval)
+		last = time.Now()
+		prev int64
+	)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			p.mtx.Lock()
+			p.clear()
+			p.mtx.Unlock()
+			return
+		case now := <-t.C:
+			done := atomic.LoadInt64(&p.done)
+			speed := float64(done-prev) / now.Sub(last).Seconds()
+			prev, last = done, now
+
+			line := p.status(done, atomic.LoadInt64(&p.total), speed)
+
+			p.mtx.Lock()
+			p.clear()
+			p.width = len(line)
+			fmt.Fprint(os.Stderr, line)
+			p.mtx.Unlock()
+		}
+	}
+}
+
+// clear erases the status line, p.mtx must be held.
+func (p *progress) clear() {
+	if p.width != 0 {
+		fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", p.width))
+		p.width = 0
+	}
+}
+
+// stdout is where printf writes to, tests replace it.
+var stdout io.Writer = os.Stdout
+
+// printf prints to stdout erasing the status line first,
+// it is redrawn on the next update.
+func printf(format string, a ...interface{}) {
+	if p := prog; p != nil {
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+		p.clear()
+	}
+	fmt.Fprintf(stdout, format, a...)
+}
+
+// status returns status line for the specified numbers of processed
+// and total bytes and current speed in bytes per second.
+func (p *progress) status(done, total int64, speed float64) string {
+	var sb strings.Builder
+
+	sb.WriteString(formatBytes(float64(done)))
+	if total > 0 && done <= total {
+		fmt.Fprintf(&sb, " / %s (%d%%)", formatBytes(float64(total)), done*100/total)
+	}
+	fmt.Fprintf(&sb, ", %s/s", formatBytes(speed))
+	if total > 0 && done <= total && speed > 0 {
+		eta := time.Duration(float64(total-done) / speed * float64(time.Second))
+		fmt.Fprintf(&sb, ", ETA %s", eta.Round(time.Second))
+	}
+	fmt.Fprintf(&sb, ", backend %s", tz.ActiveBackend())
+	return sb.String()
+}
+
+// addTotal adds n to the total number of bytes to process.
+func (p *progress) addTotal(n int64) {
+	if p != nil {
+		atomic.AddInt64(&p.total, n)
+	}
+}
+
+// addDone adds n to the number of processed bytes.
+func (p *progress) addDone(n int64) {
+	if p != nil {
+		atomic.AddInt64(&p.done, n)
+	}
+}
+
+// track returns r which reports read bytes to the global progress reporter.
+func track(r io.Reader) io.Reader {
+	if prog == nil {
+		return r
+	}
+	return &progressReader{r: r, p: prog}
+}
+
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.p.addDone(int64(n))
+	return n, err
+}
+
+func formatBytes(n float64) string {
+	const units = "KMGTPE"
+
+	if n < 1024 {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	i := -1
+	for ; n >= 1024 && i < len(units)-1; i++ {
+		n /= 1024
+	}
+	return fmt.Sprintf("%.1f %ciB", n, units[i])
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/hex"
+	"io"
+)
+
+// salt is the global salt set by -salt flag.
+var salt []byte
+
+// setSalt sets global salt from hex string, empty string means no salt.
+func setSalt(s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != 0 {
+		salt = b
+	}
+	return nil
+}
+
+// salted returns r XORing data with the global salt if it is set.
+// off is the offset of the first byte read from r in the file,
+// so that salt is applied the same way as if the file was read from the start.
+func salted(r io.Reader, off int64) io.Reader {
+	if salt == nil {
+		return r
+	}
+	return &saltReader{r: r, off: uint64(off)}
+}
+
+// saltReader XORs data with the repeated salt like NeoFS storage nodes do
+// when computing salted range hashes.
+type saltReader struct {
+	r   io.Reader
+	off uint64
+}
+
+func (s *saltReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= salt[(s.off+uint64(i))%uint64(len(salt))]
+	}
+	s.off += uint64(n)
+	return n, err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nspcc-dev/tzhash/manifest"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// part is a child part of the split object.
+type part struct {
+	name string
+	hash [tz.Size]byte
+	size int64 // -1 if unknown
+}
+
+// runSplit verifies that parts combine into the parent.
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	hashes := fs.Bool("hashes", false, "PARTs are files with part hashes, one per line, instead of part data")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s split [-hashes] PARENT PART...\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Verify that PARTs combine into PARENT, which is either a hash or a file.")
+		fmt.Fprintln(fs.Output(), "If PARENT is a file and PARTs are data files, every prefix is checked")
+		fmt.Fprintln(fs.Output(), "and the first one which doesn't reconcile is reported.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("PARENT and at least one PART are required")
+	}
+
+	var (
+		parts []part
+		err   error
+	)
+	if *hashes {
+		parts, err = readPartHashes(fs.Args()[1:])
+	} else {
+		parts, err = sumParts(fs.Args()[1:])
+	}
+	if err != nil {
+		return err
+	}
+
+	parent := fs.Arg(0)
+	if h, ok := manifest.ParseHash(parent); ok {
+		return reconcileHash(h, parts)
+	}
+	if *hashes {
+		return errors.New("PARENT file requires part data files to check prefixes")
+	}
+	return reconcileFile(parent, parts)
+}
+
+// readPartHashes reads part hashes from files, one per line. Only the first
+// field of every line is used, so that tzsum output can be given as is.
+func readPartHashes(names []string) ([]part, error) {
+	var parts []part
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		s := bufio.NewScanner(f)
+		for line := 1; s.Scan(); line++ {
+			fields := strings.Fields(s.Text())
+			if len(fields) == 0 || fields[0][0] == '#' {
+				continue
+			}
+			h, ok := manifest.ParseHash(fields[0])
+			if !ok {
+				_ = f.Close()
+				return nil, fmt.Errorf("%s:%d: invalid hash", name, line)
+			}
+			parts = append(parts, part{name: fmt.Sprintf("%s:%d", name, line), hash: h, size: -1})
+		}
+		err = s.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return parts, nil
+}
+
+// sumParts hashes part files.
+func sumParts(names []string) ([]part, error) {
+	parts := make([]part, len(names))
+	for i, name := range names {
+		s, err := sum(name, *jobs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		parts[i] = part{name: name, hash: s.hash, size: s.size}
+	}
+	return parts, nil
+}
+
+// reconcileHash checks that parts combine into the parent hash.
+func reconcileHash(parent [tz.Size]byte, parts []part) error {
+	h := combine(parts)
+	if h != parent {
+		printf("parts 1-%d: FAILED, got %x\n", len(parts), h)
+		return errors.New("parts don't reconcile with the parent hash")
+	}
+	printf("parts 1-%d: OK\n", len(parts))
+	return nil
+}
+
+// reconcileFile checks that every prefix of parts combines into the hash
+// of the corresponding prefix of the parent file.
+func reconcileFile(name string, parts []part) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		d   = tz.NewDigest()
+		acc []byte
+		r   io.Reader = f
+		off int64
+	)
+	for i := range parts {
+		n, err := io.CopyN(d, r, parts[i].size)
+		off += n
+		if err == io.EOF {
+			printf("parts 1-%d: FAILED, parent is only %d bytes long\n", i+1, off)
+			return errors.New("parent is shorter than parts")
+		} else if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			acc = parts[0].hash[:]
+		} else if acc, err = tz.Concat([][]byte{acc, parts[i].hash[:]}); err != nil {
+			return err
+		}
+		if h := d.Checksum(); !bytes.Equal(acc, h[:]) {
+			printf("parts 1-%d: FAILED at parent bytes %d-%d (%s)\n", i+1, off-n, off, parts[i].name)
+			return errors.New("parts don't reconcile with the parent")
+		}
+	}
+
+	if n, _ := io.Copy(io.Discard, r); n != 0 {
+		printf("parts 1-%d: FAILED, parent has %d more bytes\n", len(parts), n)
+		return errors.New("parent is longer than parts")
+	}
+	printf("parts 1-%d: OK\n", len(parts))
+	return nil
+}
+
+// combine returns combined hash of parts.
+func combine(parts []part) [tz.Size]byte {
+	var h [tz.Size]byte
+
+	hs := make([][]byte, len(parts))
+	for i := range parts {
+		hs[i] = parts[i].hash[:]
+	}
+	c, _ := tz.Concat(hs) // hashes are of the correct size
+	copy(h[:], c)
+	return h
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	var (
+		tmp    = t.TempDir()
+		data   = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+		bounds = []int{0, 10, 20, 36}
+		parent = filepath.Join(tmp, "parent")
+		names  []string
+	)
+	require.NoError(t, os.WriteFile(parent, data, 0o644))
+	for i := 1; i < len(bounds); i++ {
+		name := filepath.Join(tmp, fmt.Sprintf("part%d", i))
+		require.NoError(t, os.WriteFile(name, data[bounds[i-1]:bounds[i]], 0o644))
+		names = append(names, name)
+	}
+
+	defer func() { stdout = os.Stdout }()
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		stdout = &out
+		err := runSplit(args)
+		return out.String(), err
+	}
+	writeParent := func(t *testing.T, b []byte) {
+		require.NoError(t, os.WriteFile(parent, b, 0o644))
+	}
+
+	t.Run("file", func(t *testing.T) {
+		out, err := run(append([]string{parent}, names...)...)
+		require.NoError(t, err)
+		require.Equal(t, "parts 1-3: OK\n", out)
+	})
+	t.Run("hash", func(t *testing.T) {
+		h := tz.Sum(data)
+		out, err := run(append([]string{fmt.Sprintf("%x", h)}, names...)...)
+		require.NoError(t, err)
+		require.Equal(t, "parts 1-3: OK\n", out)
+
+		out, err = run(append([]string{fmt.Sprintf("%x", h)}, names[1], names[0], names[2])...)
+		require.Error(t, err)
+		require.Contains(t, out, "parts 1-3: FAILED")
+	})
+	t.Run("prefix mismatch", func(t *testing.T) {
+		defer writeParent(t, data)
+
+		b := append([]byte(nil), data...)
+		b[15] ^= 1
+		writeParent(t, b)
+
+		out, err := run(append([]string{parent}, names...)...)
+		require.Error(t, err)
+		require.Equal(t, fmt.Sprintf("parts 1-2: FAILED at parent bytes 10-20 (%s)\n", names[1]), out)
+	})
+	t.Run("short parent", func(t *testing.T) {
+		defer writeParent(t, data)
+		writeParent(t, data[:15])
+
+		out, err := run(append([]string{parent}, names...)...)
+		require.Error(t, err)
+		require.Equal(t, "parts 1-2: FAILED, parent is only 15 bytes long\n", out)
+	})
+	t.Run("long parent", func(t *testing.T) {
+		defer writeParent(t, data)
+		writeParent(t, append(append([]byte(nil), data...), "tail"...))
+
+		out, err := run(append([]string{parent}, names...)...)
+		require.Error(t, err)
+		require.Equal(t, "parts 1-3: FAILED, parent has 4 more bytes\n", out)
+	})
+	t.Run("hashes", func(t *testing.T) {
+		var list bytes.Buffer
+		list.WriteString("# part hashes\n\n")
+		for i := 1; i < len(bounds); i++ {
+			fmt.Fprintf(&list, "%x  part%d\n", tz.Sum(data[bounds[i-1]:bounds[i]]), i)
+		}
+		hashes := filepath.Join(tmp, "hashes")
+		require.NoError(t, os.WriteFile(hashes, list.Bytes(), 0o644))
+
+		out, err := run("-hashes", fmt.Sprintf("%x", tz.Sum(data)), hashes)
+		require.NoError(t, err)
+		require.Equal(t, "parts 1-3: OK\n", out)
+
+		_, err = run("-hashes", parent, hashes)
+		require.Error(t, err)
+
+		require.NoError(t, os.WriteFile(hashes, []byte("not a hash\n"), 0o644))
+		_, err = run("-hashes", fmt.Sprintf("%x", tz.Sum(data)), hashes)
+		require.EqualError(t, err, hashes+":1: invalid hash")
+	})
+	t.Run("missing part", func(t *testing.T) {
+		_, err := run(parent, filepath.Join(tmp, "missing"))
+		require.Error(t, err)
+	})
+}
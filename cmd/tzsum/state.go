@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// stateInterval is the interval between state saves.
+const stateInterval = 10 * time.Second
+
+// savedState is the content of the state file. Size and modification time
+// are used to detect file changes between runs.
+type savedState struct {
+	File   string    `json:"file"`
+	Size   int64     `json:"size"`
+	Mtime  time.Time `json:"mtime"`
+	Salt   []byte    `json:"salt,omitempty"`
+	Offset int64     `json:"offset"`
+	Digest []byte    `json:"digest"`
+}
+
+// sumResumable hashes the named file saving digest state to stateFile
+// periodically. If stateFile contains state for the same file, hashing
+// is resumed from the saved offset. State file is removed on success.
+func sumResumable(name, stateFile string) (fileSum, error) {
+	var s fileSum
+
+	f, err := os.Open(name)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return s, err
+	}
+	s.size, s.mtime = fi.Size(), fi.ModTime()
+
+	st := savedState{File: name, Size: s.size, Mtime: s.mtime, Salt: salt}
+	d := tz.NewDigest()
+	if err := loadState(stateFile, &st, d); err != nil {
+		return s, err
+	}
+	if _, err := f.Seek(st.Offset, io.SeekStart); err != nil {
+		return s, err
+	}
+
+	prog.addTotal(s.size)
+	prog.addDone(st.Offset)
+
+	var (
+		r    = salted(throttle(track(f)), st.Offset)
+		buf  = make([]byte, 1<<20)
+		last = time.Now()
+	)
+	for {
+		n, err := io.ReadFull(r, buf)
+		_, _ = d.Write(buf[:n])
+		st.Offset += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return s, err
+		}
+
+		if time.Since(last) >= stateInterval {
+			if err := saveState(stateFile, &st, d); err != nil {
+				return s, err
+			}
+			last = time.Now()
+		}
+	}
+
+	s.hash = d.Checksum()
+	if err := os.Remove(stateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return s, err
+	}
+	return s, nil
+}
+
+// loadState restores digest state from the file if it was saved for the file
+// described by st. Missing state file is not an error.
+func loadState(name string, st *savedState, d *tz.Digest) error {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var saved savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	if saved.File != st.File || saved.Size != st.Size || !saved.Mtime.Equal(st.Mtime) {
+		return errors.New("state was saved for a different file or the file has changed")
+	}
+	if !bytes.Equal(saved.Salt, st.Salt) {
+		return errors.New("state was saved with a different salt")
+	}
+	if err := d.UnmarshalBinary(saved.Digest); err != nil {
+		return err
+	}
+	st.Offset = saved.Offset
+	return nil
+}
+
+// saveState atomically writes digest state to the file.
+func saveState(name string, st *savedState, d *tz.Digest) error {
+	var err error
+	if st.Digest, err = d.MarshalBinary(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
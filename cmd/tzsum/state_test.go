@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumResumable(t *testing.T) {
+	var (
+		tmp   = t.TempDir()
+		state = filepath.Join(tmp, "state")
+		rnd   = rand.New(rand.NewSource(42))
+	)
+
+	// interrupt saves the state a run over the named file leaves
+	// when it is interrupted after off bytes hashed as data.
+	interrupt := func(t *testing.T, name string, off int64, data []byte) {
+		fi, err := os.Stat(name)
+		require.NoError(t, err)
+
+		d := tz.NewDigest()
+		_, _ = d.Write(data[:off])
+		st := savedState{File: name, Size: fi.Size(), Mtime: fi.ModTime(), Salt: salt, Offset: off}
+		require.NoError(t, saveState(state, &st, d))
+	}
+	write := func(t *testing.T, name string, size int) []byte {
+		data := make([]byte, size)
+		_, _ = rnd.Read(data)
+		require.NoError(t, os.WriteFile(name, data, 0o644))
+		return data
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			size int
+			off  int64
+		}{
+			{"empty", 0, 0},
+			{"start", 1000, 0},
+			{"middle", 3<<20 + 17, 1<<20 + 5},
+			{"end", 1000, 1000},
+		}
+		for _, tc := range testCases {
+			name := filepath.Join(tmp, tc.name)
+			data := write(t, name, tc.size)
+
+			// Resumed hash can only match other data if the saved digest is used.
+			other := append([]byte(nil), data...)
+			for i := range other[:tc.off] {
+				other[i] ^= 0xFF
+			}
+			interrupt(t, name, tc.off, other)
+
+			s, err := sumResumable(name, state)
+			require.NoError(t, err, tc.name)
+			require.Equal(t, tz.Sum(other), s.hash, tc.name)
+			require.Equal(t, int64(tc.size), s.size, tc.name)
+			require.NoFileExists(t, state, tc.name)
+		}
+	})
+	t.Run("salt", func(t *testing.T) {
+		defer func() { salt = nil }()
+		salt = []byte{1, 2, 3}
+
+		name := filepath.Join(tmp, "salted")
+		data := write(t, name, 2<<20)
+		x := make([]byte, len(data))
+		for i := range data {
+			x[i] = data[i] ^ salt[i%len(salt)]
+		}
+		interrupt(t, name, 1<<20+1, x)
+
+		s, err := sumResumable(name, state)
+		require.NoError(t, err)
+		require.Equal(t, tz.Sum(x), s.hash)
+	})
+	t.Run("stale", func(t *testing.T) {
+		name := filepath.Join(tmp, "stale")
+
+		testCases := map[string]func(t *testing.T){
+			"modified": func(t *testing.T) {
+				mtime := time.Now().Add(-time.Hour)
+				require.NoError(t, os.Chtimes(name, mtime, mtime))
+			},
+			"resized": func(t *testing.T) {
+				write(t, name, 2000)
+			},
+			"other file": func(t *testing.T) {
+				other := filepath.Join(tmp, "other")
+				interrupt(t, other, 100, write(t, other, 1000))
+			},
+			"salt": func(t *testing.T) {
+				salt = []byte{1}
+			},
+		}
+		for desc, change := range testCases {
+			t.Run(desc, func(t *testing.T) {
+				defer func() { salt = nil }()
+
+				data := write(t, name, 1000)
+				interrupt(t, name, 100, data)
+				change(t)
+
+				_, err := sumResumable(name, state)
+				require.Error(t, err)
+				require.FileExists(t, state)
+				require.NoError(t, os.Remove(state))
+			})
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// runSubtract prints hash of the missing part given the whole hash
+// and the hash of either its left or right part.
+func runSubtract(args []string) error {
+	fs := flag.NewFlagSet("subtract", flag.ExitOnError)
+	left := fs.Bool("left", false, "PART is the left part of WHOLE, print the right one")
+	right := fs.Bool("right", false, "PART is the right part of WHOLE, print the left one")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s subtract -left|-right WHOLE PART\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Print hash of the remaining part of WHOLE after removing PART.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if *left == *right {
+		return errors.New("exactly one of -left and -right must be specified")
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("WHOLE and PART hashes are required")
+	}
+
+	hs, err := decodeHashes(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var h []byte
+	if *left {
+		h, err = tz.SubtractL(hs[0], hs[1])
+	} else {
+		h, err = tz.SubtractR(hs[0], hs[1])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", h)
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package dedup implements the fingerprint index of deduplicating storage.
+//
+// Index maps chunk hashes to locations of stored chunks and counts references
+// to them. Objects are stored as sequences of chunk hashes, e.g. produced
+// by package cdc, and because the hash is homomorphic, the sequence can be
+// verified against the object hash without reading any chunk.
+//
+// Collisions of Tillich-Zémor hash can be computed in practice, so the hash
+// alone doesn't identify the content if writers are not trusted: a hostile
+// writer could make Insert return the location of different data. Because
+// of that, chunks are also identified by SHA-256 of their data and Insert
+// refuses chunks with the known hash and different SHA-256. Resolve and Verify
+// rely on TZ hashes only, they bind chunk sequences to the object hash, but
+// not chunk data to the hashes.
+package dedup
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+var (
+	// ErrNotFound is returned when there is no chunk with the requested hash.
+	ErrNotFound = errors.New("chunk not found")
+	// ErrMismatch is returned when chunks don't combine into the object hash.
+	ErrMismatch = errors.New("chunks don't match the object hash")
+	// ErrCollision is returned by Insert for different data with the same
+	// TZ hash as the indexed chunk.
+	ErrCollision = errors.New("hash collision")
+)
+
+// Location is the place where the chunk is stored.
+type Location struct {
+	// Container identifies the file, blob or volume containing the chunk.
+	Container string
+	Offset    int64
+	Length    int64
+}
+
+// Index is an in-memory fingerprint index. It is safe for concurrent use.
+type Index struct {
+	mtx    sync.RWMutex
+	chunks map[tz.Hash]*entry
+}
+
+type entry struct {
+	loc    Location
+	sha256 [sha256.Size]byte
+	refs   int
+}
+
+// NewIndex returns an empty Index.
+func NewIndex() *Index {
+	return &Index{chunks: make(map[tz.Hash]*entry)}
+}
+
+// Insert adds a reference to the chunk with the hash h and SHA-256 of
+// data sum. If the chunk is already indexed, its location is returned with
+// true, so the new copy of the chunk doesn't need to be stored. Otherwise loc
+// is recorded. ErrCollision is returned if the indexed chunk with the hash h
+// has different SHA-256.
+func (ix *Index) Insert(h tz.Hash, sum [sha256.Size]byte, loc Location) (Location, bool, error) {
+	if err := h.Validate(); err != nil {
+		return Location{}, false, err
+	}
+
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+
+	if e, ok := ix.chunks[h]; ok {
+		if e.sha256 != sum {
+			return Location{}, false, fmt.Errorf("insert %s: %w", h, ErrCollision)
+		}
+		e.refs++
+		return e.loc, true, nil
+	}
+	ix.chunks[h] = &entry{loc: loc, sha256: sum, refs: 1}
+	return loc, false, nil
+}
+
+// Lookup returns the location of the chunk with the hash h.
+func (ix *Index) Lookup(h tz.Hash) (Location, bool) {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+
+	e, ok := ix.chunks[h]
+	if !ok {
+		return Location{}, false
+	}
+	return e.loc, true
+}
+
+// Refs returns the number of references to the chunk with the hash h.
+func (ix *Index) Refs(h tz.Hash) int {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+
+	if e, ok := ix.chunks[h]; ok {
+		return e.refs
+	}
+	return 0
+}
+
+// Release removes a reference to the chunk with the hash h. Chunks without
+// references stay in the index until GC, so they can be reused.
+func (ix *Index) Release(h tz.Hash) error {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+
+	e, ok := ix.chunks[h]
+	if !ok || e.refs == 0 {
+		return fmt.Errorf("release %s: %w", h, ErrNotFound)
+	}
+	e.refs--
+	return nil
+}
+
+// GC removes chunks without references from the index and returns
+// their locations, so the storage can be freed.
+func (ix *Index) GC() map[tz.Hash]Location {
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+
+	res := make(map[tz.Hash]Location)
+	for h, e := range ix.chunks {
+		if e.refs == 0 {
+			res[h] = e.loc
+			delete(ix.chunks, h)
+		}
+	}
+	return res
+}
+
+// Len returns the number of indexed chunks.
+func (ix *Index) Len() int {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+
+	return len(ix.chunks)
+}
+
+// Resolve returns locations of the chunks with the given hashes
+// after checking that the chunks combined reproduce the object
+// with the hash target.
+func (ix *Index) Resolve(chunks []tz.Hash, target tz.Hash) ([]Location, error) {
+	locs := make([]Location, len(chunks))
+
+	ix.mtx.RLock()
+	for i := range chunks {
+		e, ok := ix.chunks[chunks[i]]
+		if !ok {
+			ix.mtx.RUnlock()
+			return nil, fmt.Errorf("chunk %d (%s): %w", i, chunks[i], ErrNotFound)
+		}
+		locs[i] = e.loc
+	}
+	ix.mtx.RUnlock()
+
+	if err := Verify(chunks, target); err != nil {
+		return nil, err
+	}
+	return locs, nil
+}
+
+// Verify checks that chunks with the given hashes combined reproduce
+// the object with the hash target.
+func Verify(chunks []tz.Hash, target tz.Hash) error {
+	h := tz.Hash(tz.Sum(nil))
+	if len(chunks) != 0 {
+		hs := make([][]byte, len(chunks))
+		for i := range chunks {
+			hs[i] = chunks[i][:]
+		}
+		c, err := tz.Concat(hs)
+		if err != nil {
+			return err
+		}
+		copy(h[:], c)
+	}
+	if h != target {
+		return ErrMismatch
+	}
+	return nil
+}
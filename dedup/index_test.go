@@ -0,0 +1,153 @@
+package dedup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/cdc"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+// store chunks data into the index, returning chunk hashes, chunk end
+// offsets and whether every chunk was already stored.
+func store(t *testing.T, ix *Index, name string, data []byte) ([]tz.Hash, []int64, []bool) {
+	c, err := cdc.NewChunker(bytes.NewReader(data), cdc.WithSizes(256, 1024, 4096))
+	require.NoError(t, err)
+
+	var (
+		hs   []tz.Hash
+		ends []int64
+		dups []bool
+	)
+	for {
+		ch, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		_, dup, err := ix.Insert(ch.Hash, sha256.Sum256(ch.Data), Location{Container: name, Offset: ch.Offset, Length: int64(ch.Length)})
+		require.NoError(t, err)
+		hs = append(hs, ch.Hash)
+		ends = append(ends, ch.Offset+int64(ch.Length))
+		dups = append(dups, dup)
+	}
+	return hs, ends, dups
+}
+
+func TestIndex(t *testing.T) {
+	const prefix = "prefix"
+
+	a := make([]byte, 64<<10)
+	_, _ = rand.New(rand.NewSource(42)).Read(a)
+	b := append([]byte(prefix), a...)
+
+	ix := NewIndex()
+	ha, endsA, dups := store(t, ix, "a", a)
+	require.NotContains(t, dups, true)
+	require.Equal(t, len(ha), ix.Len())
+
+	// Chunk boundaries depend only on the data since the previous boundary,
+	// so once b has a boundary where a has one, all subsequent chunks
+	// are the same. Only chunks before the first common boundary can be new.
+	hb, endsB, dups := store(t, ix, "b", b)
+	inA := make(map[int64]bool)
+	for _, end := range endsA {
+		inA[end+int64(len(prefix))] = true
+	}
+	sync := -1
+	for i, end := range endsB {
+		if inA[end] {
+			sync = i
+			break
+		}
+	}
+	require.True(t, sync >= 0 && sync < len(endsB)-1, "chunk boundaries must resynchronize")
+	for i := sync + 1; i < len(dups); i++ {
+		require.True(t, dups[i], "chunk %d after the edit must be deduplicated", i)
+	}
+	require.Equal(t, len(ha)+sync+1, ix.Len())
+
+	locs, err := ix.Resolve(hb, tz.Sum(b))
+	require.NoError(t, err)
+	var actual []byte
+	for _, l := range locs {
+		src := a
+		if l.Container == "b" {
+			src = b
+		}
+		actual = append(actual, src[l.Offset:l.Offset+l.Length]...)
+	}
+	require.Equal(t, b, actual)
+
+	_, err = ix.Resolve(hb, tz.Sum(a))
+	require.ErrorIs(t, err, ErrMismatch)
+	_, err = ix.Resolve(append(hb, tz.Sum([]byte("missing"))), tz.Sum(b))
+	require.ErrorIs(t, err, ErrNotFound)
+
+	t.Run("gc", func(t *testing.T) {
+		inB := make(map[tz.Hash]bool)
+		for _, h := range hb {
+			inB[h] = true
+		}
+		onlyA := make(map[tz.Hash]Location)
+		for _, h := range ha {
+			if !inB[h] {
+				onlyA[h], _ = ix.Lookup(h)
+			}
+		}
+		total := ix.Len()
+
+		for _, h := range ha {
+			require.NoError(t, ix.Release(h))
+		}
+		require.Equal(t, onlyA, ix.GC())
+		require.Equal(t, 1, ix.Refs(hb[len(hb)-1]))
+
+		for _, h := range hb {
+			require.NoError(t, ix.Release(h))
+		}
+		require.Len(t, ix.GC(), total-len(onlyA))
+		require.Zero(t, ix.Len())
+
+		_, ok := ix.Lookup(ha[0])
+		require.False(t, ok)
+		require.ErrorIs(t, ix.Release(ha[0]), ErrNotFound)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	require.NoError(t, Verify(nil, tz.Sum(nil)))
+	require.ErrorIs(t, Verify(nil, tz.Sum([]byte{1})), ErrMismatch)
+
+	hs := []tz.Hash{tz.Sum([]byte{1}), tz.Sum([]byte{2})}
+	require.NoError(t, Verify(hs, tz.Sum([]byte{1, 2})))
+	require.ErrorIs(t, Verify(hs, tz.Sum([]byte{2, 1})), ErrMismatch)
+
+	_, _, err := NewIndex().Insert(tz.Hash{1}, [sha256.Size]byte{}, Location{})
+	require.Error(t, err)
+}
+
+func TestCollision(t *testing.T) {
+	ix := NewIndex()
+	h := tz.Hash(tz.Sum([]byte("chunk")))
+	loc := Location{Container: "a", Length: 5}
+
+	_, dup, err := ix.Insert(h, sha256.Sum256([]byte("chunk")), loc)
+	require.NoError(t, err)
+	require.False(t, dup)
+
+	// Different data with the same TZ hash must not be deduplicated.
+	_, _, err = ix.Insert(h, sha256.Sum256([]byte("other")), Location{Container: "b"})
+	require.ErrorIs(t, err, ErrCollision)
+	require.Equal(t, 1, ix.Refs(h))
+
+	actual, dup, err := ix.Insert(h, sha256.Sum256([]byte("chunk")), Location{Container: "b"})
+	require.NoError(t, err)
+	require.True(t, dup)
+	require.Equal(t, loc, actual)
+}
@@ -77,9 +77,8 @@ func Inv(a, b *GF127) {
 	var (
 		v    = x127x631
 		u    = *a
-		c, d = &GF127{1, 0}, &GF127{0, 0}
-		t    = new(GF127)
-		x    *GF127
+		c, d = GF127{1, 0}, GF127{0, 0}
+		t, x GF127
 	)
 
 	// degree of polynomial is a position of most significant bit
@@ -90,28 +89,34 @@ func Inv(a, b *GF127) {
 			d, c = c, d
 		}
 
-		x = xN(du - dv)
-
-		Mul(x, &v, t)
-		Add(&u, t, &u)
+		// u and v are polynomials of degree at most 127, not field elements,
+		// so v*x^(du-dv) is computed exactly, without reduction.
+		shl(&v, uint(du-dv), &t)
+		Add(&u, &t, &u)
 
-		// becasuse mulAVX performs reduction on t, we need
-		// manually reduce u at first step
-		if msb(&u) == 127 {
-			Add(&u, &x127x631, &u)
-		}
+		x = xN(du - dv)
+		Mul(&x, &d, &t)
+		Add(&c, &t, &c)
+	}
+	*b = c
+}
 
-		Mul(x, d, t)
-		Add(c, t, c)
+// shl sets b to a*x^n without reduction. It is assumed that n < 128.
+func shl(a *GF127, n uint, b *GF127) {
+	if n >= 64 {
+		b[1] = a[0] << (n - 64)
+		b[0] = 0
+		return
 	}
-	*b = *c
+	b[1] = a[1]<<n | a[0]>>(64-n)
+	b[0] = a[0] << n
 }
 
-func xN(n int) *GF127 {
+func xN(n int) GF127 {
 	if n < 64 {
-		return &GF127{1 << uint(n), 0}
+		return GF127{1 << uint(n), 0}
 	}
-	return &GF127{0, 1 << uint(n-64)}
+	return GF127{0, 1 << uint(n-64)}
 }
 
 func msb(a *GF127) (x int) {
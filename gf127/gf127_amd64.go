@@ -1,5 +1,5 @@
-//go:build amd64 && !generic
-// +build amd64,!generic
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
 
 // Package gf127 implements the GF(2^127) arithmetic
 // modulo reduction polynomial x^127 + x^63 + 1 .
@@ -49,7 +49,14 @@ func Mul11(a, b *GF127) {
 	}
 }
 
+//go:noescape
 func addAVX(a, b, c *GF127)
+
+//go:noescape
 func mulAVX(a, b, c *GF127)
+
+//go:noescape
 func mul10AVX(a, b *GF127)
+
+//go:noescape
 func mul11AVX(a, b *GF127)
@@ -1,3 +1,6 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
 #include "textflag.h"
 
 // func Add(a, b, c *[2]uint64)
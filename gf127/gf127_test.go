@@ -121,3 +121,12 @@ func TestGF127_MarshalBinary(t *testing.T) {
 	err = b.UnmarshalBinary([]byte{0, 1, 2, 3})
 	require.Error(t, err)
 }
+
+func BenchmarkInv(b *testing.B) {
+	a, c := &GF127{54321, 12345}, new(GF127)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Inv(a, c)
+	}
+}
@@ -1,5 +1,5 @@
-//go:build amd64 && !generic
-// +build amd64,!generic
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
 
 package gf127
 
@@ -23,5 +23,8 @@ func Mul11x2(a, b *GF127x2) {
 	}
 }
 
+//go:noescape
 func mul10x2AVX2(a, b *GF127x2)
+
+//go:noescape
 func mul11x2AVX2(a, b *GF127x2)
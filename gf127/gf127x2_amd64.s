@@ -1,9 +1,12 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
 #include "textflag.h"
 
 // func Mul10x2(a, b) *[4]uint64
 TEXT ·mul10x2AVX2(SB), NOSPLIT, $0
 	MOVQ        a+0(FP), AX
-	VMOVDQA     (AX), Y0
+	VMOVDQU     (AX), Y0
 	VPSLLQ      $1, Y0, Y1
 	VPALIGNR    $8, Y1, Y0, Y2
 	VPSRLQ      $63, Y2, Y2
@@ -13,13 +16,14 @@ TEXT ·mul10x2AVX2(SB), NOSPLIT, $0
 	VPUNPCKHQDQ Y3, Y3, Y3
 	VPXOR       Y2, Y3, Y3
 	MOVQ        b+8(FP), AX
-	VMOVDQA     Y3, (AX)
+	VMOVDQU     Y3, (AX)
+	VZEROUPPER
 	RET
 
 // func Mul11x2(a, b) *[4]uint64
 TEXT ·mul11x2AVX2(SB), NOSPLIT, $0
 	MOVQ        a+0(FP), AX
-	VMOVDQA     (AX), Y0
+	VMOVDQU     (AX), Y0
 	VPSLLQ      $1, Y0, Y1
 	VPALIGNR    $8, Y1, Y0, Y2
 	VPSRLQ      $63, Y2, Y2
@@ -30,5 +34,6 @@ TEXT ·mul11x2AVX2(SB), NOSPLIT, $0
 	VPXOR       Y2, Y3, Y3
 	VPXOR       Y0, Y3, Y3
 	MOVQ        b+8(FP), AX
-	VMOVDQA     Y3, (AX)
+	VMOVDQU     Y3, (AX)
+	VZEROUPPER
 	RET
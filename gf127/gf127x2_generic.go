@@ -1,5 +1,5 @@
-//go:build !(amd64 && !generic)
-// +build !amd64 generic
+//go:build !(amd64 && !generic && !purego && !tinygo)
+// +build !amd64 generic purego tinygo
 
 package gf127
 
@@ -0,0 +1,352 @@
+// Package gf2x implements arithmetic in binary fields GF(2^m) with
+// the reduction polynomial chosen at runtime.
+//
+// It is meant for prototyping variants of the hash with other fields,
+// package gf127 is much faster for GF(2^127). Fields of up to 128 bits use
+// fixed-size multiplication routines, larger ones use the generic one.
+// Reduction is fast for sparse polynomials like trinomials or pentanomials.
+package gf2x
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// MaxDegree is the maximal supported field degree.
+const MaxDegree = 4096
+
+// Element is an element of a field stored as little-endian 64-bit words.
+// It must be created by the field it is used with.
+type Element []uint64
+
+// Field is GF(2^m) modulo the irreducible polynomial
+// x^m + x^taps[0] + ... + x^taps[k].
+type Field struct {
+	m     int
+	words int
+	// taps are exponents of the reduction polynomial except m in
+	// descending order.
+	taps []int
+	mul  func(f *Field, a, b, c Element)
+}
+
+// NewField returns GF(2^m) with the reduction polynomial x^m + sum of x^t
+// for every t in taps. Taps must be distinct and less than m, the polynomial
+// must be irreducible. E.g. the field of package gf127 is NewField(127, 63, 0).
+func NewField(m int, taps ...int) (*Field, error) {
+	if m < 2 || m > MaxDegree {
+		return nil, fmt.Errorf("degree must be in [2, %d]", MaxDegree)
+	}
+
+	f := &Field{
+		m:     m,
+		words: (m + 63) / 64,
+		taps:  append([]int(nil), taps...),
+		mul:   mulGeneric,
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(f.taps)))
+	for i, t := range f.taps {
+		if t < 0 || t >= m {
+			return nil, fmt.Errorf("tap %d is out of range", t)
+		}
+		if i > 0 && t == f.taps[i-1] {
+			return nil, fmt.Errorf("duplicate tap %d", t)
+		}
+	}
+	switch f.words {
+	case 1:
+		f.mul = mul1
+	case 2:
+		f.mul = mul2
+	}
+	if !f.irreducible() {
+		return nil, errors.New("reduction polynomial is reducible")
+	}
+	return f, nil
+}
+
+// Degree returns the degree of the field.
+func (f *Field) Degree() int {
+	return f.m
+}
+
+// String returns the reduction polynomial.
+func (f *Field) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "x^%d", f.m)
+	for _, t := range f.taps {
+		switch t {
+		case 0:
+			b.WriteString(" + 1")
+		case 1:
+			b.WriteString(" + x")
+		default:
+			fmt.Fprintf(&b, " + x^%d", t)
+		}
+	}
+	return b.String()
+}
+
+// New returns zero element of the field.
+func (f *Field) New() Element {
+	return make(Element, f.words)
+}
+
+// One returns the multiplicative identity.
+func (f *Field) One() Element {
+	e := f.New()
+	e[0] = 1
+	return e
+}
+
+// SetBytes returns element with the big-endian representation data,
+// which must be (m+7)/8 bytes long and have no bits above the degree.
+func (f *Field) SetBytes(data []byte) (Element, error) {
+	if len(data) != (f.m+7)/8 {
+		return nil, fmt.Errorf("data must be %d bytes long", (f.m+7)/8)
+	}
+
+	e := f.New()
+	for i, v := range data {
+		pos := (len(data) - 1 - i) * 8
+		e[pos/64] |= uint64(v) << uint(pos%64)
+	}
+	if f.degree(e) >= f.m {
+		return nil, errors.New("element doesn't belong to the field")
+	}
+	return e, nil
+}
+
+// Bytes returns big-endian representation of a, (m+7)/8 bytes long.
+func (f *Field) Bytes(a Element) []byte {
+	data := make([]byte, (f.m+7)/8)
+	for i := range data {
+		pos := (len(data) - 1 - i) * 8
+		data[i] = byte(a[pos/64] >> uint(pos%64))
+	}
+	return data
+}
+
+// Equal checks if a and b are equal.
+func (f *Field) Equal(a, b Element) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZero checks if a is zero.
+func (f *Field) IsZero(a Element) bool {
+	for _, w := range a {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add sets c to a+b.
+func (f *Field) Add(a, b, c Element) {
+	for i := range c {
+		c[i] = a[i] ^ b[i]
+	}
+}
+
+// MulX sets b to a*x.
+func (f *Field) MulX(a, b Element) {
+	var carry uint64
+	for i := range a {
+		w := a[i]
+		b[i] = w<<1 | carry
+		carry = w >> 63
+	}
+	top := uint((f.m) % 64)
+	if top == 0 {
+		if carry == 0 {
+			return
+		}
+	} else {
+		last := &b[f.words-1]
+		if *last>>top&1 == 0 {
+			return
+		}
+		*last &^= 1 << top
+	}
+	for _, t := range f.taps {
+		b[t/64] ^= 1 << uint(t%64)
+	}
+}
+
+// Mul sets c to a*b. c can be the same as a or b.
+func (f *Field) Mul(a, b, c Element) {
+	f.mul(f, a, b, c)
+}
+
+// Square sets b to a^2.
+func (f *Field) Square(a, b Element) {
+	f.mul(f, a, a, b)
+}
+
+// Inv sets b to a^-1 computed as a^(2^m - 2). Inverse of zero is zero.
+func (f *Field) Inv(a, b Element) {
+	var (
+		r = f.One()
+		s = append(f.New()[:0], a...)
+	)
+	for i := 1; i < f.m; i++ {
+		f.Square(s, s)
+		f.Mul(r, s, r)
+	}
+	copy(b, r)
+}
+
+// degree returns the degree of a polynomial stored in words, -1 for zero.
+func (f *Field) degree(a []uint64) int {
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i] != 0 {
+			return i*64 + 63 - bits.LeadingZeros64(a[i])
+		}
+	}
+	return -1
+}
+
+// reduce sets c to the product r of degree less than 2m modulo
+// the reduction polynomial. r is modified, h must have f.words words.
+func (f *Field) reduce(r []uint64, c Element, h []uint64) {
+	for f.degree(r) >= f.m {
+		// h = r >> m, r = r mod x^m, r += h * (taps).
+		shiftRight(r, f.m, h)
+		clearFrom(r, f.m)
+		for _, t := range f.taps {
+			xorShifted(r, h, t)
+		}
+	}
+	copy(c, r[:f.words])
+}
+
+// shiftRight sets dst to src >> n, dst can be shorter than the result.
+func shiftRight(src []uint64, n int, dst []uint64) {
+	w, s := n/64, uint(n%64)
+	for i := range dst {
+		var v uint64
+		if i+w < len(src) {
+			v = src[i+w] >> s
+		}
+		if s != 0 && i+w+1 < len(src) {
+			v |= src[i+w+1] << (64 - s)
+		}
+		dst[i] = v
+	}
+}
+
+// clearFrom clears bits starting from n.
+func clearFrom(a []uint64, n int) {
+	w := n / 64
+	if w >= len(a) {
+		return
+	}
+	a[w] &= 1<<uint(n%64) - 1
+	for i := w + 1; i < len(a); i++ {
+		a[i] = 0
+	}
+}
+
+// xorShifted sets dst to dst ^ (src << n) dropping bits beyond dst.
+func xorShifted(dst, src []uint64, n int) {
+	w, s := n/64, uint(n%64)
+	for i := range src {
+		if i+w < len(dst) {
+			dst[i+w] ^= src[i] << s
+		}
+		if s != 0 && i+w+1 < len(dst) {
+			dst[i+w+1] ^= src[i] >> (64 - s)
+		}
+	}
+}
+
+// irreducible checks the reduction polynomial with Rabin's test:
+// x^(2^m) = x and gcd(x^(2^(m/p)) - x, f) = 1 for every prime p dividing m.
+func (f *Field) irreducible() bool {
+	if len(f.taps) == 0 || f.taps[len(f.taps)-1] != 0 {
+		return false // divisible by x
+	}
+
+	x := f.New()
+	x[0] = 2
+	pow := func(k int) Element { // x^(2^k)
+		r := append(f.New()[:0], x...)
+		for i := 0; i < k; i++ {
+			f.Square(r, r)
+		}
+		return r
+	}
+	if !f.Equal(pow(f.m), x) {
+		return false
+	}
+
+	poly := make([]uint64, f.m/64+1)
+	poly[f.m/64] |= 1 << uint(f.m%64)
+	for _, t := range f.taps {
+		poly[t/64] |= 1 << uint(t%64)
+	}
+	for _, p := range primeFactors(f.m) {
+		r := pow(f.m / p)
+		f.Add(r, x, r)
+		if g := gcd(poly, r); len(g) != 1 || g[0] != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func primeFactors(n int) []int {
+	var ps []int
+	for p := 2; p*p <= n; p++ {
+		if n%p == 0 {
+			ps = append(ps, p)
+			for n%p == 0 {
+				n /= p
+			}
+		}
+	}
+	if n > 1 {
+		ps = append(ps, n)
+	}
+	return ps
+}
+
+// gcd returns the greatest common divisor of polynomials a and b
+// with trailing zero words trimmed.
+func gcd(a, b []uint64) []uint64 {
+	a = trim(append([]uint64(nil), a...))
+	b = trim(append([]uint64(nil), b...))
+	for len(b) != 0 {
+		// a = a mod b
+		db := polyDegree(b)
+		for da := polyDegree(a); da >= db; da = polyDegree(a) {
+			xorShifted(a, b, da-db)
+			a = trim(a)
+		}
+		a, b = b, a
+	}
+	return a
+}
+
+func trim(a []uint64) []uint64 {
+	for len(a) != 0 && a[len(a)-1] == 0 {
+		a = a[:len(a)-1]
+	}
+	return a
+}
+
+func polyDegree(a []uint64) int {
+	if len(a) == 0 {
+		return -1
+	}
+	return (len(a)-1)*64 + 63 - bits.LeadingZeros64(a[len(a)-1])
+}
@@ -0,0 +1,162 @@
+package gf2x
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/gf127"
+	"github.com/stretchr/testify/require"
+)
+
+func random(f *Field) Element {
+	e := f.New()
+	for i := range e {
+		e[i] = rand.Uint64()
+	}
+	if top := uint(f.m % 64); top != 0 {
+		e[len(e)-1] &= 1<<top - 1
+	}
+	return e
+}
+
+func TestGF127(t *testing.T) {
+	f, err := NewField(127, 63, 0)
+	require.NoError(t, err)
+	require.Equal(t, "x^127 + x^63 + 1", f.String())
+
+	for i := 0; i < 1000; i++ {
+		a, b := gf127.Random(), gf127.Random()
+		ab, bb := a.Bytes(), b.Bytes()
+		x, err := f.SetBytes(ab[:])
+		require.NoError(t, err)
+		y, err := f.SetBytes(bb[:])
+		require.NoError(t, err)
+
+		var c gf127.GF127
+		gf127.Mul(a, b, &c)
+		cb := c.Bytes()
+		z := f.New()
+		f.Mul(x, y, z)
+		require.Equal(t, cb[:], f.Bytes(z))
+
+		gf127.Add(a, b, &c)
+		cb = c.Bytes()
+		f.Add(x, y, z)
+		require.Equal(t, cb[:], f.Bytes(z))
+
+		gf127.Mul10(a, &c)
+		cb = c.Bytes()
+		f.MulX(x, z)
+		require.Equal(t, cb[:], f.Bytes(z))
+
+		gf127.Inv(a, &c)
+		cb = c.Bytes()
+		f.Inv(x, z)
+		require.Equal(t, cb[:], f.Bytes(z))
+	}
+}
+
+func TestField(t *testing.T) {
+	polys := [][]int{
+		{2, 1, 0},
+		{8, 4, 3, 1, 0},
+		{64, 4, 3, 1, 0},
+		{113, 9, 0},
+		{191, 9, 0},
+		{255, 52, 0},
+		{571, 10, 5, 2, 0},
+	}
+	for _, p := range polys {
+		f, err := NewField(p[0], p[1:]...)
+		require.NoError(t, err, "degree %d", p[0])
+		require.Equal(t, p[0], f.Degree())
+
+		for i := 0; i < 100; i++ {
+			a, b, c := random(f), random(f), random(f)
+
+			ab, ba := f.New(), f.New()
+			f.Mul(a, b, ab)
+			f.Mul(b, a, ba)
+			require.True(t, f.Equal(ab, ba))
+
+			// Specialized multiplication must match the generic one.
+			g := f.New()
+			mulGeneric(f, a, b, g)
+			require.True(t, f.Equal(ab, g))
+
+			// a*(b+c) = a*b + a*c
+			s, l, r := f.New(), f.New(), f.New()
+			f.Add(b, c, s)
+			f.Mul(a, s, l)
+			f.Mul(a, c, r)
+			f.Add(r, ab, r)
+			require.True(t, f.Equal(l, r))
+
+			// a*x
+			x := f.New()
+			x[0] = 2
+			f.Mul(a, x, l)
+			f.MulX(a, r)
+			require.True(t, f.Equal(l, r))
+
+			if f.IsZero(a) {
+				continue
+			}
+			inv := f.New()
+			f.Inv(a, inv)
+			f.Mul(a, inv, inv)
+			require.True(t, f.Equal(f.One(), inv))
+
+			e, err := f.SetBytes(f.Bytes(a))
+			require.NoError(t, err)
+			require.True(t, f.Equal(a, e))
+		}
+	}
+}
+
+func TestNewField(t *testing.T) {
+	for _, p := range [][]int{
+		{1, 0},
+		{MaxDegree + 1, 0},
+		{8},
+		{8, 0},          // x^8 + 1 = (x+1)^8
+		{8, 4, 3, 1},    // divisible by x
+		{8, 8, 0},       // tap out of range
+		{8, 3, 3, 0},    // duplicate tap
+		{8, 5, 2, 1, 0}, // divisible by x^2 + x + 1
+		{127, 2, 0},
+	} {
+		_, err := NewField(p[0], p[1:]...)
+		require.Error(t, err, "%v", p)
+	}
+}
+
+func TestSetBytes(t *testing.T) {
+	f, err := NewField(8, 4, 3, 1, 0)
+	require.NoError(t, err)
+
+	_, err = f.SetBytes([]byte{1, 2})
+	require.Error(t, err)
+
+	f, err = NewField(113, 9, 0)
+	require.NoError(t, err)
+	data := make([]byte, 15)
+	data[0] = 0x02
+	_, err = f.SetBytes(data)
+	require.Error(t, err)
+	data[0] = 0x01
+	_, err = f.SetBytes(data)
+	require.NoError(t, err)
+}
+
+func BenchmarkMul(b *testing.B) {
+	for _, p := range [][]int{{64, 4, 3, 1, 0}, {127, 63, 0}, {255, 52, 0}} {
+		f, _ := NewField(p[0], p[1:]...)
+		x, y, z := random(f), random(f), f.New()
+		b.Run(f.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				f.Mul(x, y, z)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gf2x
+
+// clmul64 returns carry-less product of a and b. It uses 4-bit windows:
+// the lower 61 bits of a are multiplied by every 4-bit polynomial, so that
+// the products fit into 64 bits, the upper 3 bits are handled separately.
+func clmul64(a, b uint64) (hi, lo uint64) {
+	var t [16]uint64
+
+	a0 := a &^ (7 << 61)
+	for u := 1; u < 16; u++ {
+		t[u] = t[u>>1] << 1
+		if u&1 != 0 {
+			t[u] ^= a0
+		}
+	}
+
+	for i := 60; i >= 0; i -= 4 {
+		hi = hi<<4 | lo>>60
+		lo = lo<<4 ^ t[b>>uint(i)&15]
+	}
+	for j := uint(61); j < 64; j++ {
+		m := -(a >> j & 1)
+		lo ^= b << j & m
+		hi ^= b >> (64 - j) & m
+	}
+	return hi, lo
+}
+
+// mul1 multiplies elements of fields of at most 64 bits.
+func mul1(f *Field, a, b, c Element) {
+	var r [2]uint64
+	var h [1]uint64
+
+	r[1], r[0] = clmul64(a[0], b[0])
+	f.reduce(r[:], c, h[:])
+}
+
+// mul2 multiplies elements of fields of at most 128 bits
+// using Karatsuba multiplication.
+func mul2(f *Field, a, b, c Element) {
+	var r [4]uint64
+	var h [2]uint64
+
+	h0, l0 := clmul64(a[0], b[0])
+	h1, l1 := clmul64(a[1], b[1])
+	hm, lm := clmul64(a[0]^a[1], b[0]^b[1])
+	hm ^= h0 ^ h1
+	lm ^= l0 ^ l1
+
+	r[0] = l0
+	r[1] = h0 ^ lm
+	r[2] = l1 ^ hm
+	r[3] = h1
+	f.reduce(r[:], c, h[:])
+}
+
+// mulGeneric multiplies elements of any field word by word.
+func mulGeneric(f *Field, a, b, c Element) {
+	r := make([]uint64, 2*f.words)
+	for i := range a {
+		for j := range b {
+			hi, lo := clmul64(a[i], b[j])
+			r[i+j] ^= lo
+			r[i+j+1] ^= hi
+		}
+	}
+	f.reduce(r, c, make([]uint64, f.words))
+}
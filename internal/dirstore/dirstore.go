@@ -0,0 +1,88 @@
+// Package dirstore implements storage of small state files in a directory
+// shared by multipart and checkpoint packages.
+package dirstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Dir keeps every state in a separate file of the directory named by the
+// state ID and the extension.
+type Dir struct {
+	Path string
+	Ext  string
+}
+
+// Load returns the state saved with the specified ID, os.ErrNotExist is
+// returned if there is none.
+func (d Dir) Load(id string) ([]byte, error) {
+	return os.ReadFile(d.path(id))
+}
+
+// Save replaces the state atomically and durably: new data is written to
+// a temporary file which is synced to disk before it is renamed, then the
+// directory is synced too, so after a crash either the old or the new state
+// is seen.
+func (d Dir) Save(id string, state []byte) error {
+	name := d.path(id)
+	tmp := name + ".tmp"
+	if err := writeFile(tmp, state); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return syncDir(d.Path)
+}
+
+// Delete removes the state, missing state is not an error.
+func (d Dir) Delete(id string) error {
+	err := os.Remove(d.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (d Dir) path(id string) string {
+	return filepath.Join(d.Path, id+d.Ext)
+}
+
+// writeFile writes data to the named file and syncs it.
+func writeFile(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes the rename durable. Directories can't be synced on Windows,
+// renames are durable there once MoveFileEx returns.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package dirstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	d := Dir{Path: t.TempDir(), Ext: ".state"}
+
+	_, err := d.Load("id")
+	require.True(t, errors.Is(err, os.ErrNotExist), err)
+	require.NoError(t, d.Delete("id"))
+
+	require.NoError(t, d.Save("id", []byte("first")))
+	require.NoError(t, d.Save("id", []byte("second")))
+
+	data, err := d.Load("id")
+	require.NoError(t, err)
+	require.Equal(t, []byte("second"), data)
+
+	files, err := os.ReadDir(d.Path)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "id.state", files[0].Name())
+
+	require.NoError(t, d.Delete("id"))
+	_, err = d.Load("id")
+	require.True(t, errors.Is(err, os.ErrNotExist), err)
+
+	t.Run("missing directory", func(t *testing.T) {
+		d := Dir{Path: filepath.Join(d.Path, "missing")}
+		require.Error(t, d.Save("id", nil))
+	})
+}
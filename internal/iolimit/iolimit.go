@@ -0,0 +1,90 @@
+// Package iolimit implements throughput limiting for readers shared by
+// command-line tools.
+package iolimit
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chunk is the maximum amount of data read at once by limited reader,
+// it makes throttling smoother.
+const chunk = 64 * 1024
+
+// Limiter limits the total throughput of all readers using it.
+type Limiter struct {
+	mtx  sync.Mutex
+	rate float64 // bytes per second
+	next time.Time
+}
+
+// NewLimiter returns limiter allowing rate bytes per second.
+func NewLimiter(rate int64) *Limiter {
+	return &Limiter{rate: float64(rate)}
+}
+
+// Wait blocks until n more bytes can be consumed.
+func (l *Limiter) Wait(n int) {
+	l.mtx.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	d := l.next.Sub(now)
+	l.mtx.Unlock()
+
+	time.Sleep(d)
+}
+
+// Reader returns reader which reads from r no faster than l allows.
+// Nil limiter returns r as is.
+func (l *Limiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &reader{r: r, l: l}
+}
+
+type reader struct {
+	r io.Reader
+	l *Limiter
+}
+
+func (t *reader) Read(p []byte) (int, error) {
+	if len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	t.l.Wait(n)
+	return n, err
+}
+
+// ParseSize parses positive size in bytes with an optional K, M or G suffix.
+func ParseSize(s string) (int64, error) {
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult != 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.New("size must be positive")
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,25 @@
+package iolimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"1":   1,
+		"10K": 10 << 10,
+		"3M":  3 << 20,
+		"2G":  2 << 30,
+	} {
+		n, err := ParseSize(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, n, s)
+	}
+
+	for _, s := range []string{"", "K", "0", "-1M", "1T"} {
+		_, err := ParseSize(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,88 @@
+// Package protowire implements the subset of protobuf wire format
+// used by hash exchange messages.
+package protowire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Wire types.
+const (
+	Varint  = 0
+	Fixed64 = 1
+	Bytes   = 2
+	Fixed32 = 5
+)
+
+// AppendVarint appends unsigned varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// AppendVarintField appends varint field.
+func AppendVarintField(b []byte, num int, v uint64) []byte {
+	b = AppendVarint(b, uint64(num)<<3|Varint)
+	return AppendVarint(b, v)
+}
+
+// AppendBytesField appends length-delimited field.
+func AppendBytesField(b []byte, num int, v []byte) []byte {
+	b = AppendVarint(b, uint64(num)<<3|Bytes)
+	b = AppendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// Field is a decoded message field. Value contains varint value,
+// Data contains length-delimited field data, fixed-size fields are
+// only skipped.
+type Field struct {
+	Num   int
+	Wire  int
+	Value uint64
+	Data  []byte
+}
+
+// Range calls f for every field of the message in order.
+func Range(b []byte, f func(Field) error) error {
+	for len(b) != 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 || key>>3 == 0 || key>>3 > 1<<29-1 {
+			return errors.New("invalid field key")
+		}
+		b = b[n:]
+
+		fd := Field{Num: int(key >> 3), Wire: int(key & 7)}
+		switch fd.Wire {
+		case Varint:
+			if fd.Value, n = binary.Uvarint(b); n <= 0 {
+				return errors.New("invalid varint field")
+			}
+			b = b[n:]
+		case Bytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return errors.New("invalid length-delimited field")
+			}
+			fd.Data, b = b[n:n+int(l)], b[n+int(l):]
+		case Fixed64, Fixed32:
+			size := 8
+			if fd.Wire == Fixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return errors.New("invalid fixed-size field")
+			}
+			b = b[size:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", fd.Wire)
+		}
+		if err := f(fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
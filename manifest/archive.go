@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FromTar reads tar archive from r and returns manifest of its regular file
+// members without extracting them. Member paths are cleaned and, like in
+// Build, sorted, so the manifest matches the one of the extracted tree.
+// If an archive contains several members with the same path, the last one
+// is used as it would be after extraction. Workers option is ignored.
+func FromTar(r io.Reader, opts ...Option) (*Manifest, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		es = make(map[string]Entry)
+		tr = tar.NewReader(r)
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := cleanPath(hdr.Name)
+		if !c.matches(name) {
+			continue
+		}
+		e := Entry{Path: name, Size: hdr.Size, ModTime: hdr.ModTime}
+		if err := c.sumMember(&e, tr); err != nil {
+			return nil, err
+		}
+		es[name] = e
+	}
+	return c.fromEntries(es)
+}
+
+// FromZip returns manifest of regular file members of zip archive of the
+// specified size read from r, see FromTar. Workers option is ignored.
+func FromZip(r io.ReaderAt, size int64, opts ...Option) (*Manifest, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	es := make(map[string]Entry)
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		name := cleanPath(f.Name)
+		if !c.matches(name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		e := Entry{Path: name, Size: int64(f.UncompressedSize64), ModTime: f.Modified}
+		err = c.sumMember(&e, rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		es[name] = e
+	}
+	return c.fromEntries(es)
+}
+
+// cleanPath returns slash-separated member path relative to the archive root.
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func (c *config) sumMember(e *Entry, r io.Reader) error {
+	if c.wrap != nil {
+		r = c.wrap(e.Path, e.Size, r)
+	}
+	if err := c.sum(e, r); err != nil {
+		return fmt.Errorf("%s: %w", e.Path, err)
+	}
+	return nil
+}
+
+func (c *config) fromEntries(es map[string]Entry) (*Manifest, error) {
+	m := &Manifest{Entries: make([]Entry, 0, len(es)), ChunkSize: c.chunkSize}
+	for _, e := range es {
+		m.Entries = append(m.Entries, e)
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	return m, m.setRoot()
+}
@@ -0,0 +1,127 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sortedPaths(fsys map[string][]byte) []string {
+	var names []string
+	for name := range fsys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func testTar(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./a/", Typeflag: tar.TypeDir, Mode: 0755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "a.txt"}))
+	// Overwritten by the next member with the same path.
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Size: 3, Mode: 0644}))
+	_, err := tw.Write([]byte("old"))
+	require.NoError(t, err)
+
+	for _, name := range sortedPaths(files) {
+		data := files[name]
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     "./" + name,
+			Typeflag: tar.TypeReg,
+			Size:     int64(len(data)),
+			Mode:     0644,
+			ModTime:  time.Unix(1600000000, 0),
+		}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func testZip(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	_, err := zw.Create("a/")
+	require.NoError(t, err)
+	for _, name := range sortedPaths(files) {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	fsys := testFS()
+	files := make(map[string][]byte)
+	for name, f := range fsys {
+		files[name] = f.Data
+	}
+
+	expected, err := Build(fsys)
+	require.NoError(t, err)
+
+	check := func(t *testing.T, m *Manifest) {
+		require.Equal(t, paths(expected), paths(m))
+		for i := range m.Entries {
+			require.Equal(t, expected.Entries[i].Hash, m.Entries[i].Hash)
+			require.Equal(t, expected.Entries[i].Size, m.Entries[i].Size)
+		}
+		require.Equal(t, expected.Root, m.Root)
+	}
+
+	t.Run("tar", func(t *testing.T) {
+		m, err := FromTar(bytes.NewReader(testTar(t, files)))
+		require.NoError(t, err)
+		check(t, m)
+	})
+	t.Run("zip", func(t *testing.T) {
+		data := testZip(t, files)
+		m, err := FromZip(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		check(t, m)
+	})
+	t.Run("filters", func(t *testing.T) {
+		m, err := FromTar(bytes.NewReader(testTar(t, files)), WithExclude("tmp", ".*", "a/*.log"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a.txt", "a/b", "z/empty.txt"}, paths(m))
+
+		data := testZip(t, files)
+		m, err = FromZip(bytes.NewReader(data), int64(len(data)), WithInclude("*.txt"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a.txt", "z/empty.txt"}, paths(m))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		data := testTar(t, files)
+		_, err := FromTar(bytes.NewReader(data[:len(data)/2]))
+		require.Error(t, err)
+
+		data = testZip(t, files)
+		_, err = FromZip(bytes.NewReader(data[:len(data)-10]), int64(len(data)-10))
+		require.Error(t, err)
+	})
+}
+
+func TestCleanPath(t *testing.T) {
+	for name, expected := range map[string]string{
+		"a":         "a",
+		"./a/b":     "a/b",
+		"/a//b":     "a/b",
+		"../../a":   "a",
+		"a/../../b": "b",
+	} {
+		require.Equal(t, expected, cleanPath(name), name)
+	}
+}
@@ -0,0 +1,225 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Comment lines extending the text form of manifests with file chunks.
+// Like the root line, they are ignored by tools which are not aware of them.
+const (
+	// ChunkSizePrefix starts the line with the chunk size, which precedes
+	// all file lines.
+	ChunkSizePrefix = "# chunk-size: "
+
+	// ChunkPrefix starts the line with a chunk hash of the preceding file.
+	ChunkPrefix = "# chunk: "
+)
+
+// WriteTo writes manifest in the tzsum format including the root line.
+// If the manifest has chunk size, it is written first and every file line
+// is followed by its chunk lines. It implements io.WriterTo.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	var (
+		total int64
+		bw    = bufio.NewWriter(w)
+	)
+	printf := func(format string, args ...interface{}) {
+		n, _ := fmt.Fprintf(bw, format, args...)
+		total += int64(n)
+	}
+
+	if m.ChunkSize != 0 {
+		printf("%s%d\n", ChunkSizePrefix, m.ChunkSize)
+	}
+	for i := range m.Entries {
+		printf("%x  %s\n", m.Entries[i].Hash, m.Entries[i].Path)
+		for j := range m.Entries[i].Chunks {
+			printf("%s%x\n", ChunkPrefix, m.Entries[i].Chunks[j])
+		}
+	}
+	printf("%s%x\n", RootPrefix, m.Root)
+
+	// Write errors are sticky, the first one is returned by Flush.
+	if err := bw.Flush(); err != nil {
+		return total - int64(bw.Buffered()), err
+	}
+	return total, nil
+}
+
+// Read parses manifest in the text form written by WriteTo. File lines
+// can also be BSD-style ones, other comments and empty lines are skipped.
+// If there is no root line, the root is computed, otherwise the manifest
+// is validated. Sizes and modification times are not a part of the text form.
+func Read(r io.Reader) (*Manifest, error) {
+	var (
+		m       = new(Manifest)
+		hasRoot bool
+		s       = bufio.NewScanner(r)
+	)
+	for i := 1; s.Scan(); i++ {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, ChunkSizePrefix):
+			n, err := strconv.ParseInt(line[len(ChunkSizePrefix):], 10, 64)
+			if err != nil || n <= 0 || len(m.Entries) != 0 {
+				return nil, fmt.Errorf("line %d: invalid chunk size", i)
+			}
+			m.ChunkSize = n
+		case strings.HasPrefix(line, ChunkPrefix):
+			h, ok := ParseHash(line[len(ChunkPrefix):])
+			if !ok || m.ChunkSize == 0 || len(m.Entries) == 0 {
+				return nil, fmt.Errorf("line %d: invalid chunk", i)
+			}
+			e := &m.Entries[len(m.Entries)-1]
+			e.Chunks = append(e.Chunks, h)
+		case strings.HasPrefix(line, RootPrefix):
+			var ok bool
+			if m.Root, ok = ParseHash(line[len(RootPrefix):]); !ok {
+				return nil, fmt.Errorf("line %d: invalid root", i)
+			}
+			hasRoot = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			h, file, ok := ParseLine(line)
+			if !ok {
+				return nil, fmt.Errorf("line %d: malformed checksum line", i)
+			}
+			m.Entries = append(m.Entries, Entry{Path: file, Hash: h})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !hasRoot {
+		if err := m.setRoot(); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks that chunk hashes of every file combined are equal
+// to its hash and the root is the combination of file hashes.
+func (m *Manifest) Validate() error {
+	if m.ChunkSize < 0 {
+		return errors.New("negative chunk size")
+	}
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		if m.ChunkSize == 0 {
+			if len(e.Chunks) != 0 {
+				return fmt.Errorf("%s: chunks without chunk size", e.Path)
+			}
+			continue
+		}
+		if h, err := combine(e.Chunks); err != nil || h != e.Hash {
+			return fmt.Errorf("%s: chunks don't match file hash", e.Path)
+		}
+	}
+
+	root, err := combine(entryHashes(m.Entries))
+	if err != nil || root != m.Root {
+		return errors.New("root doesn't match file hashes")
+	}
+	return nil
+}
+
+func entryHashes(es []Entry) [][tz.Size]byte {
+	hs := make([][tz.Size]byte, len(es))
+	for i := range es {
+		hs[i] = es[i].Hash
+	}
+	return hs
+}
+
+type jsonManifest struct {
+	ChunkSize int64       `json:"chunkSize,omitempty"`
+	Files     []jsonEntry `json:"files"`
+	Root      string      `json:"root"`
+}
+
+type jsonEntry struct {
+	Path    string     `json:"path"`
+	Hash    string     `json:"hash"`
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"modTime,omitempty"`
+	Chunks  []string   `json:"chunks,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Hashes are hex-encoded,
+// zero sizes and modification times are omitted.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	jm := jsonManifest{
+		ChunkSize: m.ChunkSize,
+		Files:     make([]jsonEntry, len(m.Entries)),
+		Root:      hex.EncodeToString(m.Root[:]),
+	}
+	for i, e := range m.Entries {
+		je := &jm.Files[i]
+		je.Path = e.Path
+		je.Hash = hex.EncodeToString(e.Hash[:])
+		je.Size = e.Size
+		if !e.ModTime.IsZero() {
+			t := e.ModTime
+			je.ModTime = &t
+		}
+		for _, c := range e.Chunks {
+			je.Chunks = append(je.Chunks, hex.EncodeToString(c[:]))
+		}
+	}
+	return json.Marshal(jm)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Decoded manifest is validated.
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	var jm jsonManifest
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+
+	res := Manifest{ChunkSize: jm.ChunkSize, Entries: make([]Entry, len(jm.Files))}
+	var ok bool
+	if res.Root, ok = ParseHash(jm.Root); !ok {
+		return errors.New("invalid root")
+	}
+	for i, je := range jm.Files {
+		e := &res.Entries[i]
+		e.Path, e.Size = je.Path, je.Size
+		if e.Path == "" {
+			return fmt.Errorf("file %d: empty path", i)
+		}
+		if e.Hash, ok = ParseHash(je.Hash); !ok {
+			return fmt.Errorf("%s: invalid hash", e.Path)
+		}
+		if je.ModTime != nil {
+			e.ModTime = *je.ModTime
+		}
+		for _, s := range je.Chunks {
+			h, ok := ParseHash(s)
+			if !ok {
+				return fmt.Errorf("%s: invalid chunk hash", e.Path)
+			}
+			e.Chunks = append(e.Chunks, h)
+		}
+	}
+	if err := res.Validate(); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
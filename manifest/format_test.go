@@ -0,0 +1,138 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkedFS() fstest.MapFS {
+	return fstest.MapFS{
+		"big":   {Data: []byte("0123456789abcdef!"), ModTime: time.Unix(1600000000, 0).UTC()},
+		"empty": {Data: nil},
+		"small": {Data: []byte("abc")},
+	}
+}
+
+func TestBuildChunks(t *testing.T) {
+	fsys := chunkedFS()
+
+	m, err := Build(fsys, WithChunkSize(8))
+	require.NoError(t, err)
+	require.EqualValues(t, 8, m.ChunkSize)
+	require.NoError(t, m.Validate())
+
+	data := fsys["big"].Data
+	require.Equal(t, [][tz.Size]byte{tz.Sum(data[:8]), tz.Sum(data[8:16]), tz.Sum(data[16:])}, m.Entries[0].Chunks)
+	require.Equal(t, tz.Sum(data), m.Entries[0].Hash)
+	require.Empty(t, m.Entries[1].Chunks)
+	require.Equal(t, tz.Sum(nil), m.Entries[1].Hash)
+	require.Len(t, m.Entries[2].Chunks, 1)
+
+	plain, err := Build(fsys)
+	require.NoError(t, err)
+	require.Equal(t, plain.Root, m.Root)
+
+	archived, err := FromTar(bytes.NewReader(testTar(t, map[string][]byte{"big": data})), WithChunkSize(8))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "big"}, paths(archived))
+	require.Equal(t, m.Entries[0].Chunks, archived.Entries[1].Chunks)
+}
+
+func TestReadText(t *testing.T) {
+	for _, chunkSize := range []int64{0, 8} {
+		m, err := Build(chunkedFS(), WithChunkSize(chunkSize))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		n, err := m.WriteTo(&buf)
+		require.NoError(t, err)
+		require.EqualValues(t, buf.Len(), n)
+
+		actual, err := Read(&buf)
+		require.NoError(t, err)
+		require.Equal(t, m.ChunkSize, actual.ChunkSize)
+		require.Equal(t, m.Root, actual.Root)
+		require.Equal(t, paths(m), paths(actual))
+		for i := range m.Entries {
+			require.Equal(t, m.Entries[i].Hash, actual.Entries[i].Hash)
+			require.Equal(t, m.Entries[i].Chunks, actual.Entries[i].Chunks)
+		}
+	}
+
+	t.Run("without root", func(t *testing.T) {
+		h := tz.Sum([]byte("a"))
+		m, err := Read(strings.NewReader(
+			"# comment\n\n" + tz.Hash(h).String() + "  a\r\n" + TagName + " (b) = " + tz.Hash(h).String() + "\n"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, paths(m))
+
+		root, err := tz.Concat([][]byte{h[:], h[:]})
+		require.NoError(t, err)
+		require.Equal(t, root, m.Root[:])
+	})
+	t.Run("invalid", func(t *testing.T) {
+		m, err := Build(chunkedFS(), WithChunkSize(8))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		_, err = m.WriteTo(&buf)
+		require.NoError(t, err)
+		text := buf.String()
+		lines := strings.Split(text, "\n")
+
+		h := tz.Hash(tz.Sum(nil)).String()
+		for _, s := range []string{
+			"malformed\n",
+			ChunkPrefix + h + "\n",
+			ChunkSizePrefix + "0\n",
+			ChunkSizePrefix + "x\n",
+			RootPrefix + "00\n",
+			h + "  a\n" + ChunkSizePrefix + "8\n",
+			h + "  a\n" + ChunkPrefix + h + "\n",
+			h + "  a\n" + RootPrefix + tz.Hash(tz.Sum([]byte("x"))).String() + "\n",
+			strings.Join(append(lines[:2:2], lines[3:]...), "\n"), // chunk is missing
+		} {
+			_, err := Read(strings.NewReader(s))
+			require.Error(t, err, s)
+		}
+	})
+}
+
+func TestJSON(t *testing.T) {
+	m, err := Build(chunkedFS(), WithChunkSize(8))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	var actual Manifest
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Equal(t, *m, actual)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Equal(t, tz.Hash(m.Root).String(), raw["root"])
+	require.EqualValues(t, 8, raw["chunkSize"])
+
+	t.Run("invalid", func(t *testing.T) {
+		h := tz.Hash(tz.Sum(nil)).String()
+		for _, s := range []string{
+			`[]`,
+			`{"files":[],"root":"00"}`,
+			`{"files":[{"path":"a","hash":"00"}],"root":"` + h + `"}`,
+			`{"files":[{"hash":"` + h + `"}],"root":"` + h + `"}`,
+			`{"files":[{"path":"a","hash":"` + h + `","chunks":["` + h + `"]}],"root":"` + h + `"}`,
+			`{"chunkSize":1,"files":[{"path":"a","hash":"` + h + `","chunks":["00"]}],"root":"` + h + `"}`,
+		} {
+			var m Manifest
+			require.Error(t, json.Unmarshal([]byte(s), &m), s)
+		}
+	})
+}
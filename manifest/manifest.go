@@ -0,0 +1,62 @@
+// Package manifest implements building and parsing of checksum manifests
+// in the format of tzsum: "HASH  PATH" lines sorted by path optionally
+// followed by "# root: HASH" line with the combined hash of all files.
+// Manifests can also contain hashes of file chunks of the same size
+// and have JSON form, see Manifest.
+package manifest
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+const (
+	// RootPrefix starts manifest line containing combined hash of all files.
+	// It is a comment for tools which are not aware of it.
+	RootPrefix = "# root: "
+
+	// TagName is the algorithm name used in BSD-style checksum lines.
+	TagName = "TZ"
+)
+
+// ParseLine parses manifest line in 'HASH  FILENAME' or BSD-style
+// 'TZ (FILENAME) = HASH' format. As in coreutils, '*' before the file name
+// (binary mode) is allowed.
+func ParseLine(line string) (h [tz.Size]byte, file string, ok bool) {
+	const hexSize = 2 * tz.Size
+
+	if prefix := TagName + " ("; strings.HasPrefix(line, prefix) {
+		i := strings.LastIndex(line, ") = ")
+		if i < len(prefix) {
+			return h, "", false
+		}
+		file = line[len(prefix):i]
+		h, ok = ParseHash(line[i+4:])
+		return h, file, ok && file != ""
+	}
+
+	if len(line) < hexSize+2 || line[hexSize] != ' ' {
+		return h, "", false
+	}
+	if line[hexSize+1] != ' ' && line[hexSize+1] != '*' {
+		return h, "", false
+	}
+	if h, ok = ParseHash(line[:hexSize]); !ok {
+		return h, "", false
+	}
+
+	file = line[hexSize+2:]
+	return h, file, file != ""
+}
+
+// ParseHash parses hex-encoded hash.
+func ParseHash(s string) (h [tz.Size]byte, ok bool) {
+	b, err := hex.DecodeString(strings.ToLower(s))
+	if err != nil || len(b) != tz.Size {
+		return h, false
+	}
+	copy(h[:], b)
+	return h, true
+}
@@ -0,0 +1,41 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	hexHash := strings.Repeat("0a", tz.Size)
+	var expected [tz.Size]byte
+	for i := range expected {
+		expected[i] = 0x0a
+	}
+
+	testCases := []struct {
+		line string
+		file string
+		ok   bool
+	}{
+		{hexHash + "  file", "file", true},
+		{hexHash + " *file", "file", true},
+		{strings.ToUpper(hexHash) + "  a b", "a b", true},
+		{"TZ (a) = b) = " + hexHash, "a) = b", true},
+		{hexHash + " file", "", false},
+		{hexHash + "  ", "", false},
+		{hexHash[2:] + "  file", "", false},
+		{"TZ () = " + hexHash, "", false},
+		{"TZ (file) = 01", "", false},
+	}
+	for _, tc := range testCases {
+		h, file, ok := ParseLine(tc.line)
+		require.Equal(t, tc.ok, ok, tc.line)
+		if ok {
+			require.Equal(t, tc.file, file)
+			require.Equal(t, expected, h)
+		}
+	}
+}
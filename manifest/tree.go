@@ -0,0 +1,286 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Entry is a checksum of a single file.
+type Entry struct {
+	Path    string // slash-separated path relative to the tree root
+	Hash    [tz.Size]byte
+	Size    int64
+	ModTime time.Time
+	// Chunks are hashes of consecutive chunks of the file if the manifest
+	// has chunk size. Their combination is Hash.
+	Chunks [][tz.Size]byte
+}
+
+// Manifest contains checksums of all files in a tree sorted by path
+// and their combined hash.
+type Manifest struct {
+	Entries []Entry
+	// ChunkSize is the size of file chunks hashes of which are stored
+	// in entries, 0 if there are none.
+	ChunkSize int64
+	Root      [tz.Size]byte
+}
+
+// Option configures Build.
+type Option func(*config)
+
+type config struct {
+	include   []string
+	exclude   []string
+	workers   int
+	chunkSize int64
+	wrap      func(name string, size int64, r io.Reader) io.Reader
+}
+
+// WithInclude restricts the manifest to files which path or base name
+// matches any of the patterns in the path.Match syntax.
+func WithInclude(patterns ...string) Option {
+	return func(c *config) {
+		c.include = append(c.include, patterns...)
+	}
+}
+
+// WithExclude skips files and directories which path or base name
+// matches any of the patterns in the path.Match syntax.
+func WithExclude(patterns ...string) Option {
+	return func(c *config) {
+		c.exclude = append(c.exclude, patterns...)
+	}
+}
+
+// WithWorkers sets the number of files hashed concurrently, 1 by default.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithChunkSize makes manifest contain hashes of file chunks of size n
+// in addition to hashes of whole files.
+func WithChunkSize(n int64) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.chunkSize = n
+		}
+	}
+}
+
+// WithReader sets function wrapping every file reader, e.g. to limit
+// throughput or to report progress. It is called concurrently if the
+// number of workers is greater than 1.
+func WithReader(wrap func(name string, size int64, r io.Reader) io.Reader) Option {
+	return func(c *config) {
+		c.wrap = wrap
+	}
+}
+
+// Build hashes all regular files in fsys and returns their manifest.
+// Files are sorted by slash-separated path, so the result doesn't depend
+// on the order of directory entries. Root of the empty manifest is
+// the hash of empty data.
+func Build(fsys fs.FS, opts ...Option) (*Manifest, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Manifest{ChunkSize: c.chunkSize}
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && name != "." && matchAny(c.exclude, name) {
+			return fs.SkipDir
+		}
+		if d.Type().IsRegular() && c.matches(name) {
+			m.Entries = append(m.Entries, Entry{Path: name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	if err := c.sumEntries(fsys, m.Entries); err != nil {
+		return nil, err
+	}
+	return &m, m.setRoot()
+}
+
+func newConfig(opts []Option) (*config, error) {
+	c := &config{workers: 1}
+	for _, o := range opts {
+		o(c)
+	}
+
+	if err := validatePatterns(c.include); err != nil {
+		return nil, err
+	}
+	if err := validatePatterns(c.exclude); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// matches returns true if the file passes filters: neither it nor any
+// of its parent directories is excluded and it is included if there are
+// include patterns.
+func (c *config) matches(name string) bool {
+	for p := name; p != "." && p != "/"; p = path.Dir(p) {
+		if matchAny(c.exclude, p) {
+			return false
+		}
+	}
+	return len(c.include) == 0 || matchAny(c.include, name)
+}
+
+// setRoot sets the root to the combined hash of entries.
+func (m *Manifest) setRoot() error {
+	root, err := combine(entryHashes(m.Entries))
+	m.Root = root
+	return err
+}
+
+// combine returns combined hash of hs, which is the hash of empty data
+// if there are none.
+func combine(hs [][tz.Size]byte) ([tz.Size]byte, error) {
+	var res [tz.Size]byte
+	if len(hs) == 0 {
+		return tz.Sum(nil), nil
+	}
+
+	bs := make([][]byte, len(hs))
+	for i := range hs {
+		bs[i] = hs[i][:]
+	}
+	c, err := tz.Concat(bs)
+	if err != nil {
+		return res, err
+	}
+	copy(res[:], c)
+	return res, nil
+}
+
+// sumEntries hashes files of entries using at most c.workers goroutines.
+// The first error encountered is returned.
+func (c *config) sumEntries(fsys fs.FS, es []Entry) error {
+	var (
+		next = make(chan int)
+		errs = make(chan error, c.workers)
+	)
+	for i := 0; i < c.workers; i++ {
+		go func() {
+			var err error
+			for j := range next {
+				if err == nil {
+					err = c.sumEntry(fsys, &es[j])
+				}
+			}
+			errs <- err
+		}()
+	}
+	for i := range es {
+		next <- i
+	}
+	close(next)
+
+	var err error
+	for i := 0; i < c.workers; i++ {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (c *config) sumEntry(fsys fs.FS, e *Entry) error {
+	f, err := fsys.Open(e.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	e.Size, e.ModTime = fi.Size(), fi.ModTime()
+
+	var r io.Reader = f
+	if c.wrap != nil {
+		r = c.wrap(e.Path, e.Size, r)
+	}
+
+	if err := c.sum(e, r); err != nil {
+		return &fs.PathError{Op: "read", Path: e.Path, Err: err}
+	}
+	return nil
+}
+
+// sum sets hash and chunk hashes of e to the ones of the data read from r.
+func (c *config) sum(e *Entry, r io.Reader) error {
+	d := tz.Get()
+	defer tz.Put(d)
+
+	if c.chunkSize == 0 {
+		if _, err := io.Copy(d, r); err != nil {
+			return err
+		}
+		e.Hash = d.Checksum()
+		return nil
+	}
+
+	e.Chunks = nil
+	for {
+		n, err := io.CopyN(d, r, c.chunkSize)
+		if n != 0 {
+			e.Chunks = append(e.Chunks, d.Checksum())
+			d.Reset()
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	h, err := combine(e.Chunks)
+	e.Hash = h
+	return err
+}
+
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	base := path.Base(name)
+	for _, p := range patterns {
+		// Patterns are validated in advance.
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
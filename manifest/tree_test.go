@@ -0,0 +1,147 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":       {Data: []byte("a")},
+		"a/b":         {Data: []byte("b")},
+		"a/c.log":     {Data: []byte("c")},
+		"tmp/d":       {Data: []byte("d")},
+		"z/.keep/x":   {Data: []byte("x")},
+		"z/empty.txt": {Data: nil},
+	}
+}
+
+func paths(m *Manifest) []string {
+	ps := make([]string, len(m.Entries))
+	for i := range m.Entries {
+		ps[i] = m.Entries[i].Path
+	}
+	return ps
+}
+
+func TestBuild(t *testing.T) {
+	fsys := testFS()
+
+	for _, workers := range []int{1, 3} {
+		m, err := Build(fsys, WithWorkers(workers))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a.txt", "a/b", "a/c.log", "tmp/d", "z/.keep/x", "z/empty.txt"}, paths(m))
+
+		var hs [][]byte
+		for i := range m.Entries {
+			e := m.Entries[i]
+			require.Equal(t, tz.Sum(fsys[e.Path].Data), e.Hash)
+			require.EqualValues(t, len(fsys[e.Path].Data), e.Size)
+			hs = append(hs, e.Hash[:])
+		}
+		root, err := tz.Concat(hs)
+		require.NoError(t, err)
+		require.Equal(t, root, m.Root[:])
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		m, err := Build(fstest.MapFS{})
+		require.NoError(t, err)
+		require.Empty(t, m.Entries)
+		require.Equal(t, tz.Sum(nil), m.Root)
+	})
+	t.Run("unreadable", func(t *testing.T) {
+		_, err := Build(errFS{fsys}, WithWorkers(2))
+		require.True(t, errors.Is(err, fs.ErrPermission))
+	})
+}
+
+func TestBuildFilters(t *testing.T) {
+	fsys := testFS()
+
+	m, err := Build(fsys, WithInclude("*.txt"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "z/empty.txt"}, paths(m))
+
+	m, err = Build(fsys, WithExclude("tmp", ".*", "a/*.log"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "a/b", "z/empty.txt"}, paths(m))
+
+	m, err = Build(fsys, WithInclude("*.txt", "a/*"), WithExclude("z"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "a/b", "a/c.log"}, paths(m))
+
+	_, err = Build(fsys, WithExclude("["))
+	require.Error(t, err)
+}
+
+func TestBuildReader(t *testing.T) {
+	var total, read int64
+
+	m, err := Build(testFS(), WithWorkers(2), WithReader(func(name string, size int64, r io.Reader) io.Reader {
+		atomic.AddInt64(&total, size)
+		return io.TeeReader(r, writerFunc(func(p []byte) (int, error) {
+			atomic.AddInt64(&read, int64(len(p)))
+			return len(p), nil
+		}))
+	}))
+	require.NoError(t, err)
+	require.Len(t, m.Entries, 6)
+	require.EqualValues(t, 5, total)
+	require.EqualValues(t, 5, read)
+}
+
+// errFS fails to open "a/b".
+type errFS struct {
+	fstest.MapFS
+}
+
+func (f errFS) Open(name string) (fs.File, error) {
+	if name == "a/b" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestManifestWriteTo(t *testing.T) {
+	m, err := Build(testFS())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), n)
+
+	var i int
+	s := bufio.NewScanner(&buf)
+	for ; s.Scan(); i++ {
+		line := s.Text()
+		if i == len(m.Entries) {
+			require.True(t, strings.HasPrefix(line, RootPrefix))
+			h, ok := ParseHash(line[len(RootPrefix):])
+			require.True(t, ok)
+			require.Equal(t, m.Root, h)
+			continue
+		}
+
+		h, file, ok := ParseLine(line)
+		require.True(t, ok)
+		require.Equal(t, m.Entries[i].Path, file)
+		require.Equal(t, m.Entries[i].Hash, h)
+	}
+	require.Equal(t, len(m.Entries)+1, i)
+}
@@ -0,0 +1,10 @@
+package mmap
+
+import "golang.org/x/sys/unix"
+
+// adviseSequential hints the kernel to read ahead aggressively and
+// to free pages soon after they were accessed. It is only a hint,
+// so errors are ignored.
+func adviseSequential(b []byte) {
+	_ = unix.Madvise(b, unix.MADV_SEQUENTIAL)
+}
@@ -0,0 +1,76 @@
+// Package mmap provides read-only memory mapping of files and file ranges.
+// Mapping is supported on Linux and BSD systems, ErrUnsupported is returned
+// on other platforms.
+package mmap
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrUnsupported is returned if memory mapping is not supported on the platform.
+var ErrUnsupported = errors.New("memory mapping is not supported")
+
+// Region is a read-only memory-mapped range of a file.
+// Accessing data of a file truncated after mapping results in SIGBUS,
+// see runtime/debug.SetPanicOnFault to handle it.
+type Region struct {
+	mapped []byte // page-aligned mapping
+	data   []byte
+}
+
+// Open maps the whole named file. The file is closed after mapping,
+// the mapping is valid until Close.
+func Open(name string) (*Region, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Map(f, 0, fi.Size())
+}
+
+// Map maps length bytes of f starting at off. Offset doesn't need to be
+// aligned. Mapping of the empty range returns empty region.
+// Sequential access is advised to the kernel where supported.
+func Map(f *os.File, off, length int64) (*Region, error) {
+	if off < 0 || length < 0 {
+		return nil, errors.New("negative offset or length")
+	}
+	if length == 0 {
+		return &Region{}, nil
+	}
+
+	shift := off % int64(os.Getpagesize())
+	if length+shift > int64(maxInt) {
+		return nil, errors.New("region is too large")
+	}
+
+	mapped, err := mmap(f, off-shift, int(length+shift))
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: err}
+	}
+	return &Region{mapped: mapped, data: mapped[shift:]}, nil
+}
+
+// Bytes returns mapped data. It must not be modified or used after Close.
+func (r *Region) Bytes() []byte {
+	return r.data
+}
+
+// Close unmaps the region.
+func (r *Region) Close() error {
+	if r.mapped == nil {
+		return nil
+	}
+	err := munmap(r.mapped)
+	r.mapped, r.data = nil, nil
+	return err
+}
+
+const maxInt = int(^uint(0) >> 1)
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package mmap
+
+import "os"
+
+func mmap(*os.File, int64, int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func munmap([]byte) error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package mmap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	data := make([]byte, 3*os.Getpagesize()+17)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	name := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(name, data, 0644))
+
+	r, err := Open(name)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+	require.Equal(t, data, r.Bytes())
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, rng := range [][2]int{{0, 1}, {1, 100}, {os.Getpagesize() - 1, os.Getpagesize() + 2}, {len(data) - 1, 1}} {
+		r, err := Map(f, int64(rng[0]), int64(rng[1]))
+		require.NoError(t, err)
+		require.Equal(t, data[rng[0]:rng[0]+rng[1]], r.Bytes())
+		require.NoError(t, r.Close())
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		r, err := Map(f, 5, 0)
+		require.NoError(t, err)
+		require.Empty(t, r.Bytes())
+		require.NoError(t, r.Close())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Map(f, -1, 1)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,277 @@
+package multipart
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/internal/dirstore"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// ErrNoState is returned by Storage for uploads without saved state.
+var ErrNoState = errors.New("no upload state")
+
+// Storage persists Tracker state between restarts.
+type Storage interface {
+	// Load returns state saved for the upload or ErrNoState.
+	Load(id string) ([]byte, error)
+	// Save replaces state of the upload.
+	Save(id string, state []byte) error
+	// Delete removes state of the upload. Missing state is not an error.
+	Delete(id string) error
+}
+
+// TrackedPart is a part with its offset in the object.
+type TrackedPart struct {
+	Number int
+	Offset int64
+	Part
+}
+
+// Tracker is Upload with parts and their offsets persisted in Storage
+// after every uploaded part, so that the upload can be resumed
+// after restart.
+type Tracker struct {
+	id      string
+	storage Storage
+	upload  *Upload
+
+	mtx     sync.Mutex
+	offsets map[int]int64
+}
+
+type trackerState struct {
+	MinPartSize int64       `json:"minPartSize"`
+	Parts       []partState `json:"parts"`
+}
+
+type partState struct {
+	Number int    `json:"number"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Hash   string `json:"hash"`
+}
+
+// OpenTracker returns Tracker for the upload restored from s if there is
+// saved state for it, or a new one with minPartSize (see NewUpload).
+// Minimal part size of restored uploads is taken from the state.
+func OpenTracker(s Storage, id string, minPartSize int64) (*Tracker, error) {
+	t := &Tracker{id: id, storage: s, offsets: make(map[int]int64)}
+
+	data, err := s.Load(id)
+	if errors.Is(err, ErrNoState) {
+		t.upload = NewUpload(minPartSize)
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var st trackerState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid upload state: %w", err)
+	}
+	t.upload = NewUpload(st.MinPartSize)
+	for _, ps := range st.Parts {
+		b, err := hex.DecodeString(ps.Hash)
+		if err != nil || len(b) != tz.Size {
+			return nil, fmt.Errorf("invalid hash of part %d", ps.Number)
+		}
+		p := Part{Size: ps.Size}
+		copy(p.Hash[:], b)
+		if err := t.upload.SetPart(ps.Number, p); err != nil {
+			return nil, err
+		}
+		t.offsets[ps.Number] = ps.Offset
+	}
+	return t, nil
+}
+
+// Record records part hash computed elsewhere and saves the state.
+func (t *Tracker) Record(number int, offset int64, p Part) error {
+	if offset < 0 {
+		return errors.New("negative part offset")
+	}
+	if err := t.upload.SetPart(number, p); err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.offsets[number] = offset
+	return t.save()
+}
+
+// Reader returns reader hashing part data read from r. When r reaches EOF,
+// the part is recorded and the state is saved. Saving errors are returned
+// from Read instead of io.EOF.
+func (t *Tracker) Reader(number int, offset int64, r io.Reader) (io.Reader, error) {
+	if err := checkNumber(number); err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		return nil, errors.New("negative part offset")
+	}
+	return &trackedReader{t: t, number: number, offset: offset, r: tz.NewHashingReader(r)}, nil
+}
+
+type trackedReader struct {
+	t      *Tracker
+	number int
+	offset int64
+	r      *tz.HashingReader
+	size   int64
+	done   bool
+}
+
+func (p *trackedReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.size += int64(n)
+	if err == io.EOF && !p.done {
+		p.done = true
+		if err := p.t.Record(p.number, p.offset, Part{Hash: p.r.Sum(), Size: p.size}); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// Parts returns recorded parts ordered by number.
+func (t *Tracker) Parts() []TrackedPart {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.parts()
+}
+
+func (t *Tracker) parts() []TrackedPart {
+	parts := make([]TrackedPart, 0, len(t.offsets))
+	for number, offset := range t.offsets {
+		p, _ := t.upload.Part(number)
+		parts = append(parts, TrackedPart{Number: number, Offset: offset, Part: p})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts
+}
+
+// Next returns number and offset of the part to upload next for sequential
+// uploads: recorded parts starting from the first one are checked to be
+// contiguous, the next part follows the last contiguous one.
+func (t *Tracker) Next() (int, int64) {
+	var (
+		number = 1
+		offset int64
+	)
+	for _, p := range t.Parts() {
+		if p.Number != number || p.Offset != offset {
+			break
+		}
+		number++
+		offset += p.Size
+	}
+	return number, offset
+}
+
+// Complete returns hash and size of the object combined from the parts
+// with the given numbers, see Upload.Complete. Parts must also be contiguous.
+// State is deleted on success.
+func (t *Tracker) Complete(numbers []int) (tz.Hash, int64, error) {
+	if err := t.checkOffsets(numbers); err != nil {
+		return tz.Hash{}, 0, err
+	}
+	h, size, err := t.upload.Complete(numbers)
+	if err != nil {
+		return h, 0, err
+	}
+	if err := t.storage.Delete(t.id); err != nil {
+		return h, 0, err
+	}
+	return h, size, nil
+}
+
+// Verify is like Complete, but also checks the combined hash against
+// the expected one. State is kept if it doesn't match.
+func (t *Tracker) Verify(numbers []int, expected tz.Hash) (int64, error) {
+	if err := t.checkOffsets(numbers); err != nil {
+		return 0, err
+	}
+	h, size, err := t.upload.Complete(numbers)
+	if err != nil {
+		return 0, err
+	}
+	if h != expected {
+		return 0, tz.ErrChecksumMismatch
+	}
+	return size, t.storage.Delete(t.id)
+}
+
+func (t *Tracker) checkOffsets(numbers []int) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var offset int64
+	for _, number := range numbers {
+		off, ok := t.offsets[number]
+		if !ok {
+			return fmt.Errorf("part %d was not uploaded", number)
+		}
+		if off != offset {
+			return fmt.Errorf("part %d has offset %d, expected %d", number, off, offset)
+		}
+		p, _ := t.upload.Part(number)
+		offset += p.Size
+	}
+	return nil
+}
+
+// save must be called with mtx held.
+func (t *Tracker) save() error {
+	st := trackerState{MinPartSize: t.upload.minPartSize}
+	for _, p := range t.parts() {
+		st.Parts = append(st.Parts, partState{
+			Number: p.Number,
+			Offset: p.Offset,
+			Size:   p.Size,
+			Hash:   p.Hash.String(),
+		})
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return t.storage.Save(t.id, data)
+}
+
+// DirStorage is Storage keeping state of every upload in a separate file
+// of the directory. Upload IDs are used as file names, so they must be
+// valid ones.
+type DirStorage string
+
+// Load implements Storage.
+func (d DirStorage) Load(id string) ([]byte, error) {
+	data, err := d.dir().Load(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoState
+	}
+	return data, err
+}
+
+// Save implements Storage. State is replaced atomically and synced to disk.
+func (d DirStorage) Save(id string, state []byte) error {
+	return d.dir().Save(id, state)
+}
+
+// Delete implements Storage.
+func (d DirStorage) Delete(id string) error {
+	return d.dir().Delete(id)
+}
+
+func (d DirStorage) dir() dirstore.Dir {
+	return dirstore.Dir{Path: string(d), Ext: ".json"}
+}
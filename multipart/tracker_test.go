@@ -0,0 +1,96 @@
+package multipart
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	const partSize = 1000
+
+	data := make([]byte, 3*partSize+17)
+	_, _ = rand.Read(data)
+
+	s := DirStorage(t.TempDir())
+	tr, err := OpenTracker(s, "upload", partSize)
+	require.NoError(t, err)
+
+	number, offset := tr.Next()
+	require.Equal(t, 1, number)
+	require.EqualValues(t, 0, offset)
+
+	// Upload the first two parts and an interrupted third one.
+	for i := 0; i < 3; i++ {
+		number, offset := tr.Next()
+		part := data[offset : offset+partSize]
+		r, err := tr.Reader(number, offset, bytes.NewReader(part))
+		require.NoError(t, err)
+		if i == 2 {
+			_, err = io.CopyN(io.Discard, r, partSize/2)
+		} else {
+			_, err = io.Copy(io.Discard, r)
+		}
+		require.NoError(t, err)
+	}
+	require.Len(t, tr.Parts(), 2)
+
+	// Restart.
+	tr, err = OpenTracker(s, "upload", 0)
+	require.NoError(t, err)
+	require.Equal(t, []TrackedPart{
+		{Number: 1, Offset: 0, Part: Part{Hash: tz.Sum(data[:partSize]), Size: partSize}},
+		{Number: 2, Offset: partSize, Part: Part{Hash: tz.Sum(data[partSize : 2*partSize]), Size: partSize}},
+	}, tr.Parts())
+
+	for {
+		number, offset := tr.Next()
+		if offset == int64(len(data)) {
+			break
+		}
+		end := offset + partSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		r, err := tr.Reader(number, offset, bytes.NewReader(data[offset:end]))
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, r)
+		require.NoError(t, err)
+	}
+
+	// Part 4 offset doesn't match shorter part 3.
+	require.NoError(t, tr.Record(3, 2*partSize, Part{Hash: tz.Sum(data[2*partSize : 2*partSize+10]), Size: 10}))
+	_, _, err = tr.Complete([]int{1, 2, 3, 4})
+	require.Error(t, err)
+	require.NoError(t, tr.Record(3, 2*partSize, Part{Hash: tz.Sum(data[2*partSize : 3*partSize]), Size: partSize}))
+
+	_, err = tr.Verify([]int{1, 2, 3, 4}, tz.Sum(data[1:]))
+	require.ErrorIs(t, err, tz.ErrChecksumMismatch)
+
+	_, _, err = tr.Complete([]int{1, 3, 4})
+	require.Error(t, err, "gap")
+
+	// Minimal part size is restored from the state, the default one would fail.
+	size, err := tr.Verify([]int{1, 2, 3, 4}, tz.Sum(data))
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), size)
+
+	_, err = os.Stat(filepath.Join(string(s), "upload.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	t.Run("invalid state", func(t *testing.T) {
+		require.NoError(t, s.Save("bad", []byte(`{"parts":[{"number":1,"hash":"00"}]}`)))
+		_, err := OpenTracker(s, "bad", 0)
+		require.Error(t, err)
+
+		require.NoError(t, s.Save("bad", []byte(`{`)))
+		_, err = OpenTracker(s, "bad", 0)
+		require.Error(t, err)
+	})
+}
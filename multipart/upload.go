@@ -0,0 +1,142 @@
+// Package multipart computes Tillich-Zémor hashes of objects uploaded
+// in parts, like S3 multipart uploads. Part hashes are computed while parts
+// are streamed and are combined into the hash of the whole object on
+// completion, so no additional read pass is needed.
+package multipart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// S3 limits.
+const (
+	// MinPartSize is the minimal size of all parts except the last one.
+	MinPartSize = 5 << 20
+
+	// MaxParts is the maximal part number.
+	MaxParts = 10000
+)
+
+// Part is a hash of the uploaded part.
+type Part struct {
+	Hash tz.Hash
+	Size int64
+}
+
+// Upload tracks hashes of uploaded parts. Parts can be uploaded concurrently,
+// in any order and re-uploaded, the last uploaded one is used.
+type Upload struct {
+	minPartSize int64
+
+	mtx   sync.Mutex
+	parts map[int]Part
+}
+
+// NewUpload returns Upload requiring all parts except the last one
+// to be at least minPartSize bytes long. If minPartSize is not positive,
+// MinPartSize is used.
+func NewUpload(minPartSize int64) *Upload {
+	if minPartSize <= 0 {
+		minPartSize = MinPartSize
+	}
+	return &Upload{minPartSize: minPartSize, parts: make(map[int]Part)}
+}
+
+func checkNumber(number int) error {
+	if number < 1 || number > MaxParts {
+		return fmt.Errorf("part number must be in [1, %d] range, got %d", MaxParts, number)
+	}
+	return nil
+}
+
+// SetPart records part hash computed elsewhere.
+func (u *Upload) SetPart(number int, p Part) error {
+	if err := checkNumber(number); err != nil {
+		return err
+	}
+	u.mtx.Lock()
+	u.parts[number] = p
+	u.mtx.Unlock()
+	return nil
+}
+
+// Part returns hash of the uploaded part.
+func (u *Upload) Part(number int) (Part, bool) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	p, ok := u.parts[number]
+	return p, ok
+}
+
+// Reader returns reader hashing part data read from r. Part is recorded
+// when r reaches EOF, so interrupted uploads don't affect the result.
+func (u *Upload) Reader(number int, r io.Reader) (io.Reader, error) {
+	if err := checkNumber(number); err != nil {
+		return nil, err
+	}
+	return &partReader{u: u, number: number, r: tz.NewHashingReader(r)}, nil
+}
+
+type partReader struct {
+	u      *Upload
+	number int
+	r      *tz.HashingReader
+	size   int64
+	done   bool
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.size += int64(n)
+	if err == io.EOF && !p.done {
+		p.done = true
+		_ = p.u.SetPart(p.number, Part{Hash: p.r.Sum(), Size: p.size})
+	}
+	return n, err
+}
+
+// Complete returns hash and size of the object combined from the parts
+// with the given numbers, which must be in ascending order like
+// in CompleteMultipartUpload request. All parts except the last one
+// must be at least the minimal part size long.
+func (u *Upload) Complete(numbers []int) (tz.Hash, int64, error) {
+	if len(numbers) == 0 {
+		return tz.Hash{}, 0, errors.New("no parts to complete")
+	}
+
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	var (
+		size int64
+		hs   = make([][]byte, len(numbers))
+	)
+	for i, number := range numbers {
+		if i != 0 && number <= numbers[i-1] {
+			return tz.Hash{}, 0, fmt.Errorf("part numbers must be in ascending order, got %d after %d", number, numbers[i-1])
+		}
+		p, ok := u.parts[number]
+		if !ok {
+			return tz.Hash{}, 0, fmt.Errorf("part %d was not uploaded", number)
+		}
+		if i != len(numbers)-1 && p.Size < u.minPartSize {
+			return tz.Hash{}, 0, fmt.Errorf("part %d is too small: %d bytes, minimum is %d", number, p.Size, u.minPartSize)
+		}
+		size += p.Size
+		hs[i] = p.Hash[:]
+	}
+
+	c, err := tz.Concat(hs)
+	if err != nil {
+		return tz.Hash{}, 0, err
+	}
+
+	var h tz.Hash
+	copy(h[:], c)
+	return h, size, nil
+}
@@ -0,0 +1,96 @@
+package multipart
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func upload(t *testing.T, u *Upload, number int, data []byte) {
+	r, err := u.Reader(number, bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+}
+
+func TestUpload(t *testing.T) {
+	const partSize = 1000
+
+	data := make([]byte, 3*partSize+17)
+	_, _ = rand.Read(data)
+
+	u := NewUpload(partSize)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, 4)
+	)
+	for i := 0; i < 4; i++ {
+		end := (i + 1) * partSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		wg.Add(1)
+		go func(i int, part []byte) {
+			defer wg.Done()
+			r, err := u.Reader(2*i+1, bytes.NewReader(part))
+			if err == nil {
+				_, err = io.Copy(io.Discard, r)
+			}
+			errs[i] = err
+		}(i, data[i*partSize:end])
+	}
+	wg.Wait()
+	for i := range errs {
+		require.NoError(t, errs[i])
+	}
+
+	p, ok := u.Part(7)
+	require.True(t, ok)
+	require.EqualValues(t, 17, p.Size)
+	require.Equal(t, tz.Hash(tz.Sum(data[3*partSize:])), p.Hash)
+
+	h, size, err := u.Complete([]int{1, 3, 5, 7})
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), size)
+	require.Equal(t, tz.Hash(tz.Sum(data)), h)
+
+	t.Run("reupload", func(t *testing.T) {
+		upload(t, u, 3, data[:partSize])
+		h, _, err := u.Complete([]int{1, 3})
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(append(data[:partSize:partSize], data[:partSize]...))), h)
+	})
+	t.Run("interrupted", func(t *testing.T) {
+		r, err := u.Reader(9, bytes.NewReader(data))
+		require.NoError(t, err)
+		_, err = r.Read(make([]byte, 10))
+		require.NoError(t, err)
+
+		_, ok := u.Part(9)
+		require.False(t, ok)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := u.Complete(nil)
+		require.Error(t, err)
+
+		_, _, err = u.Complete([]int{3, 1})
+		require.Error(t, err)
+
+		_, _, err = u.Complete([]int{1, 2})
+		require.Error(t, err)
+
+		_, _, err = u.Complete([]int{7, 9})
+		require.Error(t, err)
+
+		_, err = u.Reader(0, nil)
+		require.Error(t, err)
+		require.Error(t, u.SetPart(MaxParts+1, Part{}))
+	})
+}
@@ -0,0 +1,267 @@
+// Package oci computes Tillich-Zémor hashes of OCI image layers and records
+// them in image manifest annotations. Layer hashes are combined into the hash
+// of all layer blobs concatenated in order, so registry mirroring tools can
+// verify layers independently and the whole image by a single value.
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Annotations used by this package.
+const (
+	// AnnotationHash is the layer descriptor annotation with hex-encoded
+	// hash of the layer blob.
+	AnnotationHash = "ru.nspcc.tzhash.hash"
+	// AnnotationLayers is the manifest annotation with hex-encoded
+	// combined hash of all layer blobs.
+	AnnotationLayers = "ru.nspcc.tzhash.layers"
+)
+
+// MediaTypeManifest is the media type of OCI image manifest.
+const MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+
+// Descriptor is OCI content descriptor.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	URLs        []string          `json:"urls,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Extra contains all other fields (platform, artifactType, data etc.),
+	// they are kept as is when descriptor is encoded back.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Manifest is OCI image manifest. Only the fields needed for layer
+// hashing are present, others are kept in Extra.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	// Extra contains all other fields (subject, artifactType etc.),
+	// they are kept as is when manifest is encoded back.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Fields of Descriptor and Manifest which are not stored in Extra.
+var (
+	descriptorFields = []string{"mediaType", "digest", "size", "urls", "annotations"}
+	manifestFields   = []string{"schemaVersion", "mediaType", "config", "layers", "annotations"}
+)
+
+type (
+	jsonDescriptor Descriptor
+	jsonManifest   Manifest
+)
+
+// MarshalJSON implements json.Marshaler. Extra fields are added to the
+// known ones.
+func (d Descriptor) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(jsonDescriptor(d))
+	if err != nil {
+		return nil, err
+	}
+	return joinExtra(data, d.Extra)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown fields are stored
+// in Extra.
+func (d *Descriptor) UnmarshalJSON(data []byte) error {
+	var jd jsonDescriptor
+	if err := json.Unmarshal(data, &jd); err != nil {
+		return err
+	}
+	extra, err := splitExtra(data, descriptorFields)
+	if err != nil {
+		return err
+	}
+	jd.Extra = extra
+	*d = Descriptor(jd)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Extra fields are added to the
+// known ones.
+func (m Manifest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(jsonManifest(m))
+	if err != nil {
+		return nil, err
+	}
+	return joinExtra(data, m.Extra)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown fields are stored
+// in Extra.
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	var jm jsonManifest
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	extra, err := splitExtra(data, manifestFields)
+	if err != nil {
+		return err
+	}
+	jm.Extra = extra
+	*m = Manifest(jm)
+	return nil
+}
+
+// splitExtra returns fields of JSON object data except the known ones,
+// nil is returned if there are none.
+func splitExtra(data []byte, known []string) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		delete(fields, k)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// joinExtra adds extra fields to JSON object data, known fields take
+// precedence.
+func joinExtra(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// Layer reads layer blob from r until EOF and returns its descriptor
+// with SHA-256 digest, size and Tillich-Zémor hash annotation.
+// The blob is hashed as is, compressed layers are not decompressed.
+func Layer(mediaType string, r io.Reader) (Descriptor, error) {
+	var (
+		s = sha256.New()
+		w = tz.NewHashingWriter(s)
+	)
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	return Descriptor{
+		MediaType:   mediaType,
+		Digest:      "sha256:" + hex.EncodeToString(s.Sum(nil)),
+		Size:        n,
+		Annotations: map[string]string{AnnotationHash: tz.Hash(w.Sum()).String()},
+	}, nil
+}
+
+// LayerHash returns the hash from the AnnotationHash annotation of d.
+func LayerHash(d Descriptor) (tz.Hash, error) {
+	return parseAnnotation(d.Annotations, AnnotationHash)
+}
+
+// Annotate sets AnnotationLayers of m to the combined hash of its layers.
+// All layers must have AnnotationHash.
+func Annotate(m *Manifest) error {
+	h, err := combine(m.Layers)
+	if err != nil {
+		return err
+	}
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+	m.Annotations[AnnotationLayers] = h.String()
+	return nil
+}
+
+// LayersHash returns the combined hash of m layers after checking that it
+// matches AnnotationLayers of m.
+func LayersHash(m *Manifest) (tz.Hash, error) {
+	expected, err := parseAnnotation(m.Annotations, AnnotationLayers)
+	if err != nil {
+		return tz.Hash{}, err
+	}
+	h, err := combine(m.Layers)
+	if err != nil {
+		return tz.Hash{}, err
+	}
+	if h != expected {
+		return tz.Hash{}, errors.New("layer hashes don't match manifest annotation")
+	}
+	return h, nil
+}
+
+// VerifyLayer reads layer blob from r until EOF and checks its size and hash
+// against d.
+func VerifyLayer(d Descriptor, r io.Reader) error {
+	expected, err := LayerHash(d)
+	if err != nil {
+		return err
+	}
+
+	v := tz.NewVerifyingReader(r, expected)
+	n, err := io.Copy(io.Discard, v)
+	if err != nil {
+		return err
+	}
+	if n != d.Size {
+		return fmt.Errorf("layer size mismatch: expected %d, got %d", d.Size, n)
+	}
+	return nil
+}
+
+func combine(layers []Descriptor) (tz.Hash, error) {
+	if len(layers) == 0 {
+		return tz.Sum(nil), nil
+	}
+
+	hs := make([][]byte, len(layers))
+	for i := range layers {
+		h, err := LayerHash(layers[i])
+		if err != nil {
+			return tz.Hash{}, fmt.Errorf("layer %d: %w", i, err)
+		}
+		hs[i] = h[:]
+	}
+
+	var h tz.Hash
+	c, err := tz.Concat(hs)
+	if err != nil {
+		return h, err
+	}
+	copy(h[:], c)
+	return h, nil
+}
+
+func parseAnnotation(annotations map[string]string, key string) (tz.Hash, error) {
+	var h tz.Hash
+
+	s, ok := annotations[key]
+	if !ok {
+		return h, fmt.Errorf("missing %s annotation", key)
+	}
+	if hex.DecodedLen(len(s)) != tz.Size {
+		return h, fmt.Errorf("invalid %s annotation length", key)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid %s annotation: %w", key, err)
+	}
+	return h, nil
+}
@@ -0,0 +1,114 @@
+package oci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+const mediaTypeLayer = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+func TestLayers(t *testing.T) {
+	blobs := [][]byte{[]byte("first layer"), []byte("second layer"), {}}
+
+	m := &Manifest{SchemaVersion: 2, MediaType: MediaTypeManifest}
+	for _, b := range blobs {
+		d, err := Layer(mediaTypeLayer, bytes.NewReader(b))
+		require.NoError(t, err)
+
+		sha := sha256.Sum256(b)
+		require.Equal(t, "sha256:"+hex.EncodeToString(sha[:]), d.Digest)
+		require.Equal(t, int64(len(b)), d.Size)
+
+		h, err := LayerHash(d)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(b)), h)
+
+		require.NoError(t, VerifyLayer(d, bytes.NewReader(b)))
+		m.Layers = append(m.Layers, d)
+	}
+
+	_, err := LayersHash(m)
+	require.Error(t, err)
+
+	require.NoError(t, Annotate(m))
+	h, err := LayersHash(m)
+	require.NoError(t, err)
+	require.Equal(t, tz.Hash(tz.Sum(bytes.Join(blobs, nil))), h)
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	var actual Manifest
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Equal(t, *m, actual)
+
+	t.Run("tampered", func(t *testing.T) {
+		require.Error(t, VerifyLayer(m.Layers[0], bytes.NewReader(blobs[1])))
+
+		d := m.Layers[0]
+		d.Size++
+		require.Error(t, VerifyLayer(d, bytes.NewReader(blobs[0])))
+
+		m.Layers[0], m.Layers[1] = m.Layers[1], m.Layers[0]
+		_, err := LayersHash(m)
+		require.Error(t, err)
+	})
+	t.Run("invalid annotation", func(t *testing.T) {
+		for _, a := range []map[string]string{
+			nil,
+			{AnnotationHash: "00"},
+			{AnnotationHash: string(bytes.Repeat([]byte{'x'}, 2*tz.Size))},
+		} {
+			_, err := LayerHash(Descriptor{Annotations: a})
+			require.Error(t, err)
+			require.Error(t, Annotate(&Manifest{Layers: []Descriptor{{Annotations: a}}}))
+		}
+	})
+	t.Run("unknown fields", func(t *testing.T) {
+		const manifest = `{
+			"schemaVersion": 2,
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"artifactType": "application/vnd.example+type",
+			"config": {
+				"mediaType": "application/vnd.oci.empty.v1+json",
+				"digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+				"size": 2,
+				"data": "e30="
+			},
+			"layers": [],
+			"subject": {
+				"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"digest": "sha256:5b0bcabd1ed22e9fb1310cf6c2dec7cdef19f0ad69efa1f392e94a4333501270",
+				"size": 1234,
+				"platform": {"architecture": "amd64", "os": "linux"}
+			}
+		}`
+
+		var m Manifest
+		require.NoError(t, json.Unmarshal([]byte(manifest), &m))
+		require.Equal(t, json.RawMessage(`"e30="`), m.Config.Extra["data"])
+		require.NoError(t, Annotate(&m))
+
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		var expected, actual map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(manifest), &expected))
+		require.NoError(t, json.Unmarshal(data, &actual))
+		expected["annotations"] = map[string]interface{}{AnnotationLayers: m.Annotations[AnnotationLayers]}
+		require.Equal(t, expected, actual)
+	})
+	t.Run("no layers", func(t *testing.T) {
+		m := &Manifest{}
+		require.NoError(t, Annotate(m))
+		h, err := LayersHash(m)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(nil)), h)
+	})
+}
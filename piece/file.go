@@ -0,0 +1,159 @@
+package piece
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// File provides verify-on-read access to the object described by the manifest.
+// Every piece is verified when it is read for the first time after being
+// fetched, and data is served from verified pieces only, so corrupted
+// data is never returned. The last verified piece is cached, which makes
+// sequential reads with small buffers cheap.
+//
+// File implements fs.File, io.ReaderAt and io.Seeker. ReadAt is safe
+// for concurrent use, Read and Seek are not.
+type File struct {
+	r io.ReaderAt
+	m *Manifest
+
+	off int64
+
+	mtx   sync.Mutex
+	index int
+	cache []byte
+}
+
+var _ interface {
+	fs.File
+	io.ReaderAt
+	io.Seeker
+} = (*File)(nil)
+
+// NewFile returns File reading the object from r. If r implements fs.File,
+// its Stat and Close are used. The manifest must be valid.
+func NewFile(r io.ReaderAt, m *Manifest) *File {
+	return &File{r: r, m: m, index: -1}
+}
+
+// ReadAt implements io.ReaderAt. *MismatchError is returned for the first
+// corrupted piece, data from the preceding pieces is still put into p.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	var n int
+	for n < len(p) {
+		if off >= f.m.Size {
+			return n, io.EOF
+		}
+
+		i := int(off / f.m.PieceSize)
+		data, err := f.piece(i)
+		if err != nil {
+			return n, err
+		}
+
+		start, _ := f.m.Range(i)
+		k := copy(p[n:], data[off-start:])
+		n += k
+		off += int64(k)
+	}
+	return n, nil
+}
+
+// piece returns verified data of the i-th piece.
+func (f *File) piece(i int) ([]byte, error) {
+	f.mtx.Lock()
+	if f.index == i {
+		data := f.cache
+		f.mtx.Unlock()
+		return data, nil
+	}
+	f.mtx.Unlock()
+
+	off, length := f.m.Range(i)
+	data := make([]byte, length)
+	if n, err := f.r.ReadAt(data, off); n != len(data) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if err := f.m.VerifyPiece(i, data); err != nil {
+		return nil, err
+	}
+
+	f.mtx.Lock()
+	f.index, f.cache = i, data
+	f.mtx.Unlock()
+	return data, nil
+}
+
+// Read implements io.Reader.
+func (f *File) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := f.ReadAt(p, f.off)
+	f.off += int64(n)
+	if err == io.EOF && n != 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.m.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+// Stat implements fs.File. If the underlying reader doesn't implement fs.File,
+// information with the size from the manifest is returned.
+func (f *File) Stat() (fs.FileInfo, error) {
+	if s, ok := f.r.(fs.File); ok {
+		return s.Stat()
+	}
+	return fileInfo{size: f.m.Size}, nil
+}
+
+// Close implements fs.File. The underlying reader is closed if it
+// implements io.Closer.
+func (f *File) Close() error {
+	f.mtx.Lock()
+	f.index, f.cache = -1, nil
+	f.mtx.Unlock()
+
+	if c, ok := f.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type fileInfo struct {
+	size int64
+}
+
+func (fi fileInfo) Name() string       { return "" }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return 0o444 }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return false }
+func (fi fileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,80 @@
+package piece
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	data := make([]byte, 3*100+17)
+	_, _ = rand.Read(data)
+
+	m, err := Create(bytes.NewReader(data), 100)
+	require.NoError(t, err)
+
+	t.Run("read", func(t *testing.T) {
+		f := NewFile(bytes.NewReader(data), m)
+		require.NoError(t, iotest.TestReader(f, data))
+
+		fi, err := f.Stat()
+		require.NoError(t, err)
+		require.EqualValues(t, len(data), fi.Size())
+		require.NoError(t, f.Close())
+	})
+	t.Run("read at", func(t *testing.T) {
+		f := NewFile(bytes.NewReader(data), m)
+		buf := make([]byte, 150)
+		n, err := f.ReadAt(buf, 90)
+		require.NoError(t, err)
+		require.Equal(t, 150, n)
+		require.Equal(t, data[90:240], buf)
+
+		n, err = f.ReadAt(buf, 300)
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, data[300:], buf[:n])
+
+		_, err = f.ReadAt(buf, -1)
+		require.Error(t, err)
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		bad := append([]byte{}, data...)
+		bad[250] ^= 1
+
+		f := NewFile(bytes.NewReader(bad), m)
+		actual, err := io.ReadAll(f)
+
+		var me *MismatchError
+		require.True(t, errors.As(err, &me))
+		require.Equal(t, 2, me.Piece)
+		require.Equal(t, data[:200], actual)
+
+		// Other pieces are still readable.
+		buf := make([]byte, 17)
+		_, err = f.ReadAt(buf, 300)
+		require.NoError(t, err)
+		require.Equal(t, data[300:], buf)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		f := NewFile(bytes.NewReader(data[:250]), m)
+		_, err := io.ReadAll(f)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+	t.Run("fs.File", func(t *testing.T) {
+		fsys := fstest.MapFS{"object": &fstest.MapFile{Data: data}}
+		of, err := fsys.Open("object")
+		require.NoError(t, err)
+
+		f := NewFile(of.(io.ReaderAt), m)
+		fi, err := f.Stat()
+		require.NoError(t, err)
+		require.Equal(t, "object", fi.Name())
+		require.NoError(t, f.Close())
+	})
+}
@@ -0,0 +1,177 @@
+// Package piece implements BitTorrent-like piece manifests with
+// homomorphic root. Every piece can be verified independently by its hash,
+// the root is the combination of all piece hashes and equals the hash of the
+// whole object, so the manifest itself is verified by the root only.
+package piece
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// magic starts binary encoding of the manifest, the last byte is the version.
+const magic = "tzp\x01"
+
+// Manifest describes an object split into pieces of the same size,
+// the last piece can be shorter.
+type Manifest struct {
+	PieceSize int64
+	Size      int64
+	Pieces    []tz.Hash
+	Root      tz.Hash
+}
+
+// MismatchError is returned when piece data doesn't match the manifest.
+type MismatchError struct {
+	Piece int
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("piece %d doesn't match", e.Piece)
+}
+
+// Create reads r until EOF and returns manifest with the specified piece size.
+func Create(r io.Reader, pieceSize int64) (*Manifest, error) {
+	if pieceSize <= 0 {
+		return nil, errors.New("piece size must be positive")
+	}
+
+	m := &Manifest{PieceSize: pieceSize}
+	d := tz.NewDigest()
+	for {
+		n, err := io.CopyN(d, r, pieceSize)
+		if n != 0 {
+			m.Size += n
+			m.Pieces = append(m.Pieces, d.Checksum())
+			d.Reset()
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	m.Root = combine(m.Pieces)
+	return m, nil
+}
+
+// combine returns combined hash of pieces, which is the hash of empty data
+// if there are none.
+func combine(pieces []tz.Hash) tz.Hash {
+	if len(pieces) == 0 {
+		return tz.Sum(nil)
+	}
+
+	hs := make([][]byte, len(pieces))
+	for i := range pieces {
+		hs[i] = pieces[i][:]
+	}
+
+	var h tz.Hash
+	c, _ := tz.Concat(hs) // all hashes have the correct size
+	copy(h[:], c)
+	return h
+}
+
+// numPieces returns the number of pieces of size bytes.
+func numPieces(size, pieceSize int64) int64 {
+	return (size + pieceSize - 1) / pieceSize
+}
+
+// Range returns offset and length of the i-th piece.
+func (m *Manifest) Range(i int) (off, length int64) {
+	off = int64(i) * m.PieceSize
+	length = m.PieceSize
+	if off+length > m.Size {
+		length = m.Size - off
+	}
+	return off, length
+}
+
+// VerifyPiece checks that data is the i-th piece. *MismatchError
+// is returned if it isn't.
+func (m *Manifest) VerifyPiece(i int, data []byte) error {
+	if i < 0 || i >= len(m.Pieces) {
+		return fmt.Errorf("piece %d is out of range", i)
+	}
+	if _, length := m.Range(i); int64(len(data)) != length || tz.Sum(data) != m.Pieces[i] {
+		return &MismatchError{Piece: i}
+	}
+	return nil
+}
+
+// Validate checks that the number of pieces matches the size and the root
+// matches piece hashes.
+func (m *Manifest) Validate() error {
+	if m.PieceSize <= 0 || m.Size < 0 {
+		return errors.New("piece size must be positive and size must be non-negative")
+	}
+	if n := numPieces(m.Size, m.PieceSize); int64(len(m.Pieces)) != n {
+		return fmt.Errorf("expected %d pieces, got %d", n, len(m.Pieces))
+	}
+	if combine(m.Pieces) != m.Root {
+		return errors.New("root doesn't match pieces")
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (m *Manifest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(magic)+2*binary.MaxVarintLen64+(len(m.Pieces)+1)*tz.Size)
+	b = append(b, magic...)
+
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(m.PieceSize))]...)
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(m.Size))]...)
+	b = append(b, m.Root[:]...)
+	for i := range m.Pieces {
+		b = append(b, m.Pieces[i][:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// Decoded manifest is validated.
+func (m *Manifest) UnmarshalBinary(data []byte) error {
+	if len(data) < len(magic) || string(data[:len(magic)]) != magic {
+		return errors.New("invalid manifest header")
+	}
+	data = data[len(magic):]
+
+	pieceSize, n := binary.Uvarint(data)
+	if n <= 0 || pieceSize == 0 || pieceSize > 1<<62 {
+		return errors.New("invalid piece size")
+	}
+	data = data[n:]
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > 1<<62 {
+		return errors.New("invalid size")
+	}
+	data = data[n:]
+
+	count := numPieces(int64(size), int64(pieceSize))
+	if len(data)%tz.Size != 0 || int64(len(data)/tz.Size-1) != count {
+		return errors.New("invalid number of pieces")
+	}
+
+	res := Manifest{
+		PieceSize: int64(pieceSize),
+		Size:      int64(size),
+		Pieces:    make([]tz.Hash, count),
+	}
+	copy(res.Root[:], data)
+	for i := range res.Pieces {
+		copy(res.Pieces[i][:], data[(i+1)*tz.Size:])
+	}
+	if err := res.Validate(); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
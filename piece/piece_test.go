@@ -0,0 +1,92 @@
+package piece
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	const pieceSize = 100
+
+	for _, size := range []int{0, 1, pieceSize, 3*pieceSize + 17} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		m, err := Create(bytes.NewReader(data), pieceSize)
+		require.NoError(t, err)
+		require.EqualValues(t, size, m.Size)
+		require.Len(t, m.Pieces, (size+pieceSize-1)/pieceSize)
+		require.Equal(t, tz.Hash(tz.Sum(data)), m.Root)
+		require.NoError(t, m.Validate())
+
+		for i := range m.Pieces {
+			off, length := m.Range(i)
+			require.NoError(t, m.VerifyPiece(i, data[off:off+length]))
+		}
+
+		b, err := m.MarshalBinary()
+		require.NoError(t, err)
+
+		var actual Manifest
+		require.NoError(t, actual.UnmarshalBinary(b))
+		require.Equal(t, m.PieceSize, actual.PieceSize)
+		require.Equal(t, m.Size, actual.Size)
+		require.Equal(t, m.Root, actual.Root)
+		require.Equal(t, len(m.Pieces), len(actual.Pieces))
+		for i := range m.Pieces {
+			require.Equal(t, m.Pieces[i], actual.Pieces[i])
+		}
+	}
+
+	_, err := Create(bytes.NewReader(nil), 0)
+	require.Error(t, err)
+}
+
+func TestVerifyPiece(t *testing.T) {
+	data := make([]byte, 250)
+	_, _ = rand.Read(data)
+
+	m, err := Create(bytes.NewReader(data), 100)
+	require.NoError(t, err)
+
+	var me *MismatchError
+	require.True(t, errors.As(m.VerifyPiece(1, data[:100]), &me))
+	require.Equal(t, 1, me.Piece)
+	require.True(t, errors.As(m.VerifyPiece(2, data[200:249]), &me))
+	require.Error(t, m.VerifyPiece(3, nil))
+	require.Error(t, m.VerifyPiece(-1, nil))
+}
+
+func TestManifestInvalid(t *testing.T) {
+	data := make([]byte, 250)
+	_, _ = rand.Read(data)
+
+	m, err := Create(bytes.NewReader(data), 100)
+	require.NoError(t, err)
+
+	b, err := m.MarshalBinary()
+	require.NoError(t, err)
+
+	corrupted := append([]byte(nil), b...)
+	corrupted[len(corrupted)-1] ^= 1
+
+	for _, b := range [][]byte{
+		nil,
+		b[:3],
+		b[:len(b)-tz.Size],
+		append(b[:len(b):len(b)], make([]byte, tz.Size)...),
+		corrupted,
+		append([]byte("tzp\x02"), b[4:]...),
+	} {
+		var actual Manifest
+		require.Error(t, actual.UnmarshalBinary(b))
+	}
+
+	m.Pieces = m.Pieces[:2]
+	require.Error(t, m.Validate())
+}
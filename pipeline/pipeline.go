@@ -0,0 +1,232 @@
+// Package pipeline implements concurrent hashing of streams.
+//
+// The stream is read and split into chunks on one goroutine, chunks are
+// hashed by a pool of workers and results are delivered in order to the
+// aggregator, which combines chunk hashes into the hash of the whole stream.
+// The number of chunks in flight is bounded, so reading is suspended
+// when hashing or result processing can't keep up. The first error of any
+// stage or context cancellation stops all of them.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// DefaultChunkSize is the default size of chunks produced by FixedSize.
+const DefaultChunkSize = 1 << 20
+
+// Splitter splits the stream into chunks.
+type Splitter interface {
+	// Next returns the next non-empty chunk or io.EOF after the last one.
+	// Returned data is owned by the pipeline.
+	Next() ([]byte, error)
+}
+
+// SplitterFunc is a function implementing Splitter.
+type SplitterFunc func() ([]byte, error)
+
+// Next implements Splitter.
+func (f SplitterFunc) Next() ([]byte, error) {
+	return f()
+}
+
+// FixedSize returns Splitter reading chunks of the given size from r,
+// the last one can be shorter.
+func FixedSize(r io.Reader, size int) Splitter {
+	return SplitterFunc(func() ([]byte, error) {
+		buf := make([]byte, size)
+		n, err := io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if n == 0 && err == nil {
+			err = io.EOF
+		}
+		return buf[:n], err
+	})
+}
+
+// Result is the hash of a chunk.
+type Result struct {
+	// Index is the number of the chunk starting from zero.
+	Index  int
+	Offset int64
+	Length int
+	Hash   tz.Hash
+}
+
+// Option configures the pipeline.
+type Option func(*config)
+
+type config struct {
+	workers int
+	queue   int
+}
+
+// WithWorkers sets the number of hashing goroutines, GOMAXPROCS by default.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
+
+// WithQueue sets the maximal number of chunks read but not yet processed
+// by the aggregator, twice the number of workers by default. Together
+// with the chunk size it bounds memory used by the pipeline.
+func WithQueue(n int) Option {
+	return func(c *config) {
+		c.queue = n
+	}
+}
+
+type job struct {
+	index  int
+	offset int64
+	data   []byte
+}
+
+// Run hashes chunks produced by s and returns the hash of their
+// concatenation. If fn is not nil, it is called for every chunk in order
+// on the calling goroutine, a non-nil error stops the pipeline and is
+// returned. Splitter and fn are not interrupted by ctx cancellation, Run
+// returns after they return.
+func Run(ctx context.Context, s Splitter, fn func(Result) error, opts ...Option) (tz.Hash, error) {
+	c := config{workers: runtime.GOMAXPROCS(0)}
+	for _, o := range opts {
+		o(&c)
+	}
+	if c.workers <= 0 {
+		return tz.Hash{}, errors.New("number of workers must be positive")
+	}
+	if c.queue == 0 {
+		c.queue = 2 * c.workers
+	}
+	if c.queue < 0 {
+		return tz.Hash{}, errors.New("queue size must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		jobs    = make(chan job, c.workers)
+		results = make(chan Result, c.workers)
+		// tokens limits the number of chunks in flight: the reader takes one
+		// before reading a chunk, the aggregator returns it.
+		tokens = make(chan struct{}, c.queue)
+
+		readErr error
+		wg      sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		readErr = read(ctx, s, jobs, tokens)
+		if readErr != nil {
+			cancel()
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				r := Result{Index: j.index, Offset: j.offset, Length: len(j.data), Hash: tz.Sum(j.data)}
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	sum, err := aggregate(results, tokens, fn)
+	if err != nil {
+		cancel()
+	}
+	// Drain results, so that workers don't block.
+	for range results {
+	}
+	wg.Wait()
+
+	switch {
+	case readErr != nil:
+		return tz.Hash{}, readErr
+	case err != nil:
+		return tz.Hash{}, err
+	case ctx.Err() != nil:
+		return tz.Hash{}, ctx.Err()
+	}
+	return sum, nil
+}
+
+// read sends chunks produced by s to jobs until s returns io.EOF.
+func read(ctx context.Context, s Splitter, jobs chan<- job, tokens chan struct{}) error {
+	var offset int64
+	for index := 0; ; index++ {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		data, err := s.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		select {
+		case jobs <- job{index: index, offset: offset, data: data}:
+		case <-ctx.Done():
+			return nil
+		}
+		offset += int64(len(data))
+	}
+}
+
+// aggregate combines results in order until results is closed.
+func aggregate(results <-chan Result, tokens <-chan struct{}, fn func(Result) error) (tz.Hash, error) {
+	var (
+		sum     = tz.Hash(tz.Sum(nil))
+		pending = make(map[int]Result)
+		next    int
+	)
+	for r := range results {
+		pending[r.Index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if fn != nil {
+				if err := fn(r); err != nil {
+					return sum, err
+				}
+			}
+			s, _ := tz.Concat([][]byte{sum[:], r.Hash[:]}) // both hashes are valid
+			copy(sum[:], s)
+			<-tokens
+		}
+	}
+	return sum, nil
+}
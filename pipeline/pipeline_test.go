@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	data := make([]byte, 100000)
+	_, _ = rand.Read(data)
+
+	for _, workers := range []int{1, 3, 8} {
+		var results []Result
+		h, err := Run(context.Background(), FixedSize(bytes.NewReader(data), 1000), func(r Result) error {
+			results = append(results, r)
+			return nil
+		}, WithWorkers(workers), WithQueue(5))
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(data)), h)
+
+		require.Len(t, results, 100)
+		for i, r := range results {
+			require.Equal(t, i, r.Index)
+			require.EqualValues(t, i*1000, r.Offset)
+			require.Equal(t, 1000, r.Length)
+			require.Equal(t, tz.Hash(tz.Sum(data[r.Offset:r.Offset+1000])), r.Hash)
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		h, err := Run(context.Background(), FixedSize(bytes.NewReader(nil), 1000), nil)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(nil)), h)
+	})
+
+	t.Run("uneven", func(t *testing.T) {
+		h, err := Run(context.Background(), FixedSize(bytes.NewReader(data[:2500]), 1000), nil)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(data[:2500])), h)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := Run(context.Background(), FixedSize(bytes.NewReader(data), 1000), nil, WithWorkers(0))
+		require.Error(t, err)
+		_, err = Run(context.Background(), FixedSize(bytes.NewReader(data), 1000), nil, WithQueue(-1))
+		require.Error(t, err)
+	})
+}
+
+func TestErrors(t *testing.T) {
+	data := make([]byte, 100000)
+	_, _ = rand.Read(data)
+
+	t.Run("reader", func(t *testing.T) {
+		expected := errors.New("read error")
+		r := io.MultiReader(bytes.NewReader(data[:5500]), iotest.ErrReader(expected))
+		_, err := Run(context.Background(), FixedSize(r, 1000), nil, WithWorkers(2))
+		require.ErrorIs(t, err, expected)
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		expected := errors.New("callback error")
+		var calls int
+		_, err := Run(context.Background(), FixedSize(bytes.NewReader(data), 1000), func(r Result) error {
+			calls++
+			if r.Index == 10 {
+				return expected
+			}
+			return nil
+		}, WithWorkers(4))
+		require.ErrorIs(t, err, expected)
+		require.Equal(t, 11, calls)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := Run(ctx, FixedSize(bytes.NewReader(data), 1000), func(r Result) error {
+			if r.Index == 3 {
+				cancel()
+			}
+			return nil
+		}, WithWorkers(4))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestBackpressure(t *testing.T) {
+	const queue = 4
+
+	var (
+		reads   int32
+		release = make(chan struct{})
+		data    = make([]byte, 100)
+	)
+	s := SplitterFunc(func() ([]byte, error) {
+		if atomic.AddInt32(&reads, 1) > 50 {
+			return nil, io.EOF
+		}
+		return data, nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Run(context.Background(), s, func(Result) error {
+			<-release
+			return nil
+		}, WithWorkers(2), WithQueue(queue))
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&reads) == queue }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.EqualValues(t, queue, atomic.LoadInt32(&reads))
+
+	close(release)
+	require.NoError(t, <-done)
+}
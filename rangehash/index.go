@@ -0,0 +1,206 @@
+// Package rangehash computes hashes of arbitrary ranges of large objects.
+//
+// Index keeps hashes of fixed-size blocks of an object together with their
+// products organized in a balanced binary tree, so the hash of any range of
+// whole blocks is combined from O(log n) tree nodes without touching the data.
+// Only partial blocks at the range edges, if any, need to be read and hashed.
+//
+// Server builds on Index to answer salted range hash queries of clients.
+package rangehash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nspcc-dev/tzhash/piece"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// ErrNoData is returned when the range isn't aligned to blocks and
+// there is no data to hash its edges.
+var ErrNoData = errors.New("range isn't aligned to blocks and no data is provided")
+
+// Index is a tree of cumulative hash products over the object blocks.
+// It is safe for concurrent use.
+type Index struct {
+	blockSize int64
+	size      int64
+	// levels[0] contains block hashes, every node of levels[k+1] is
+	// the product of two adjacent nodes of levels[k]. An unpaired last node
+	// is carried to the next level unchanged.
+	levels [][]tz.Hash
+}
+
+// NewIndex returns index over size bytes of the object with the given
+// hashes of blocks of blockSize bytes, the last block can be shorter.
+// Hashes are not copied.
+func NewIndex(blocks []tz.Hash, blockSize, size int64) (*Index, error) {
+	if blockSize <= 0 || size < 0 {
+		return nil, errors.New("block size must be positive and size must be non-negative")
+	}
+	if n := (size + blockSize - 1) / blockSize; int64(len(blocks)) != n {
+		return nil, fmt.Errorf("expected %d blocks, got %d", n, len(blocks))
+	}
+	for i := range blocks {
+		if err := blocks[i].Validate(); err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+
+	ix := &Index{blockSize: blockSize, size: size, levels: [][]tz.Hash{blocks}}
+	for level := blocks; len(level) > 1; {
+		next := make([]tz.Hash, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 == len(level) {
+				next[i] = level[2*i]
+				continue
+			}
+			c, _ := tz.Concat([][]byte{level[2*i][:], level[2*i+1][:]}) // hashes are validated
+			copy(next[i][:], c)
+		}
+		ix.levels = append(ix.levels, next)
+		level = next
+	}
+	return ix, nil
+}
+
+// FromManifest returns index with manifest pieces as blocks.
+// The manifest must be valid.
+func FromManifest(m *piece.Manifest) (*Index, error) {
+	return NewIndex(m.Pieces, m.PieceSize, m.Size)
+}
+
+// Build reads r until EOF and returns index with the specified block size.
+func Build(r io.Reader, blockSize int64) (*Index, error) {
+	m, err := piece.Create(r, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return FromManifest(m)
+}
+
+// BlockSize returns the size of indexed blocks.
+func (ix *Index) BlockSize() int64 { return ix.blockSize }
+
+// Size returns the size of the object.
+func (ix *Index) Size() int64 { return ix.size }
+
+// Blocks returns hashes of the object blocks. They must not be modified.
+func (ix *Index) Blocks() []tz.Hash { return ix.levels[0] }
+
+// Root returns the hash of the whole object.
+func (ix *Index) Root() tz.Hash {
+	if top := ix.levels[len(ix.levels)-1]; len(top) != 0 {
+		return top[0]
+	}
+	return tz.Sum(nil)
+}
+
+// BlockRange returns the hash of blocks [i, j).
+func (ix *Index) BlockRange(i, j int) (tz.Hash, error) {
+	if i < 0 || j < i || j > len(ix.levels[0]) {
+		return tz.Hash{}, fmt.Errorf("block range [%d, %d) is out of bounds", i, j)
+	}
+
+	// Nodes covering the range are collected in the order of
+	// their appearance from both ends, like in a segment tree.
+	var left, right [][]byte
+	for k := 0; i < j; k++ {
+		if i&1 == 1 {
+			left = append(left, ix.levels[k][i][:])
+			i++
+		}
+		if j&1 == 1 {
+			j--
+			right = append(right, ix.levels[k][j][:])
+		}
+		i, j = i/2, j/2
+	}
+	for k := len(right) - 1; k >= 0; k-- {
+		left = append(left, right[k])
+	}
+	return concat(left), nil
+}
+
+// Range returns the hash of length bytes starting at off. Edges of the range
+// which are not aligned to blocks are read from r, which can be nil
+// if the range is aligned. The range end is aligned if it is the end
+// of the object.
+func (ix *Index) Range(r io.ReaderAt, off, length int64) (tz.Hash, error) {
+	if off < 0 || length < 0 || off > ix.size || length > ix.size-off {
+		return tz.Hash{}, fmt.Errorf("range [%d, %d) is out of bounds", off, off+length)
+	}
+
+	end := off + length
+	first := (off + ix.blockSize - 1) / ix.blockSize
+	last := end / ix.blockSize
+	if end == ix.size {
+		last = int64(len(ix.levels[0]))
+	}
+	if first >= last {
+		// No whole blocks in the range.
+		return ix.sumData(r, off, length)
+	}
+
+	hs := make([][]byte, 0, 3)
+	if start := first * ix.blockSize; off < start {
+		h, err := ix.sumData(r, off, start-off)
+		if err != nil {
+			return tz.Hash{}, err
+		}
+		hs = append(hs, h[:])
+	}
+
+	body, _ := ix.BlockRange(int(first), int(last))
+	hs = append(hs, body[:])
+
+	if start := last * ix.blockSize; start < end {
+		h, err := ix.sumData(r, start, end-start)
+		if err != nil {
+			return tz.Hash{}, err
+		}
+		hs = append(hs, h[:])
+	}
+	return concat(hs), nil
+}
+
+// sumData hashes length bytes of r starting at off.
+func (ix *Index) sumData(r io.ReaderAt, off, length int64) (tz.Hash, error) {
+	if length == 0 {
+		return tz.Sum(nil), nil
+	}
+	if r == nil {
+		return tz.Hash{}, ErrNoData
+	}
+
+	data, err := readAt(r, off, length)
+	if err != nil {
+		return tz.Hash{}, err
+	}
+	return tz.Sum(data), nil
+}
+
+// readAt reads exactly length bytes of r starting at off.
+func readAt(r io.ReaderAt, off, length int64) ([]byte, error) {
+	data := make([]byte, length)
+	if n, err := r.ReadAt(data, off); n != len(data) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// concat returns the product of valid hashes, which is the hash of
+// empty data if there are none.
+func concat(hs [][]byte) tz.Hash {
+	if len(hs) == 0 {
+		return tz.Sum(nil)
+	}
+	var h tz.Hash
+	c, _ := tz.Concat(hs)
+	copy(h[:], c)
+	return h
+}
@@ -0,0 +1,129 @@
+package rangehash
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/piece"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	const blockSize = 10
+
+	for _, size := range []int{0, 1, blockSize, 7*blockSize + 3, 16 * blockSize} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		ix, err := Build(bytes.NewReader(data), blockSize)
+		require.NoError(t, err)
+		require.EqualValues(t, size, ix.Size())
+		require.EqualValues(t, blockSize, ix.BlockSize())
+		require.Equal(t, tz.Hash(tz.Sum(data)), ix.Root())
+
+		for i := 0; i <= len(ix.Blocks()); i++ {
+			for j := i; j <= len(ix.Blocks()); j++ {
+				start, end := i*blockSize, j*blockSize
+				if end > size {
+					start, end = minInt(start, size), size
+				}
+				h, err := ix.BlockRange(i, j)
+				require.NoError(t, err)
+				require.Equal(t, tz.Hash(tz.Sum(data[start:end])), h, "blocks [%d, %d)", i, j)
+			}
+		}
+
+		r := bytes.NewReader(data)
+		for off := 0; off <= size; off++ {
+			for end := off; end <= size; end++ {
+				h, err := ix.Range(r, int64(off), int64(end-off))
+				require.NoError(t, err)
+				require.Equal(t, tz.Hash(tz.Sum(data[off:end])), h, "range [%d, %d)", off, end)
+			}
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestIndexRange(t *testing.T) {
+	data := make([]byte, 95)
+	_, _ = rand.Read(data)
+
+	m, err := piece.Create(bytes.NewReader(data), 10)
+	require.NoError(t, err)
+	ix, err := FromManifest(m)
+	require.NoError(t, err)
+
+	t.Run("aligned without data", func(t *testing.T) {
+		h, err := ix.Range(nil, 20, 50)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(data[20:70])), h)
+
+		h, err = ix.Range(nil, 90, 5)
+		require.NoError(t, err)
+		require.Equal(t, tz.Hash(tz.Sum(data[90:])), h)
+	})
+	t.Run("unaligned without data", func(t *testing.T) {
+		_, err := ix.Range(nil, 21, 50)
+		require.ErrorIs(t, err, ErrNoData)
+		_, err = ix.Range(nil, 20, 51)
+		require.ErrorIs(t, err, ErrNoData)
+	})
+	t.Run("out of bounds", func(t *testing.T) {
+		_, err := ix.Range(nil, -1, 1)
+		require.Error(t, err)
+		_, err = ix.Range(nil, 90, 6)
+		require.Error(t, err)
+		_, err = ix.BlockRange(3, 2)
+		require.Error(t, err)
+		_, err = ix.BlockRange(0, 11)
+		require.Error(t, err)
+	})
+	t.Run("short data", func(t *testing.T) {
+		_, err := ix.Range(bytes.NewReader(data[:50]), 41, 50)
+		require.Error(t, err)
+	})
+}
+
+func TestNewIndex(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte{1}))
+
+	_, err := NewIndex(nil, 0, 0)
+	require.Error(t, err)
+	_, err = NewIndex([]tz.Hash{h}, 10, 11)
+	require.Error(t, err)
+	_, err = NewIndex([]tz.Hash{h, {}}, 10, 11)
+	require.Error(t, err)
+
+	ix, err := NewIndex([]tz.Hash{h, h}, 10, 11)
+	require.NoError(t, err)
+	require.Len(t, ix.Blocks(), 2)
+}
+
+func BenchmarkIndexRange(b *testing.B) {
+	const blockSize = 4096
+
+	blocks := make([]tz.Hash, 1<<16)
+	for i := range blocks {
+		blocks[i] = tz.Sum([]byte{byte(i), byte(i >> 8)})
+	}
+	ix, err := NewIndex(blocks, blockSize, int64(len(blocks))*blockSize)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := ix.Range(nil, blockSize, (int64(len(blocks))-2)*blockSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package rangehash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// DefaultChunkSize is the amount of data hashed by a single Server worker.
+const DefaultChunkSize = 1 << 20
+
+// Option configures Server.
+type Option func(*config)
+
+type config struct {
+	index     *Index
+	chunkSize int64
+	workers   int
+}
+
+// WithIndex makes Server answer unsalted queries from the index
+// of precomputed block hashes. Only unaligned range edges are read then.
+func WithIndex(ix *Index) Option {
+	return func(c *config) {
+		c.index = ix
+	}
+}
+
+// WithChunkSize sets the amount of data hashed by a single worker.
+func WithChunkSize(n int64) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.chunkSize = n
+		}
+	}
+}
+
+// WithWorkers sets the maximal number of chunks hashed concurrently.
+// GOMAXPROCS is used by default.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// Server answers range hash queries about the object, like GETRANGEHASH
+// requests of NeoFS. It is safe for concurrent use if the underlying reader is.
+type Server struct {
+	r    io.ReaderAt
+	size int64
+	c    config
+}
+
+// NewServer returns Server for size bytes of the object read from r.
+func NewServer(r io.ReaderAt, size int64, opts ...Option) (*Server, error) {
+	s := &Server{
+		r:    r,
+		size: size,
+		c:    config{chunkSize: DefaultChunkSize, workers: runtime.GOMAXPROCS(0)},
+	}
+	for _, o := range opts {
+		o(&s.c)
+	}
+	if size < 0 {
+		return nil, errors.New("negative size")
+	}
+	if s.c.index != nil && s.c.index.Size() != size {
+		return nil, fmt.Errorf("index is built for %d bytes, object has %d", s.c.index.Size(), size)
+	}
+	return s, nil
+}
+
+// RangeHash returns the hash of length bytes of the object starting at off.
+// If salt is not empty, it is cyclically XOR-ed with the range data before
+// hashing, starting from the first byte of the range.
+//
+// The range is split into chunks hashed concurrently, then chunk hashes
+// are combined. Unsalted queries are answered from the index, if there is one.
+func (s *Server) RangeHash(off, length int64, salt []byte) (tz.Hash, error) {
+	if off < 0 || length < 0 || off > s.size || length > s.size-off {
+		return tz.Hash{}, fmt.Errorf("range [%d, %d) is out of bounds", off, off+length)
+	}
+	if len(salt) == 0 && s.c.index != nil {
+		return s.c.index.Range(s.r, off, length)
+	}
+
+	n := int((length + s.c.chunkSize - 1) / s.c.chunkSize)
+	if n == 0 {
+		return tz.Sum(nil), nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, s.c.workers)
+		hs   = make([][]byte, n)
+		errs = make([]error, n)
+	)
+	for i := 0; i < n; i++ {
+		start := int64(i) * s.c.chunkSize
+		size := s.c.chunkSize
+		if size > length-start {
+			size = length - start
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, start, size int64) {
+			defer func() { <-sem; wg.Done() }()
+
+			h, err := s.sumChunk(off+start, size, salt, start)
+			hs[i], errs[i] = h[:], err
+		}(i, start, size)
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil {
+			return tz.Hash{}, errs[i]
+		}
+	}
+	return concat(hs), nil
+}
+
+// sumChunk hashes size bytes of the object starting at off XOR-ed with salt,
+// pos is the offset of the chunk in the salted range.
+func (s *Server) sumChunk(off, size int64, salt []byte, pos int64) (tz.Hash, error) {
+	data, err := readAt(s.r, off, size)
+	if err != nil {
+		return tz.Hash{}, err
+	}
+	if len(salt) != 0 {
+		j := int(pos % int64(len(salt)))
+		for i := range data {
+			data[i] ^= salt[j]
+			if j++; j == len(salt) {
+				j = 0
+			}
+		}
+	}
+	return tz.Sum(data), nil
+}
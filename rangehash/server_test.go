@@ -0,0 +1,67 @@
+package rangehash
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func salted(data, salt []byte) []byte {
+	res := make([]byte, len(data))
+	for i := range data {
+		res[i] = data[i] ^ salt[i%len(salt)]
+	}
+	return res
+}
+
+func TestServer(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+	salt := []byte{1, 2, 3, 4, 5, 6, 7}
+
+	ix, err := Build(bytes.NewReader(data), 64)
+	require.NoError(t, err)
+
+	servers := map[string][]Option{
+		"default": nil,
+		"chunked": {WithChunkSize(30), WithWorkers(3)},
+		"index":   {WithIndex(ix), WithChunkSize(30)},
+	}
+	for name, opts := range servers {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewServer(bytes.NewReader(data), int64(len(data)), opts...)
+			require.NoError(t, err)
+
+			for _, r := range [][2]int{{0, 0}, {0, 1000}, {10, 100}, {64, 128}, {999, 1}, {1000, 0}} {
+				h, err := s.RangeHash(int64(r[0]), int64(r[1]), nil)
+				require.NoError(t, err)
+				require.Equal(t, tz.Hash(tz.Sum(data[r[0]:r[0]+r[1]])), h)
+
+				h, err = s.RangeHash(int64(r[0]), int64(r[1]), salt)
+				require.NoError(t, err)
+				require.Equal(t, tz.Hash(tz.Sum(salted(data[r[0]:r[0]+r[1]], salt))), h)
+			}
+
+			_, err = s.RangeHash(-1, 10, nil)
+			require.Error(t, err)
+			_, err = s.RangeHash(990, 11, salt)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("short data", func(t *testing.T) {
+		s, err := NewServer(bytes.NewReader(data[:500]), int64(len(data)), WithChunkSize(100))
+		require.NoError(t, err)
+		_, err = s.RangeHash(0, 1000, salt)
+		require.Error(t, err)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewServer(bytes.NewReader(data), -1)
+		require.Error(t, err)
+		_, err = NewServer(bytes.NewReader(data), 999, WithIndex(ix))
+		require.Error(t, err)
+	})
+}
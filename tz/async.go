@@ -0,0 +1,95 @@
+package tz
+
+import "errors"
+
+const (
+	// asyncBufferSize is the size of buffers queued by AsyncHasher.
+	asyncBufferSize = 64 << 10
+
+	// defaultAsyncQueue is the default number of AsyncHasher buffers.
+	defaultAsyncQueue = 4
+)
+
+// ErrFinished is returned by AsyncHasher.Write after Finish was called.
+var ErrFinished = errors.New("async hasher is finished")
+
+// AsyncHasher hashes data written to it on a background goroutine.
+// Write copies data into one of a bounded number of buffers and returns
+// as soon as it is queued, so that the writer is blocked only if hashing
+// can't keep up for longer than the queue allows. Like hash.Hash,
+// it is not safe for concurrent use.
+type AsyncHasher struct {
+	full     chan []byte
+	free     chan []byte
+	buffers  int
+	result   chan [Size]byte
+	finished bool
+}
+
+// NewAsyncHasher returns AsyncHasher with at most queue buffers of pending data
+// and starts its background goroutine. If queue is not positive, the default
+// of 4 is used. Finish must be called to stop the goroutine.
+func NewAsyncHasher(queue int) *AsyncHasher {
+	if queue <= 0 {
+		queue = defaultAsyncQueue
+	}
+
+	h := &AsyncHasher{
+		full:   make(chan []byte, queue),
+		free:   make(chan []byte, queue),
+		result: make(chan [Size]byte, 1),
+	}
+	go h.run()
+	return h
+}
+
+func (h *AsyncHasher) run() {
+	d := NewDigest()
+	for buf := range h.full {
+		_, _ = d.Write(buf)
+		h.free <- buf[:cap(buf)]
+	}
+	h.result <- d.checkSum()
+	close(h.result)
+}
+
+// Write implements io.Writer. It never returns an error before Finish.
+func (h *AsyncHasher) Write(p []byte) (int, error) {
+	if h.finished {
+		return 0, ErrFinished
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		buf := h.buffer()
+		k := copy(buf, p)
+		h.full <- buf[:k]
+		p = p[k:]
+	}
+	return n, nil
+}
+
+// buffer returns free buffer, allocating a new one if the limit allows.
+func (h *AsyncHasher) buffer() []byte {
+	select {
+	case buf := <-h.free:
+		return buf
+	default:
+	}
+	if h.buffers < cap(h.free) {
+		h.buffers++
+		return make([]byte, asyncBufferSize)
+	}
+	return <-h.free
+}
+
+// Finish stops accepting writes and returns channel which receives
+// checksum of all data written once it is hashed. The channel is closed
+// after that, subsequent calls return the same channel.
+func (h *AsyncHasher) Finish() <-chan [Size]byte {
+	if !h.finished {
+		h.finished = true
+		close(h.full)
+	}
+	return h.result
+}
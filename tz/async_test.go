@@ -0,0 +1,46 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncHasher(t *testing.T) {
+	data := make([]byte, 5*asyncBufferSize+123)
+	_, _ = rand.Read(data)
+
+	for _, chunk := range []int{1000, asyncBufferSize, 3*asyncBufferSize + 1, len(data)} {
+		h := NewAsyncHasher(2)
+
+		buf := make([]byte, chunk)
+		for i := 0; i < len(data); i += chunk {
+			n := copy(buf, data[i:])
+			k, err := h.Write(buf[:n])
+			require.NoError(t, err)
+			require.Equal(t, n, k)
+			// Data is copied, so the buffer can be reused right away.
+			for j := range buf {
+				buf[j] = 0
+			}
+		}
+
+		sum := h.Finish()
+		require.Equal(t, Sum(data), <-sum, "chunk %d", chunk)
+		require.True(t, h.buffers <= 2)
+	}
+
+	t.Run("finished", func(t *testing.T) {
+		h := NewAsyncHasher(0)
+		ch := h.Finish()
+		require.Equal(t, ch, h.Finish())
+		require.Equal(t, Sum(nil), <-ch)
+
+		_, ok := <-ch
+		require.False(t, ok)
+
+		_, err := h.Write([]byte{1})
+		require.ErrorIs(t, err, ErrFinished)
+	})
+}
@@ -0,0 +1,246 @@
+package tz
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackendAuto is a special backend name which makes the package
+// pick the fastest available backend by running a short benchmark
+// at first use. The decision is cached for the lifetime of the process.
+const BackendAuto = "auto"
+
+// BackendEnv is the name of environment variable which can be used
+// to override the default backend without recompiling. It accepts
+// the same values as SetBackend. Invalid values are ignored.
+// The variable is read once, when the default backend is first needed.
+const BackendEnv = "TZHASH_BACKEND"
+
+const (
+	autoBenchSize   = 1024
+	autoBenchRounds = 8
+)
+
+// cpuFeature describes CPU feature which is relevant for backend selection.
+type cpuFeature struct {
+	name string
+	has  *bool
+}
+
+type backend struct {
+	name      string
+	available func() bool
+	write     func(d *Digest, data []byte) (int, error)
+	// pclmul is true if matrices are multiplied with PCLMULQDQ kernel,
+	// see mulSL2 method.
+	pclmul bool
+}
+
+var (
+	genericBackend = &backend{
+		name:      "generic",
+		available: func() bool { return true },
+		write:     writeGeneric,
+	}
+
+	// backendsMtx protects allBackends which can be extended with RegisterBackend.
+	backendsMtx sync.RWMutex
+
+	// selected holds explicitly chosen *backend.
+	selected atomic.Value
+
+	defaultOnce     sync.Once
+	defaultSelected *backend
+
+	autoOnce     sync.Once
+	autoSelected *backend
+	autoBackend  = &backend{name: BackendAuto}
+)
+
+// Backend is an implementation of the hashing core.
+// It can be registered with RegisterBackend and then selected by name
+// in the same way as built-in backends.
+type Backend interface {
+	// Write updates hash state with data. State is a matrix of
+	// GF(2^127) elements stored in the following order:
+	// [ 0 2 ]
+	// [ 1 3 ]
+	Write(state *[4]GF127, data []byte)
+}
+
+// RegisterBackend makes a backend available under the provided name.
+// Registered backends are never selected by default, they need to be chosen
+// explicitly via SetBackend or BackendEnv, or picked by BackendAuto.
+// It is intended to be called from init function of the package providing
+// implementation and panics if the name is already used or impl is nil.
+func RegisterBackend(name string, impl Backend) {
+	if impl == nil {
+		panic("tz: RegisterBackend impl is nil")
+	}
+	if name == "" || name == BackendAuto {
+		panic("tz: RegisterBackend with reserved name " + name)
+	}
+
+	backendsMtx.Lock()
+	defer backendsMtx.Unlock()
+
+	for _, b := range allBackends {
+		if b.name == name {
+			panic("tz: RegisterBackend called twice for " + name)
+		}
+	}
+
+	allBackends = append(allBackends, &backend{
+		name:      name,
+		available: func() bool { return true },
+		write: func(d *Digest, data []byte) (int, error) {
+			impl.Write(&d.x, data)
+			return len(data), nil
+		},
+	})
+}
+
+// SetBackend sets the backend used for all subsequent hashing.
+// Digests resolve the backend on creation and Reset, so existing
+// digests continue to use the previous one until they are reset.
+// Empty name restores the default behaviour, which is to use the backend
+// specified in BackendEnv or the most capable backend supported by the CPU.
+// BackendAuto selects the fastest backend by benchmarking them at first use.
+func SetBackend(name string) error {
+	if name == "" {
+		selected.Store((*backend)(nil))
+		return nil
+	}
+
+	b, err := findBackend(name)
+	if err != nil {
+		return err
+	}
+	selected.Store(b)
+	return nil
+}
+
+func findBackend(name string) (*backend, error) {
+	if name == BackendAuto {
+		return autoBackend, nil
+	}
+
+	backendsMtx.RLock()
+	defer backendsMtx.RUnlock()
+
+	for _, b := range allBackends {
+		if b.name == name {
+			if !b.available() {
+				return nil, fmt.Errorf("backend is not supported by CPU: %s", name)
+			}
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown backend: %s", name)
+}
+
+// ActiveBackend returns the name of the backend currently used for hashing.
+func ActiveBackend() string {
+	return currentBackend().name
+}
+
+// Backends returns names of all backends supported by the CPU
+// in the order of preference.
+func Backends() []string {
+	backendsMtx.RLock()
+	defer backendsMtx.RUnlock()
+
+	var names []string
+	for _, b := range allBackends {
+		if b.available() {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// CPUFeatures returns names of CPU features detected at runtime
+// which are taken into account when choosing a backend.
+func CPUFeatures() []string {
+	var names []string
+	for _, f := range cpuFeatures {
+		if *f.has {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func currentBackend() *backend {
+	b, _ := selected.Load().(*backend)
+	if b == nil {
+		defaultOnce.Do(func() { defaultSelected = defaultBackend() })
+		b = defaultSelected
+	}
+	if b == autoBackend {
+		autoOnce.Do(func() { autoSelected = fastestBackend() })
+		return autoSelected
+	}
+	return b
+}
+
+// defaultBackend returns the backend specified in BackendEnv or,
+// if it is not set or invalid, the most capable backend supported by CPU.
+// Environment is consulted lazily, so that backends registered
+// in init functions of other packages can also be used.
+func defaultBackend() *backend {
+	if name := os.Getenv(BackendEnv); name != "" {
+		if b, err := findBackend(name); err == nil {
+			return b
+		}
+	}
+
+	backendsMtx.RLock()
+	defer backendsMtx.RUnlock()
+
+	for _, b := range allBackends {
+		if b.available() {
+			return b
+		}
+	}
+	return genericBackend
+}
+
+// fastestBackend runs every available backend on a small buffer
+// and returns the one which took the least time.
+func fastestBackend() *backend {
+	var (
+		best    *backend
+		bestDur time.Duration
+		data    = make([]byte, autoBenchSize)
+
+		// Digest state doesn't affect performance, so there is no need
+		// to call Reset here, which would in turn resolve the backend.
+		d = new(Digest)
+	)
+
+	backendsMtx.RLock()
+	defer backendsMtx.RUnlock()
+
+	for _, b := range allBackends {
+		if !b.available() {
+			continue
+		}
+
+		start := time.Now()
+		for i := 0; i < autoBenchRounds; i++ {
+			_, _ = b.write(d, data)
+		}
+		if dur := time.Since(start); best == nil || dur < bestDur {
+			best, bestDur = b, dur
+		}
+	}
+
+	if best == nil {
+		return genericBackend
+	}
+	return best
+}
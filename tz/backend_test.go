@@ -0,0 +1,111 @@
+package tz
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBackend(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetBackend("")) })
+
+	for _, name := range Backends() {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, SetBackend(name))
+			require.Equal(t, name, ActiveBackend())
+
+			for _, tc := range testCases {
+				sum := Sum(tc.input)
+				require.Equal(t, tc.hash, hex.EncodeToString(sum[:]))
+			}
+		})
+	}
+
+	t.Run("auto", func(t *testing.T) {
+		require.NoError(t, SetBackend(BackendAuto))
+		require.Contains(t, Backends(), ActiveBackend())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		require.NoError(t, SetBackend(""))
+		require.Equal(t, Backends()[0], ActiveBackend())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		require.Error(t, SetBackend("unknown"))
+	})
+}
+
+func TestCPUFeatures(t *testing.T) {
+	var known []string
+	for _, f := range cpuFeatures {
+		known = append(known, f.name)
+	}
+	for _, f := range CPUFeatures() {
+		require.Contains(t, known, f)
+	}
+}
+
+func TestBackendEnv(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, os.Unsetenv(BackendEnv))
+	})
+
+	require.NoError(t, os.Setenv(BackendEnv, "generic"))
+	require.Equal(t, "generic", defaultBackend().name)
+
+	require.NoError(t, os.Setenv(BackendEnv, "unknown"))
+	require.Equal(t, Backends()[0], defaultBackend().name)
+}
+
+type testBackend struct {
+	calls int
+}
+
+func (b *testBackend) Write(state *[4]GF127, data []byte) {
+	b.calls++
+
+	d := Digest{x: *state}
+	_, _ = writeGeneric(&d, data)
+	*state = d.x
+}
+
+func TestRegisterBackend(t *testing.T) {
+	impl := new(testBackend)
+	RegisterBackend("test", impl)
+
+	require.Contains(t, Backends(), "test")
+	require.NotEqual(t, "test", Backends()[0])
+
+	// Digests pooled before the backend was selected must not be used with
+	// the previous one.
+	pooled := make([]*Digest, 10)
+	for i := range pooled {
+		pooled[i] = Get()
+	}
+	for _, d := range pooled {
+		Put(d)
+	}
+
+	prepareBackend(t, "test")
+	d := Get()
+	require.Equal(t, "test", d.b.name)
+	Put(d)
+	_ = Sum([]byte{1})
+	require.Equal(t, 1, impl.calls)
+
+	for _, tc := range testCases {
+		sum := Sum(tc.input)
+		require.Equal(t, tc.hash, hex.EncodeToString(sum[:]))
+	}
+	require.NotZero(t, impl.calls)
+
+	require.NoError(t, SelfTest())
+
+	require.Panics(t, func() { RegisterBackend("test", impl) })
+	require.Panics(t, func() { RegisterBackend("generic", impl) })
+	require.Panics(t, func() { RegisterBackend(BackendAuto, impl) })
+	require.Panics(t, func() { RegisterBackend("nil", nil) })
+}
@@ -0,0 +1,95 @@
+package tz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CBORTag is the CBOR tag of Tillich-Zémor hash. There is no registered tag
+// for it, so the one from the first come first served range is used
+// ("TZH" in ASCII).
+const CBORTag = 0x545a48
+
+// CBOR major types.
+const (
+	cborBytes = 2
+	cborTag   = 6
+)
+
+// MarshalCBOR implements cbor.Marshaler. Hash is encoded as a byte string
+// tagged with CBORTag.
+func (h Hash) MarshalCBOR() ([]byte, error) {
+	b := make([]byte, 0, 5+2+Size)
+	b = appendCBORHead(b, cborTag, CBORTag)
+	b = appendCBORHead(b, cborBytes, Size)
+	return append(b, h[:]...), nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler. Both tagged and untagged
+// byte strings are accepted.
+func (h *Hash) UnmarshalCBOR(data []byte) error {
+	major, arg, rest, err := readCBORHead(data)
+	if err != nil {
+		return err
+	}
+	if major == cborTag {
+		if arg != CBORTag {
+			return fmt.Errorf("unexpected CBOR tag %d", arg)
+		}
+		if major, arg, rest, err = readCBORHead(rest); err != nil {
+			return err
+		}
+	}
+	if major != cborBytes {
+		return fmt.Errorf("unexpected CBOR major type %d", major)
+	}
+	if arg != Size || len(rest) != Size {
+		return fmt.Errorf("invalid hash length: expected %d, got %d", Size, len(rest))
+	}
+	copy(h[:], rest)
+	return nil
+}
+
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(b, major|byte(arg))
+	case arg <= 0xff:
+		return append(b, major|24, byte(arg))
+	case arg <= 0xffff:
+		return append(b, major|25, byte(arg>>8), byte(arg))
+	case arg <= 0xffffffff:
+		return append(b, major|26, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	default:
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], arg)
+		return append(append(b, major|27), buf[:]...)
+	}
+}
+
+// readCBORHead decodes the initial byte with its argument and returns
+// the rest of b. Indefinite lengths are not supported.
+func readCBORHead(b []byte) (byte, uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, 0, nil, errors.New("unexpected end of CBOR data")
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+	if info < 24 {
+		return major, uint64(info), b, nil
+	}
+	if info > 27 {
+		return 0, 0, nil, fmt.Errorf("unsupported CBOR additional info %d", info)
+	}
+	n := 1 << (info - 24)
+	if len(b) < n {
+		return 0, 0, nil, errors.New("unexpected end of CBOR data")
+	}
+	var arg uint64
+	for _, c := range b[:n] {
+		arg = arg<<8 | uint64(c)
+	}
+	return major, arg, b[n:], nil
+}
@@ -0,0 +1,51 @@
+package tz
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOR(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	b, err := h.MarshalCBOR()
+	require.NoError(t, err)
+	require.Equal(t, "da00545a48"+"5840"+h.String(), hex.EncodeToString(b))
+
+	var actual Hash
+	require.NoError(t, actual.UnmarshalCBOR(b))
+	require.Equal(t, h, actual)
+
+	t.Run("untagged", func(t *testing.T) {
+		var actual Hash
+		require.NoError(t, actual.UnmarshalCBOR(b[5:]))
+		require.Equal(t, h, actual)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, b := range [][]byte{
+			nil,
+			b[:3],
+			b[:len(b)-1],
+			append(b[:len(b):len(b)], 0),
+			append([]byte{0xc2}, b[5:]...),      // bignum tag
+			append([]byte{0x78, 0x40}, h[:]...), // text string
+			append([]byte{0x5f}, h[:]...),       // indefinite length
+			append([]byte{0x59, 0x00, 0x41}, b[7:]...),      // wrong length
+			append([]byte{0xda, 0x00, 0x54, 0x5a, 0x48}, 0), // missing data
+		} {
+			var actual Hash
+			require.Error(t, actual.UnmarshalCBOR(b), "%x", b)
+		}
+	})
+	t.Run("head", func(t *testing.T) {
+		for _, arg := range []uint64{0, 23, 24, 0xff, 0x100, 0xffff, 0x10000, 0xffffffff, 0x100000000, 1<<64 - 1} {
+			major, actual, rest, err := readCBORHead(appendCBORHead(nil, cborTag, arg))
+			require.NoError(t, err)
+			require.Equal(t, byte(cborTag), major)
+			require.Equal(t, arg, actual)
+			require.Empty(t, rest)
+		}
+	})
+}
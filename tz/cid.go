@@ -0,0 +1,48 @@
+package tz
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CIDCodecRaw is the multicodec code of raw binary content used in CIDs.
+const CIDCodecRaw = 0x55
+
+// cidBase32 is the base32 multibase encoding, the default one for CIDv1.
+var cidBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// EncodeCID returns CIDv1 of raw content with the Tillich-Zémor multihash
+// in the base32 multibase encoding.
+func EncodeCID(h Hash) string {
+	b := appendUvarint([]byte{1}, CIDCodecRaw)
+	b = append(b, EncodeMultihash(h)...)
+	return "b" + cidBase32.EncodeToString(b)
+}
+
+// DecodeCID returns Tillich-Zémor hash of CIDv1 in the base32 multibase
+// encoding. Content codec isn't checked, so CIDs of any content can be decoded.
+func DecodeCID(s string) (Hash, error) {
+	var h Hash
+
+	if len(s) == 0 || s[0] != 'b' && s[0] != 'B' {
+		return h, errors.New("CID must be base32-encoded")
+	}
+	b, err := cidBase32.DecodeString(strings.ToLower(s[1:]))
+	if err != nil {
+		return h, fmt.Errorf("invalid CID: %w", err)
+	}
+
+	version, b, err := readUvarint(b)
+	if err != nil {
+		return h, fmt.Errorf("invalid CID version: %w", err)
+	}
+	if version != 1 {
+		return h, fmt.Errorf("unsupported CID version %d", version)
+	}
+	if _, b, err = readUvarint(b); err != nil {
+		return h, fmt.Errorf("invalid CID codec: %w", err)
+	}
+	return DecodeMultihash(b)
+}
@@ -0,0 +1,37 @@
+package tz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCID(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	s := EncodeCID(h)
+	require.True(t, strings.HasPrefix(s, "bafk"), s) // version 1, raw codec
+
+	actual, err := DecodeCID(s)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	actual, err = DecodeCID(strings.ToUpper(s))
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"z" + s[1:],
+			s + "!",
+			s[:len(s)-2],
+			"b" + cidBase32.EncodeToString(append([]byte{2, CIDCodecRaw}, EncodeMultihash(h)...)),
+			"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku", // sha2-256
+		} {
+			_, err := DecodeCID(s)
+			require.Error(t, err, s)
+		}
+	})
+}
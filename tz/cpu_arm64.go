@@ -0,0 +1,16 @@
+//go:build arm64 && !generic && !purego && !tinygo
+// +build arm64,!generic,!purego,!tinygo
+
+package tz
+
+import "golang.org/x/sys/cpu"
+
+// There are no ARM64-specific backends yet, but features required
+// by future kernels (carry-less multiplication, SIMD) are detected
+// here so that they can be selected safely at runtime.
+var cpuFeatures = []cpuFeature{
+	{"asimd", &cpu.ARM64.HasASIMD},
+	{"pmull", &cpu.ARM64.HasPMULL},
+	{"sha1", &cpu.ARM64.HasSHA1},
+	{"sha2", &cpu.ARM64.HasSHA2},
+}
@@ -0,0 +1,7 @@
+//go:build !(amd64 && !generic && !purego && !tinygo) && !(arm64 && !generic && !purego && !tinygo)
+// +build !amd64 generic purego tinygo
+// +build !arm64 generic purego tinygo
+
+package tz
+
+var cpuFeatures []cpuFeature
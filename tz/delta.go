@@ -0,0 +1,103 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDeltaOld is returned by VerifyDelta if the spans don't
+	// make up the old data.
+	ErrDeltaOld = errors.New("spans don't match old hash")
+	// ErrDeltaNew is returned by VerifyDelta if replacing changed spans
+	// doesn't produce the new data.
+	ErrDeltaNew = errors.New("spans don't match new hash")
+)
+
+// Span is a contiguous part of the data taking part in a delta update.
+// Unchanged spans are described by Old and OldSize only, New and NewSize
+// are ignored for them.
+type Span struct {
+	Changed bool
+	Old     Hash
+	OldSize int64
+	New     Hash
+	NewSize int64
+}
+
+// VerifyDelta checks that the new data was obtained from the old one
+// by replacing changed spans only. Spans must cover the whole old data in order.
+// Because the hash is homomorphic, only span hashes are needed:
+// concatenation of old span hashes must be equal to oldHash, and
+// the same product with changed spans replaced must be equal to newHash.
+// Sizes are checked only if they are non-negative.
+func VerifyDelta(oldHash, newHash Hash, oldSize, newSize int64, spans []Span) error {
+	var (
+		po, pn, c sl2
+		so, sn    int64
+	)
+
+	if len(spans) == 0 {
+		return errors.New("empty span list")
+	}
+
+	po, pn = id, id
+	for i := range spans {
+		s := &spans[i]
+		if s.OldSize < 0 || s.Changed && s.NewSize < 0 {
+			return fmt.Errorf("span %d: negative size", i)
+		}
+		if err := c.UnmarshalBinary(s.Old[:]); err != nil {
+			return fmt.Errorf("span %d: old hash: %w", i, err)
+		}
+		mulSL2(&po, &c, &po)
+		so += s.OldSize
+
+		if s.Changed {
+			if err := c.UnmarshalBinary(s.New[:]); err != nil {
+				return fmt.Errorf("span %d: new hash: %w", i, err)
+			}
+			sn += s.NewSize
+		} else {
+			sn += s.OldSize
+		}
+		mulSL2(&pn, &c, &pn)
+	}
+
+	if oldSize >= 0 && so != oldSize {
+		return fmt.Errorf("%w: size %d, expected %d", ErrDeltaOld, so, oldSize)
+	}
+	if Hash(po.Bytes()) != oldHash {
+		return ErrDeltaOld
+	}
+	if newSize >= 0 && sn != newSize {
+		return fmt.Errorf("%w: size %d, expected %d", ErrDeltaNew, sn, newSize)
+	}
+	if Hash(pn.Bytes()) != newHash {
+		return ErrDeltaNew
+	}
+	return nil
+}
+
+// ReplaceRange returns the hash of the data after replacing a single range.
+// prefix is the hash of the data before the range, oldRange and newRange are hashes
+// of the range before and after the update. The hash of the suffix is
+// recovered from whole, so it needn't be known.
+func ReplaceRange(whole, prefix, oldRange, newRange Hash) (Hash, error) {
+	var res Hash
+
+	rest, err := SubtractL(whole[:], prefix[:])
+	if err != nil {
+		return res, err
+	}
+	suffix, err := SubtractL(rest, oldRange[:])
+	if err != nil {
+		return res, err
+	}
+	b, err := Concat([][]byte{prefix[:], newRange[:], suffix})
+	if err != nil {
+		return res, err
+	}
+	copy(res[:], b)
+	return res, nil
+}
@@ -0,0 +1,55 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDelta(t *testing.T) {
+	old := make([]byte, 3000)
+	rand.New(rand.NewSource(1)).Read(old)
+
+	repl := []byte("replacement of a different size")
+	upd := append(append(append([]byte{}, old[:1000]...), repl...), old[1500:]...)
+
+	spans := []Span{
+		{Old: Sum(old[:1000]), OldSize: 1000},
+		{Changed: true, Old: Sum(old[1000:1500]), OldSize: 500, New: Sum(repl), NewSize: int64(len(repl))},
+		{Old: Sum(old[1500:]), OldSize: 1500},
+	}
+	oldHash, newHash := Hash(Sum(old)), Hash(Sum(upd))
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, VerifyDelta(oldHash, newHash, int64(len(old)), int64(len(upd)), spans))
+		require.NoError(t, VerifyDelta(oldHash, newHash, -1, -1, spans))
+	})
+	t.Run("unchanged span modified", func(t *testing.T) {
+		bad := append([]byte{}, upd...)
+		bad[2000] ^= 1
+		require.ErrorIs(t, VerifyDelta(oldHash, Sum(bad), -1, -1, spans), ErrDeltaNew)
+	})
+	t.Run("wrong old", func(t *testing.T) {
+		require.ErrorIs(t, VerifyDelta(newHash, newHash, -1, -1, spans), ErrDeltaOld)
+	})
+	t.Run("size mismatch", func(t *testing.T) {
+		require.ErrorIs(t, VerifyDelta(oldHash, newHash, -1, int64(len(upd))+1, spans), ErrDeltaNew)
+		require.ErrorIs(t, VerifyDelta(oldHash, newHash, int64(len(old))-1, -1, spans), ErrDeltaOld)
+	})
+	t.Run("empty", func(t *testing.T) {
+		require.Error(t, VerifyDelta(oldHash, newHash, -1, -1, nil))
+	})
+}
+
+func TestReplaceRange(t *testing.T) {
+	old := make([]byte, 2000)
+	rand.New(rand.NewSource(2)).Read(old)
+
+	repl := []byte("new content")
+	upd := append(append(append([]byte{}, old[:700]...), repl...), old[900:]...)
+
+	h, err := ReplaceRange(Sum(old), Sum(old[:700]), Sum(old[700:900]), Sum(repl))
+	require.NoError(t, err)
+	require.Equal(t, Hash(Sum(upd)), h)
+}
@@ -1,6 +1,12 @@
 package tz
 
 import (
+	"context"
+	"errors"
+	"hash"
+	"time"
+	"unsafe"
+
 	"github.com/nspcc-dev/tzhash/gf127"
 )
 
@@ -8,41 +14,214 @@ const (
 	// Size is the size of a Tillich-Zémor hash sum in bytes.
 	Size          = 64
 	hashBlockSize = 128
+
+	// smallInputSize is the maximum size of data hashed by Sum directly,
+	// without buffering and zero-run detection.
+	smallInputSize = 64
+
+	// stateMagic prefixes marshaled digest state.
+	stateMagic = "tz\x01"
+	stateSize  = len(stateMagic) + Size
+
+	// cacheLineSize is the assumed size of a CPU cache line.
+	cacheLineSize = 64
+
+	// digestPad pads Digest to a multiple of cache line size.
+	digestPad = cacheLineSize - (unsafe.Sizeof([]byte(nil))+
+		unsafe.Sizeof(int(0))+unsafe.Sizeof((*backend)(nil))+unsafe.Sizeof(false))%cacheLineSize
 )
 
-type digest struct {
+// Digest computes Tillich-Zémor checksum. In addition to hash.Hash it provides
+// methods for cloning and (un)marshaling intermediate state.
+// The zero value is ready to use and is equivalent to NewDigest().
+type Digest struct {
 	// Stores matrix cells in the following order:
 	// [ 0 2 ]
 	// [ 1 3 ]
 	// This is done to reuse the same digest between generic
 	// and AVX2 implementation.
 	x [4]GF127
+
+	// buf coalesces small writes before they are passed to the backend.
+	buf  []byte
+	nbuf int
+
+	// b is a backend used by the digest. It is resolved on Reset
+	// so that the hot loop doesn't need to perform any dispatching.
+	b *backend
+
+	// defaultBuf is set if buf was allocated by the package with the default
+	// size, only such digests are reused by Put.
+	defaultBuf bool
+
+	// Matrix occupies exactly one cache line and the whole digest is
+	// a multiple of it, so the allocator places separate digests
+	// on separate lines and concurrent hashers don't suffer from false sharing.
+	_ [digestPad]byte
+}
+
+// Option configures a digest created with New or NewDigest.
+type Option func(*Digest)
+
+// WithBufferSize sets the size of the internal buffer used to coalesce small
+// writes, 128 bytes by default. Writes of at least this size are
+// processed directly. Larger buffers reduce the number of backend calls for
+// streams of small writes at the cost of memory, zero disables buffering.
+func WithBufferSize(n int) Option {
+	return func(d *Digest) {
+		if n < 0 {
+			n = 0
+		}
+		d.buf = make([]byte, n)
+	}
+}
+
+// WithBuffer makes digest use buf as the internal buffer instead of
+// allocating one, its length determines the buffer size. Together with
+// Reset this allows to hash without any allocations in the steady state.
+// buf must not be accessed by the caller while the digest is in use.
+// Such digests are never reused by Put, because buf is owned by the caller.
+func WithBuffer(buf []byte) Option {
+	return func(d *Digest) {
+		d.buf = buf[:len(buf):len(buf)]
+	}
 }
 
 // New returns a new hash.Hash computing the Tillich-Zémor checksum.
-func New() *digest {
-	d := new(digest)
+func New(opts ...Option) hash.Hash {
+	return NewDigest(opts...)
+}
+
+// NewDigest returns a new Digest computing the Tillich-Zémor checksum.
+func NewDigest(opts ...Option) *Digest {
+	d := new(Digest)
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.buf == nil {
+		d.buf = make([]byte, hashBlockSize)
+		d.defaultBuf = true
+	}
 	d.Reset()
 	return d
 }
 
 // Sum returns Tillich-Zémor checksum of data.
 func Sum(data []byte) [Size]byte {
-	d := new(digest)
-	d.Reset()
-	_, _ = d.Write(data) // no errors
-	return d.checkSum()
+	return SumContext(context.Background(), data)
+}
+
+// SumContext is like Sum, but the span started by the Tracer, if any,
+// is a child of the one in ctx.
+func SumContext(ctx context.Context, data []byte) [Size]byte {
+	sp := startSpan(ctx, "tz.Sum", int64(len(data)))
+	h := sum(data)
+	if sp != nil {
+		sp.End(int64(len(data)), nil)
+	}
+	return h
+}
+
+func sum(data []byte) [Size]byte {
+	var (
+		m     = currentMetrics()
+		start time.Time
+	)
+	if m != nil {
+		start = time.Now()
+	}
+
+	d := getDigest()
+	if len(data) <= smallInputSize {
+		addBytes(m, d.b.name, len(data))
+		_, _ = d.b.write(d, data)
+	} else {
+		_, _ = d.Write(data) // no errors
+	}
+	h := d.checkSum()
+
+	addOp(m, OpSum)
+	if m != nil {
+		m.ObserveSum(d.b.name, time.Since(start))
+	}
+	Put(d)
+	return h
 }
 
 // Sum implements hash.Hash.
-func (d *digest) Sum(in []byte) []byte {
-	// Make a copy of d so that caller can keep writing and summing.
-	d0 := *d
-	h := d0.checkSum()
+func (d *Digest) Sum(in []byte) []byte {
+	// Flushing buffered data doesn't change the resulting hash,
+	// so the caller can keep writing and summing.
+	h := d.checkSum()
 	return append(in, h[:]...)
 }
 
-func (d *digest) checkSum() (b [Size]byte) {
+// Checksum returns Tillich-Zémor checksum of the data written so far.
+// Like Sum it doesn't change the digest state, but avoids allocations.
+func (d *Digest) Checksum() [Size]byte {
+	return d.checkSum()
+}
+
+// Clone returns an independent copy of the digest.
+func (d *Digest) Clone() *Digest {
+	d.init()
+	c := *d
+	c.buf = make([]byte, len(d.buf))
+	c.defaultBuf = len(c.buf) == hashBlockSize
+	copy(c.buf, d.buf[:d.nbuf])
+	return &c
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// It returns digest state which can be restored with UnmarshalBinary
+// to continue hashing, e.g. after process restart.
+func (d *Digest) MarshalBinary() ([]byte, error) {
+	d.init()
+	d.flush()
+
+	b := make([]byte, 0, stateSize)
+	b = append(b, stateMagic...)
+	for _, i := range [4]int{0, 2, 1, 3} {
+		t := d.x[i].Bytes()
+		b = append(b, t[:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// It restores state produced by MarshalBinary.
+func (d *Digest) UnmarshalBinary(data []byte) error {
+	if len(data) != stateSize || string(data[:len(stateMagic)]) != stateMagic {
+		return errors.New("invalid digest state")
+	}
+
+	var c sl2
+	if err := c.UnmarshalBinary(data[len(stateMagic):]); err != nil {
+		return err
+	}
+
+	// Zero digest is allocated by decoders like encoding/gob.
+	d.init()
+	d.setSL2(&c)
+	d.nbuf = 0
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder, the encoding is the same
+// as the one of MarshalBinary.
+func (d *Digest) GobEncode() ([]byte, error) {
+	return d.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (d *Digest) GobDecode(data []byte) error {
+	return d.UnmarshalBinary(data)
+}
+
+func (d *Digest) checkSum() (b [Size]byte) {
+	d.init()
+	d.flush()
+
 	t := d.x[0].Bytes()
 	copy(b[:], t[:])
 
@@ -58,67 +237,129 @@ func (d *digest) checkSum() (b [Size]byte) {
 	return
 }
 
+// init initializes the zero digest.
+func (d *Digest) init() {
+	if d.b != nil {
+		return
+	}
+	if d.buf == nil {
+		d.buf = make([]byte, hashBlockSize)
+		d.defaultBuf = true
+	}
+	d.Reset()
+}
+
 // Reset implements hash.Hash.
-func (d *digest) Reset() {
+func (d *Digest) Reset() {
 	d.x[0] = GF127{1, 0}
 	d.x[1] = GF127{0, 0}
 	d.x[2] = GF127{0, 0}
 	d.x[3] = GF127{1, 0}
+	d.nbuf = 0
+	d.b = currentBackend()
 }
 
 // Write implements hash.Hash.
-func (d *digest) Write(data []byte) (n int, err error) {
-	return write(d, data)
+func (d *Digest) Write(data []byte) (n int, err error) {
+	d.init()
+	n = len(data)
+	addBytes(currentMetrics(), d.b.name, n)
+	if d.nbuf > 0 {
+		if len(data) >= len(d.buf) {
+			// Backends handle arbitrary alignment and length, so large
+			// writes are processed in-place without copying them into
+			// the buffer: only pending data is flushed.
+			d.flush()
+		} else {
+			k := copy(d.buf[d.nbuf:], data)
+			d.nbuf += k
+			data = data[k:]
+			if d.nbuf < len(d.buf) {
+				return
+			}
+			d.flush()
+		}
+	}
+
+	if len(data) < len(d.buf) {
+		d.nbuf = copy(d.buf, data)
+		return
+	}
+
+	d.write(data)
+	return
+}
+
+// write passes data to the backend, skipping long runs of zeros
+// which are processed with precomputed matrices instead.
+func (d *Digest) write(data []byte) {
+	for len(data) >= zeroRunSize {
+		i, k := zeroRun(data, zeroRunSize)
+		if k == 0 {
+			break
+		}
+		_, _ = d.b.write(d, data[:i])
+		d.mulZeros(k)
+		data = data[i+k:]
+	}
+	_, _ = d.b.write(d, data)
 }
 
-func writeGeneric(d *digest, data []byte) (n int, err error) {
+// flush processes data accumulated in the internal buffer.
+func (d *Digest) flush() {
+	if d.nbuf != 0 {
+		_, _ = d.b.write(d, d.buf[:d.nbuf])
+		d.nbuf = 0
+	}
+}
+
+func writeGeneric(d *Digest, data []byte) (n int, err error) {
+	var tmp GF127
+
 	n = len(data)
-	tmp := new(GF127)
 	for _, b := range data {
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x80 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x40 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x20 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x10 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x08 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x04 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x02 != 0, tmp)
-		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b&0x01 != 0, tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 7), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 6), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 5), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 4), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 3), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 2), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 1), &tmp)
+		mulBitRightGeneric(&d.x[0], &d.x[1], &d.x[2], &d.x[3], bitMask(b, 0), &tmp)
 	}
 	return
 }
 
+// bitMask returns mask with all bits set if i-th bit of b is set and 0 otherwise.
+func bitMask(b byte, i uint) uint64 {
+	return -uint64(b >> i & 1)
+}
+
 // Size implements hash.Hash.
-func (d *digest) Size() int {
+func (d *Digest) Size() int {
 	return Size
 }
 
 // BlockSize implements hash.Hash.
-func (d *digest) BlockSize() int {
+func (d *Digest) BlockSize() int {
 	return hashBlockSize
 }
 
-func mulBitRightGeneric(c00, c10, c01, c11 *GF127, bit bool, tmp *GF127) {
-	if bit {
-		*tmp = *c00
-		gf127.Mul10(c00, c00)
-		gf127.Add(c00, c01, c00)
-		gf127.Mul11(tmp, tmp)
-		gf127.Add(c01, tmp, c01)
-
-		*tmp = *c10
-		gf127.Mul10(c10, c10)
-		gf127.Add(c10, c11, c10)
-		gf127.Mul11(tmp, tmp)
-		gf127.Add(c11, tmp, c11)
-	} else {
-		*tmp = *c00
-		gf127.Mul10(c00, c00)
-		gf127.Add(c00, c01, c00)
-		*c01 = *tmp
+// mulBitRightGeneric multiplies matrix by A (mask is 0) or
+// B (mask has all bits set) from the right:
+// c00, c01 = c00*x + c01, c00 + (c00*x + c01) & mask
+// Masking is used instead of branching because input bits are random
+// and mispredicted branches are expensive, especially on 32-bit platforms.
+func mulBitRightGeneric(c00, c10, c01, c11 *GF127, mask uint64, tmp *GF127) {
+	gf127.Mul10(c00, tmp)
+	gf127.Add(tmp, c01, tmp)
+	c01[0] = c00[0] ^ tmp[0]&mask
+	c01[1] = c00[1] ^ tmp[1]&mask
+	*c00 = *tmp
 
-		*tmp = *c10
-		gf127.Mul10(c10, c10)
-		gf127.Add(c10, c11, c10)
-		*c11 = *tmp
-	}
+	gf127.Mul10(c10, tmp)
+	gf127.Add(tmp, c11, tmp)
+	c11[0] = c10[0] ^ tmp[0]&mask
+	c11[1] = c10[1] ^ tmp[1]&mask
+	*c10 = *tmp
 }
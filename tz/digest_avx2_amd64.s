@@ -1,5 +1,17 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
 #include "textflag.h"
 
+#define prefetchDistance 512
+
+// mulBit multiplies matrix stored in (in_1, in_2) by A or B depending on bit
+// and stores the result in (out_1, out_2).
+// Reduction modulo x^127 + x^63 + 1 is performed eagerly on every step:
+// element of GF(2^127) occupies the whole 128-bit lane, thus an unreduced
+// value can't survive the next shift and delaying the reduction would
+// require wider lanes which cost more than the reduction itself
+// (VPAND + VPUNPCKHQDQ + VPXOR).
 #define mulBit(bit, in_1, in_2, out_1, out_2) \
 	VPSLLW      bit, Y10, Y11    \
 	VPSLLQ      $1, in_1, Y1     \
@@ -34,6 +46,14 @@ loop:
 	CMPQ CX, $0
 	JEQ  finish
 
+	// Data is processed much slower than it can be loaded,
+	// so a hint once per 64 bytes (a cache line) is enough to hide memory latency.
+	// Prefetching never faults, thus reading past the end is safe.
+	TESTQ      $63, CX
+	JNE        noprefetch
+	PREFETCHT0 prefetchDistance(DX)
+
+noprefetch:
 	VPBROADCASTB (DX), Y10
 	ADDQ         $1, DX
 	SUBQ         $1, CX
@@ -53,4 +73,60 @@ finish:
 	VMOVDQU Y0, (AX)
 	VMOVDQU Y8, (BX)
 
+	VZEROUPPER
+	RET
+
+#define mulByte(offset) \
+	VPBROADCASTB offset(DX), Y10 \
+	mulBit($8, Y0, Y8, Y5, Y6)   \
+	mulBit($9, Y5, Y6, Y0, Y8)   \
+	mulBit($10, Y0, Y8, Y5, Y6)  \
+	mulBit($11, Y5, Y6, Y0, Y8)  \
+	mulBit($12, Y0, Y8, Y5, Y6)  \
+	mulBit($13, Y5, Y6, Y0, Y8)  \
+	mulBit($14, Y0, Y8, Y5, Y6)  \
+	mulBit($15, Y5, Y6, Y0, Y8)
+
+// func mulBlock4096Rightx2(c00c10, c01c11 *[4]uint64, data *byte)
+// It is the same as mulByteSliceRightx2 with n = 4096,
+// but is unrolled to process 8 bytes per iteration.
+TEXT ·mulBlock4096Rightx2(SB), NOSPLIT, $0
+	MOVQ c00c10+0(FP), AX
+	MOVQ c01c11+8(FP), BX
+
+	VPXOR    Y13, Y13, Y13 // Y13 = 0x0000...
+	VPCMPEQB Y14, Y14, Y14 // Y14 = 0xFFFF...
+	VPSUBQ   Y14, Y13, Y10
+	VPSLLQ   $63, Y10, Y14 // Y14 = 0x10000000... (packed quad-words with HSB set)
+
+	MOVQ $512, CX
+	MOVQ data+16(FP), DX
+
+	VMOVDQU (AX), Y0
+	VMOVDQU (BX), Y8
+
+loop4096:
+	// 8 bytes are processed per iteration, prefetch on every 8th one.
+	TESTQ      $7, CX
+	JNE        noprefetch4096
+	PREFETCHT0 prefetchDistance(DX)
+
+noprefetch4096:
+	mulByte(0)
+	mulByte(1)
+	mulByte(2)
+	mulByte(3)
+	mulByte(4)
+	mulByte(5)
+	mulByte(6)
+	mulByte(7)
+
+	ADDQ $8, DX
+	SUBQ $1, CX
+	JNE  loop4096
+
+	VMOVDQU Y0, (AX)
+	VMOVDQU Y8, (BX)
+
+	VZEROUPPER
 	RET
@@ -1,3 +1,6 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
 #include "textflag.h"
 
 // mul2 multiplicates FROM by 2, stores result in R1
@@ -11,6 +14,8 @@
 	VXORPD      R2, TO, TO       \
 	VXORPD      R3, TO, TO
 
+#define prefetchDistance 512
+
 #define mask(bit, tmp, to) \
 	VPSRLW bit, X10, tmp \
 	VPAND  X12, tmp, to  \ // to = 0x000<bit>000<bit>...
@@ -29,7 +34,8 @@
 	VANDPD  X2, X5, X3   \
 	VXORPD  X9, X3, X3
 
-TEXT ·mulByteRight(SB), NOSPLIT, $0
+// func mulByteSliceRight(c00, c10, c01, c11 *GF127, n int, data *byte)
+TEXT ·mulByteSliceRight(SB), NOSPLIT, $0
 	MOVQ    c00+0(FP), AX
 	VMOVDQU (AX), X0
 	MOVQ    c10+8(FP), CX
@@ -38,8 +44,6 @@ TEXT ·mulByteRight(SB), NOSPLIT, $0
 	VMOVDQU (BX), X1
 	MOVQ    c11+24(FP), DX
 	VMOVDQU (DX), X3
-	MOVQ    $0, CX
-	MOVB    b+32(FP), CX
 
 	VPXOR    X13, X13, X13 // X13 = 0x0000...
 	VPCMPEQB X14, X14, X14 // X14 = 0xFFFF...
@@ -47,6 +51,22 @@ TEXT ·mulByteRight(SB), NOSPLIT, $0
 	VPSUBW   X14, X13, X12 // X12 = 0x00010001... (packed words of 1)
 	VPSLLQ   $63, X10, X14 // X14 = 0x10000000... (packed quad-words with HSB set)
 
+	MOVQ n+32(FP), SI
+	MOVQ data+40(FP), DI
+
+loop:
+	CMPQ SI, $0
+	JEQ  finish
+
+	// Prefetch once per 64 bytes, i.e. once per cache line.
+	TESTQ      $63, SI
+	JNE        noprefetch
+	PREFETCHT0 prefetchDistance(DI)
+
+noprefetch:
+	MOVBQZX  (DI), CX
+	ADDQ     $1, DI
+	SUBQ     $1, SI
 	MOVQ     CX, X10
 	VPSHUFLW $0, X10, X11
 	VPSHUFD  $0, X11, X10
@@ -60,11 +80,13 @@ TEXT ·mulByteRight(SB), NOSPLIT, $0
 	mulBit($1)
 	mulBit($0)
 
+	JMP loop
+
+finish:
 	VMOVDQU X0, (AX)
 	MOVQ    c10+8(FP), CX
 	VMOVDQU X2, (CX)
 	VMOVDQU X1, (BX)
-	MOVQ    c11+24(FP), DX
 	VMOVDQU X3, (DX)
 
 	RET
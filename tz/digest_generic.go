@@ -1,8 +1,6 @@
-//go:build !(amd64 && !generic)
-// +build !amd64 generic
+//go:build !(amd64 && !generic && !purego && !tinygo)
+// +build !amd64 generic purego tinygo
 
 package tz
 
-func write(d *digest, data []byte) (int, error) {
-	return writeGeneric(d, data)
-}
+var allBackends = []*backend{genericBackend}
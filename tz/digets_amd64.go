@@ -1,5 +1,5 @@
-//go:build amd64 && !generic
-// +build amd64,!generic
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
 
 package tz
 
@@ -8,32 +8,54 @@ import (
 	"golang.org/x/sys/cpu"
 )
 
-func write(d *digest, data []byte) (n int, err error) {
-	switch {
-	case cpu.X86.HasAVX && cpu.X86.HasAVX2:
-		return writeAVX2(d, data)
-	case cpu.X86.HasAVX:
-		return writeAVX(d, data)
-	default:
-		return writeGeneric(d, data)
-	}
+var cpuFeatures = []cpuFeature{
+	{"avx", &cpu.X86.HasAVX},
+	{"avx2", &cpu.X86.HasAVX2},
+}
+
+var allBackends = []*backend{
+	{
+		name:      "avx2",
+		available: func() bool { return cpu.X86.HasAVX && cpu.X86.HasAVX2 },
+		write:     writeAVX2,
+		pclmul:    hasPCLMUL,
+	},
+	{
+		name:      "avx",
+		available: func() bool { return cpu.X86.HasAVX },
+		write:     writeAVX,
+		pclmul:    hasPCLMUL,
+	},
+	genericBackend,
 }
 
-func writeAVX2(d *digest, data []byte) (n int, err error) {
+// block4096 is the size of a block processed by a specialized kernel.
+const block4096 = 4096
+
+func writeAVX2(d *Digest, data []byte) (n int, err error) {
 	n = len(data)
+	for ; len(data) >= block4096; data = data[block4096:] {
+		mulBlock4096Rightx2(&d.x[0], &d.x[2], &data[0])
+	}
 	if len(data) != 0 {
-		mulByteSliceRightx2(&d.x[0], &d.x[2], n, &data[0])
+		mulByteSliceRightx2(&d.x[0], &d.x[2], len(data), &data[0])
 	}
 	return
 }
 
-func writeAVX(d *digest, data []byte) (n int, err error) {
+func writeAVX(d *Digest, data []byte) (n int, err error) {
 	n = len(data)
-	for _, b := range data {
-		mulByteRight(&d.x[0], &d.x[1], &d.x[2], &d.x[3], b)
+	if len(data) != 0 {
+		mulByteSliceRight(&d.x[0], &d.x[1], &d.x[2], &d.x[3], n, &data[0])
 	}
 	return
 }
 
-func mulByteRight(c00, c01, c10, c11 *GF127, b byte)
+//go:noescape
+func mulByteSliceRight(c00, c10, c01, c11 *GF127, n int, data *byte)
+
+//go:noescape
+func mulBlock4096Rightx2(c00c10 *gf127.GF127, c01c11 *gf127.GF127, data *byte)
+
+//go:noescape
 func mulByteSliceRightx2(c00c10 *gf127.GF127, c01c11 *gf127.GF127, n int, data *byte)
@@ -0,0 +1,113 @@
+package tz
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// base58Alphabet is the Bitcoin alphabet used across Neo ecosystem.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Index = func() (idx [256]int8) {
+	for i := range idx {
+		idx[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		idx[base58Alphabet[i]] = int8(i)
+	}
+	return
+}()
+
+// base64URL is the unpadded URL-safe Base64 rejecting non-canonical input.
+var base64URL = base64.RawURLEncoding.Strict()
+
+// Base58 returns Base58-encoded hash.
+func (h Hash) Base58() string {
+	var (
+		zeros int
+		// log(256)/log(58) < 1.37
+		out = make([]byte, 0, Size*137/100+1)
+	)
+	for zeros < Size && h[zeros] == 0 {
+		zeros++
+	}
+
+	// out contains base58 digits in little-endian order.
+	for _, b := range h[zeros:] {
+		carry := int(b)
+		for i := range out {
+			carry += int(out[i]) << 8
+			out[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for ; carry != 0; carry /= 58 {
+			out = append(out, byte(carry%58))
+		}
+	}
+
+	res := make([]byte, zeros+len(out))
+	for i := 0; i < zeros; i++ {
+		res[i] = base58Alphabet[0]
+	}
+	for i := range out {
+		res[len(res)-1-i] = base58Alphabet[out[i]]
+	}
+	return string(res)
+}
+
+// ParseBase58 parses Base58-encoded hash. Only the canonical encoding
+// of exactly Size bytes is accepted.
+func ParseBase58(s string) (Hash, error) {
+	var (
+		h    Hash
+		zero int
+		out  []byte // big-endian number without leading zeros
+	)
+	for zero < len(s) && s[zero] == base58Alphabet[0] {
+		zero++
+	}
+	for i := zero; i < len(s); i++ {
+		d := base58Index[s[i]]
+		if d < 0 {
+			return h, fmt.Errorf("invalid Base58 character %q", s[i])
+		}
+
+		carry := int(d)
+		for j := len(out) - 1; j >= 0; j-- {
+			carry += int(out[j]) * 58
+			out[j] = byte(carry)
+			carry >>= 8
+		}
+		if carry != 0 {
+			out = append([]byte{byte(carry)}, out...)
+		}
+		if zero+len(out) > Size {
+			return h, errors.New("invalid Base58 hash length")
+		}
+	}
+	if zero+len(out) != Size {
+		return h, errors.New("invalid Base58 hash length")
+	}
+	copy(h[zero:], out)
+	return h, nil
+}
+
+// Base64 returns unpadded URL-safe Base64-encoded hash.
+func (h Hash) Base64() string {
+	return base64URL.EncodeToString(h[:])
+}
+
+// ParseBase64 parses unpadded URL-safe Base64-encoded hash. Only the canonical
+// encoding is accepted.
+func ParseBase64(s string) (Hash, error) {
+	var h Hash
+
+	if base64URL.DecodedLen(len(s)) != Size {
+		return h, errors.New("invalid Base64 hash length")
+	}
+	if _, err := base64URL.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid Base64 hash: %w", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,93 @@
+package tz
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// base58Ref is a straightforward Base58 encoder.
+func base58Ref(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		sb.WriteByte('1')
+	}
+
+	var digits []byte
+	n := new(big.Int).SetBytes(b)
+	for m, base := new(big.Int), big.NewInt(58); n.Sign() != 0; {
+		n.DivMod(n, base, m)
+		digits = append(digits, base58Alphabet[m.Int64()])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		sb.WriteByte(digits[i])
+	}
+	return sb.String()
+}
+
+func TestBase58(t *testing.T) {
+	hs := []Hash{{}, Sum(nil), Sum([]byte("data"))}
+	for i := 0; i < 100; i++ {
+		var h Hash
+		_, _ = rand.Read(h[rand.Intn(Size):])
+		hs = append(hs, h)
+	}
+	hs[3][Size-1] = 0xff
+
+	for _, h := range hs {
+		s := h.Base58()
+		require.Equal(t, base58Ref(h[:]), s)
+
+		actual, err := ParseBase58(s)
+		require.NoError(t, err, s)
+		require.Equal(t, h, actual)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		s := Hash(Sum([]byte("data"))).Base58()
+		for _, s := range []string{
+			"",
+			s[1:],
+			"1" + s,
+			s[:10] + "0" + s[11:],
+			s[:10] + "l" + s[11:],
+			strings.Repeat("z", 90),
+			strings.Repeat("1", Size-1),
+		} {
+			_, err := ParseBase58(s)
+			require.Error(t, err, s)
+		}
+	})
+}
+
+func TestBase64(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+	h[0], h[1] = 0xfb, 0xff // '-' and '_' in the encoding
+
+	s := h.Base64()
+	require.NotContains(t, s, "=")
+	require.Contains(t, s, "-")
+
+	actual, err := ParseBase64(s)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	last := s[len(s)-1]
+	for _, s := range []string{
+		"",
+		s[1:],
+		s + "==",
+		s + "A",
+		s[:len(s)-1] + string(last+1), // non-zero padding bits
+		"+" + s[1:],
+	} {
+		_, err := ParseBase64(s)
+		require.Error(t, err, s)
+	}
+}
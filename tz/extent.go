@@ -0,0 +1,70 @@
+package tz
+
+import "fmt"
+
+// Extent is a piece of sparse data at the specified offset.
+type Extent struct {
+	Offset int64
+	Data   []byte
+}
+
+// HashedExtent is an extent described by its length and hash only.
+type HashedExtent struct {
+	Offset int64
+	Length int64
+	Hash   Hash
+}
+
+// SumExtents returns Tillich-Zémor checksum of size bytes of sparse data
+// described by extents with zeros in the gaps between them. Extents must be
+// sorted by offset, must not overlap and must fit into size. Gaps are never
+// materialized, their hashes are computed in O(log n) time like in SumZeros.
+func SumExtents(extents []Extent, size int64) ([Size]byte, error) {
+	hs := make([]HashedExtent, len(extents))
+	for i := range extents {
+		hs[i] = HashedExtent{
+			Offset: extents[i].Offset,
+			Length: int64(len(extents[i].Data)),
+			Hash:   Sum(extents[i].Data),
+		}
+	}
+	return CombineExtents(hs, size)
+}
+
+// CombineExtents is like SumExtents, but uses extent hashes computed
+// elsewhere, e.g. when extents were hashed while being downloaded.
+func CombineExtents(extents []HashedExtent, size int64) ([Size]byte, error) {
+	var (
+		r   = id
+		c   sl2
+		off int64
+	)
+	for i := range extents {
+		e := &extents[i]
+		if e.Length < 0 {
+			return [Size]byte{}, fmt.Errorf("extent %d: negative length", i)
+		}
+		if e.Offset < off {
+			return [Size]byte{}, fmt.Errorf("extent %d: offset %d overlaps previous extent or is negative", i, e.Offset)
+		}
+		if e.Offset > size || e.Length > size-e.Offset {
+			return [Size]byte{}, fmt.Errorf("extent %d: exceeds size %d", i, size)
+		}
+		if err := e.Hash.Validate(); err != nil {
+			return [Size]byte{}, fmt.Errorf("extent %d: %w", i, err)
+		}
+		_ = c.UnmarshalBinary(e.Hash[:])
+
+		z := zeroHash(uint64(e.Offset - off))
+		r.Mul(&r, &z)
+		r.Mul(&r, &c)
+		off = e.Offset + e.Length
+	}
+	if size < off {
+		return [Size]byte{}, fmt.Errorf("negative size %d", size)
+	}
+
+	z := zeroHash(uint64(size - off))
+	r.Mul(&r, &z)
+	return r.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumExtents(t *testing.T) {
+	data := make([]byte, 5000)
+	extents := []Extent{{Offset: 0, Data: make([]byte, 10)}, {Offset: 100, Data: make([]byte, 1000)}, {Offset: 1100, Data: make([]byte, 1)}, {Offset: 4000, Data: make([]byte, 500)}}
+	for _, e := range extents {
+		_, _ = rand.Read(e.Data)
+		copy(data[e.Offset:], e.Data)
+	}
+
+	for _, size := range []int64{4500, 5000} {
+		h, err := SumExtents(extents, size)
+		require.NoError(t, err)
+		require.Equal(t, Sum(data[:size]), h)
+	}
+
+	h, err := SumExtents(extents[1:2], 1100)
+	require.NoError(t, err)
+	require.Equal(t, Sum(append(make([]byte, 100), extents[1].Data...)), h)
+
+	h, err = SumExtents(nil, 123)
+	require.NoError(t, err)
+	require.Equal(t, SumZeros(123), h)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, es := range [][]Extent{
+			{{Offset: -1, Data: []byte{1}}},
+			{{Offset: 0, Data: []byte{1, 2}}, {Offset: 1, Data: []byte{3}}},
+			{{Offset: 10, Data: []byte{1}}, {Offset: 5, Data: []byte{3}}},
+			{{Offset: 4999, Data: []byte{1, 2}}},
+			{{Offset: 5001}},
+		} {
+			_, err := SumExtents(es, 5000)
+			require.Error(t, err)
+		}
+		_, err := SumExtents(nil, -1)
+		require.Error(t, err)
+
+		_, err = CombineExtents([]HashedExtent{{Length: -1, Hash: Sum(nil)}}, 10)
+		require.Error(t, err)
+		_, err = CombineExtents([]HashedExtent{{Length: 1}}, 10)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,170 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// minFileShardSize is the minimal amount of data read by a single
+// SumFileParallel worker.
+const minFileShardSize = 4 * readBufferSize
+
+// SumFile returns Tillich-Zémor checksum of the named file contents.
+// Regular files are memory-mapped where supported, otherwise reading
+// and hashing are overlapped, see SumReader.
+func SumFile(name string) ([Size]byte, error) {
+	return SumFileContext(context.Background(), name)
+}
+
+// SumFileContext is like SumFile, but the span started by the Tracer, if any,
+// is a child of the one in ctx.
+func SumFileContext(ctx context.Context, name string) ([Size]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return [Size]byte{}, err
+	}
+
+	// Files of special file systems like procfs can report zero size.
+	regular := fi.Mode().IsRegular() && fi.Size() > 0
+	size := int64(-1)
+	if regular {
+		size = fi.Size()
+	}
+
+	sp := startSpan(ctx, "tz.SumFile", size)
+	d := NewDigest()
+	r := &countingReader{r: f}
+	var ok bool
+	if regular {
+		ok, err = sumMapped(f, 0, size, d)
+	}
+	if ok {
+		r.n = size
+	} else {
+		err = sumReader(r, d)
+	}
+	if sp != nil {
+		sp.End(r.n, err)
+	}
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	return d.checkSum(), nil
+}
+
+// SumFileRange returns Tillich-Zémor checksum of length bytes of the named
+// file starting at off. Like SumFile, it uses memory mapping where supported.
+// An error is returned if the file is shorter than off+length.
+func SumFileRange(name string, off, length int64) ([Size]byte, error) {
+	if off < 0 || length < 0 {
+		return [Size]byte{}, errors.New("negative offset or length")
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	if fi.Mode().IsRegular() && off+length > fi.Size() {
+		return [Size]byte{}, io.ErrUnexpectedEOF
+	}
+
+	d := NewDigest()
+	if ok, err := sumMapped(f, off, length, d); ok {
+		if err != nil {
+			return [Size]byte{}, err
+		}
+		return d.checkSum(), nil
+	}
+
+	r := &countingReader{r: io.NewSectionReader(f, off, length)}
+	if err := sumReader(r, d); err != nil {
+		return [Size]byte{}, err
+	}
+	if r.n != length {
+		return [Size]byte{}, io.ErrUnexpectedEOF
+	}
+	return d.checkSum(), nil
+}
+
+// SumFileParallel is like SumFile, but the file is split into contiguous
+// shards which are read and hashed by at most workers goroutines.
+// If workers is not positive, GOMAXPROCS is used. Shard hashes are combined
+// using the homomorphic property, so the result is the same as the one of SumFile.
+func SumFileParallel(name string, workers int) ([Size]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return [Size]byte{}, err
+	}
+
+	size := fi.Size()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if max := size / minFileShardSize; int64(workers) > max {
+		workers = int(max)
+	}
+	if workers <= 1 || !fi.Mode().IsRegular() {
+		return SumReader(f)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		res    = make([]workerResult, workers)
+		errs   = make([]error, workers)
+		shard  = (size + int64(workers) - 1) / int64(workers)
+		result = id
+	)
+
+	for i := 0; i < workers; i++ {
+		start := int64(i) * shard
+		n := shard
+		if start+n > size {
+			n = size - start
+		}
+
+		wg.Add(1)
+		go func(i int, r io.Reader) {
+			defer wg.Done()
+
+			d := NewDigest()
+			if errs[i] = sumReader(r, d); errs[i] == nil {
+				d.flush()
+				res[i].c = d.sl2()
+			}
+		}(i, io.NewSectionReader(f, start, n))
+	}
+	wg.Wait()
+
+	for i := range res {
+		if errs[i] != nil {
+			return [Size]byte{}, errs[i]
+		}
+		mulSL2(&result, &res[i].c, &result)
+	}
+	return result.Bytes(), nil
+}
@@ -0,0 +1,144 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumFile(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, size := range []int{0, 1, readBufferSize, 2*readBufferSize + 17} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		name := filepath.Join(dir, "file")
+		require.NoError(t, os.WriteFile(name, data, 0644))
+
+		h, err := SumFile(name)
+		require.NoError(t, err)
+		require.Equal(t, Sum(data), h, "size %d", size)
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := SumFile(filepath.Join(dir, "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestSumFileParallel(t *testing.T) {
+	dir := t.TempDir()
+
+	data := make([]byte, 2*minFileShardSize+17)
+	_, _ = rand.Read(data)
+
+	name := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(name, data, 0644))
+
+	expected := Sum(data)
+	for _, workers := range []int{0, 1, 2, 3} {
+		h, err := SumFileParallel(name, workers)
+		require.NoError(t, err)
+		require.Equal(t, expected, h, "workers %d", workers)
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := SumFileParallel(filepath.Join(dir, "missing"), 2)
+		require.Error(t, err)
+	})
+}
+
+func TestSumFileRange(t *testing.T) {
+	testSumFileRange(t, 2*os.Getpagesize())
+}
+
+// testSumFileRange checks SumFileRange with ranges crossing window borders.
+func testSumFileRange(t *testing.T, window int) {
+	data := make([]byte, 2*window+3*4096+17)
+	_, _ = rand.Read(data)
+
+	name := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(name, data, 0644))
+
+	for _, r := range [][2]int{{0, 0}, {0, len(data)}, {1, 100}, {4095, window + 2}, {len(data) - 5, 5}} {
+		h, err := SumFileRange(name, int64(r[0]), int64(r[1]))
+		require.NoError(t, err)
+		require.Equal(t, Sum(data[r[0]:r[0]+r[1]]), h, "range %v", r)
+	}
+
+	h, err := SumFile(name)
+	require.NoError(t, err)
+	require.Equal(t, Sum(data), h)
+
+	_, err = SumFileRange(name, int64(len(data)-5), 6)
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	_, err = SumFileRange(name, -1, 6)
+	require.Error(t, err)
+}
+
+func TestSumFileTracer(t *testing.T) {
+	data := make([]byte, 1000)
+	h := Sum(data)
+
+	tr := new(testTracer)
+	SetTracer(tr, 1000)
+	t.Cleanup(func() { SetTracer(nil, 0) })
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "parent")
+
+	name := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(name, data, 0o644))
+	actual, err := SumFileContext(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+	require.Equal(t, []*testSpan{
+		{name: "tz.SumFile", backend: ActiveBackend(), parent: "parent", n: 1000, ended: true},
+	}, tr.spans)
+
+	t.Run("small file", func(t *testing.T) {
+		tr.spans = nil
+		require.NoError(t, os.WriteFile(name, data[:999], 0o644))
+		_, err = SumFile(name)
+		require.NoError(t, err)
+		require.Empty(t, tr.spans)
+	})
+}
+
+func BenchmarkSumFile(b *testing.B) {
+	const size = 64 << 20
+
+	data := make([]byte, size)
+	_, _ = rand.Read(data)
+
+	name := filepath.Join(b.TempDir(), "file")
+	require.NoError(b, os.WriteFile(name, data, 0644))
+
+	b.Run("mmap", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			_, err := SumFile(name)
+			require.NoError(b, err)
+		}
+	})
+	b.Run("read", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			f, err := os.Open(name)
+			require.NoError(b, err)
+			_, err = SumReader(f)
+			require.NoError(b, err)
+			require.NoError(b, f.Close())
+		}
+	})
+}
@@ -5,29 +5,133 @@
 package tz
 
 import (
+	"encoding/hex"
 	"errors"
+
+	"github.com/nspcc-dev/tzhash/gf127"
 )
 
+// Hash is a Tillich-Zémor checksum as returned by Sum.
+type Hash [Size]byte
+
+// String returns hex-encoded hash.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// GobEncode implements gob.GobEncoder.
+func (h Hash) GobEncode() ([]byte, error) {
+	return h[:], nil
+}
+
+// GobDecode implements gob.GobDecoder.
+func (h *Hash) GobDecode(data []byte) error {
+	if len(data) != Size {
+		return errors.New("invalid hash length")
+	}
+	copy(h[:], data)
+	return nil
+}
+
+// Validate checks that h can be a Tillich-Zémor hash: all matrix elements
+// belong to GF(2^127) and the determinant is 1.
+func (h Hash) Validate() error {
+	var (
+		c    sl2
+		x, y GF127
+	)
+	if err := c.UnmarshalBinary(h[:]); err != nil {
+		return err
+	}
+	gf127.Mul(&c[0][0], &c[1][1], &x)
+	gf127.Mul(&c[0][1], &c[1][0], &y)
+	gf127.Add(&x, &y, &x)
+	if x != id[0][0] {
+		return errors.New("determinant must be 1")
+	}
+	return nil
+}
+
+// foldLanes is the number of independent partial products computed by fold.
+const foldLanes = 4
+
 // Concat performs combining of hashes based on homomorphic property.
 func Concat(hs [][]byte) ([]byte, error) {
-	var b, c sl2
+	var b sl2
 
-	b = id
-	for i := range hs {
-		if err := c.UnmarshalBinary(hs[i]); err != nil {
-			return nil, err
-		}
-		b.Mul(&b, &c)
+	countOp(OpConcat)
+	if err := fold(hs, &b); err != nil {
+		return nil, err
 	}
 	return b.MarshalBinary()
 }
 
+// AppendConcat is like Concat, but appends the result to dst.
+// It doesn't allocate memory if dst has enough capacity.
+func AppendConcat(dst []byte, hs [][]byte) ([]byte, error) {
+	var b sl2
+
+	countOp(OpConcat)
+	if err := fold(hs, &b); err != nil {
+		return dst, err
+	}
+	h := b.Bytes()
+	return append(dst, h[:]...), nil
+}
+
+// fold sets r to the product of hashes in hs. The slice is split into
+// foldLanes contiguous parts, whose products are computed interleaved
+// and combined at the end. Lanes don't depend on each other, so CPU can
+// overlap their multiplications, each of which is done by the backend
+// one matrix at a time.
+func fold(hs [][]byte, r *sl2) error {
+	var (
+		acc, c [foldLanes]sl2
+		b      = currentBackend()
+	)
+
+	n := len(hs) / foldLanes
+	for k := range acc {
+		acc[k] = id
+	}
+	for i := 0; i < n; i++ {
+		for k := range acc {
+			if err := c[k].UnmarshalBinary(hs[k*n+i]); err != nil {
+				return err
+			}
+		}
+		for k := range acc {
+			b.mulSL2(&acc[k], &c[k], &acc[k])
+		}
+	}
+
+	// The tail is appended to the last lane.
+	last := &acc[foldLanes-1]
+	for _, h := range hs[foldLanes*n:] {
+		if err := c[0].UnmarshalBinary(h); err != nil {
+			return err
+		}
+		b.mulSL2(last, &c[0], last)
+	}
+
+	*r = acc[0]
+	for k := 1; k < foldLanes; k++ {
+		b.mulSL2(r, &acc[k], r)
+	}
+	return nil
+}
+
+// ErrChecksumMismatch is returned when data or hashes don't match the
+// expected checksum, e.g. by verifying readers at EOF, proofs and reassembly.
+// It can be wrapped with more details, so check errors with errors.Is.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // Validate checks if hashes in hs combined are equal to h.
+// It doesn't allocate memory.
 func Validate(h []byte, hs [][]byte) (bool, error) {
 	var (
-		b             []byte
-		got, expected [Size]byte
-		err           error
+		b        sl2
+		expected [Size]byte
 	)
 
 	if len(h) != Size {
@@ -36,24 +140,26 @@ func Validate(h []byte, hs [][]byte) (bool, error) {
 		return false, errors.New("empty slice")
 	}
 
+	countOp(OpValidate)
 	copy(expected[:], h)
 
-	b, err = Concat(hs)
-	if err != nil {
+	if err := fold(hs, &b); err != nil {
 		return false, errors.New("cant concatenate hashes")
 	}
 
-	copy(got[:], b)
-
-	return expected == got, nil
+	return expected == b.Bytes(), nil
 }
 
 // SubtractR returns hash a, such that Concat(a, b) == c
 // This is possible, because Tillich-Zemor hash is actually a matrix
 // which can be inversed.
 func SubtractR(c, b []byte) (a []byte, err error) {
-	var p1, p2, r sl2
+	var (
+		p1, p2, r sl2
+		t         [2]GF127
+	)
 
+	countOp(OpSubtract)
 	if err = r.UnmarshalBinary(c); err != nil {
 		return nil, err
 	}
@@ -61,8 +167,8 @@ func SubtractR(c, b []byte) (a []byte, err error) {
 		return nil, err
 	}
 
-	p1 = *Inv(&p2)
-	p1.Mul(&r, &p1)
+	inv(&p2, &p1, &t)
+	mulSL2(&r, &p1, &p1)
 
 	return p1.MarshalBinary()
 }
@@ -71,8 +177,12 @@ func SubtractR(c, b []byte) (a []byte, err error) {
 // This is possible, because Tillich-Zemor hash is actually a matrix
 // which can be inversed.
 func SubtractL(c, a []byte) (b []byte, err error) {
-	var p1, p2, r sl2
+	var (
+		p1, p2, r sl2
+		t         [2]GF127
+	)
 
+	countOp(OpSubtract)
 	if err = r.UnmarshalBinary(c); err != nil {
 		return nil, err
 	}
@@ -80,8 +190,8 @@ func SubtractL(c, a []byte) (b []byte, err error) {
 		return nil, err
 	}
 
-	p2 = *Inv(&p1)
-	p2.Mul(&p2, &r)
+	inv(&p1, &p2, &t)
+	mulSL2(&p2, &r, &p2)
 
 	return p2.MarshalBinary()
 }
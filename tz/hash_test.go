@@ -1,32 +1,20 @@
 package tz
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/hex"
-	"fmt"
 	"io"
 	"math/rand"
+	"strconv"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/require"
-	"golang.org/x/sys/cpu"
 )
 
 const benchDataSize = 100000
 
-type arch struct {
-	HasAVX  bool
-	HasAVX2 bool
-}
-
-var backends = []struct {
-	Name string
-	arch
-}{
-	{"AVX", arch{true, false}},
-	{"AVX2", arch{true, true}},
-	{"Generic", arch{false, false}},
-}
-
 var testCases = []struct {
 	input []byte
 	hash  string
@@ -74,11 +62,10 @@ var testCases = []struct {
 }
 
 func TestHash(t *testing.T) {
-	for i, b := range backends {
-		t.Run(b.Name+" digest", func(t *testing.T) {
-			prepareArch(t, backends[i].arch)
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
 
-			fmt.Println("FEATURES:", cpu.X86.HasAVX, cpu.X86.HasAVX2)
 			d := New()
 			for _, tc := range testCases {
 				d.Reset()
@@ -90,20 +77,251 @@ func TestHash(t *testing.T) {
 	}
 }
 
-func prepareArch(t testing.TB, b arch) {
-	realCPU := cpu.X86
-	if !realCPU.HasAVX2 && b.HasAVX2 || !realCPU.HasAVX && b.HasAVX {
-		t.Skip("Underlying CPU doesn't support necessary features")
-	} else {
-		t.Cleanup(func() {
-			cpu.X86.HasAVX = realCPU.HasAVX
-			cpu.X86.HasAVX2 = realCPU.HasAVX2
+func TestSumSmall(t *testing.T) {
+	data := newBuffer()
+
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			for n := 0; n <= smallInputSize+1; n++ {
+				expected := sumNoFastPath(data[:n])
+				actual := Sum(data[:n])
+				require.Equal(t, expected, actual[:], "size %d", n)
+			}
 		})
-		cpu.X86.HasAVX = b.HasAVX
-		cpu.X86.HasAVX2 = b.HasAVX2
 	}
 }
 
+func prepareBackend(t testing.TB, name string) {
+	require.NoError(t, SetBackend(name))
+	t.Cleanup(func() {
+		require.NoError(t, SetBackend(""))
+	})
+}
+
+func TestSmallWrites(t *testing.T) {
+	data := newBuffer()[:1000]
+	expected := Sum(data)
+
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			for _, step := range []int{1, 3, 16, 127, 128, 129, 300} {
+				d := New()
+				for j := 0; j < len(data); j += step {
+					end := j + step
+					if end > len(data) {
+						end = len(data)
+					}
+					_, _ = d.Write(data[j:end])
+
+					// Sum must not affect digest state.
+					if j == len(data)/2 {
+						_ = d.Sum(nil)
+					}
+				}
+				require.Equal(t, expected[:], d.Sum(nil), "step %d", step)
+			}
+		})
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	data := newBuffer()[:10000]
+	expected := Sum(data)
+
+	for _, size := range []int{-1, 0, 1, 100, 4096, 64 * 1024} {
+		d := New(WithBufferSize(size))
+		for j := 0; j < len(data); j += 10 {
+			_, _ = d.Write(data[j : j+10])
+		}
+		require.Equal(t, expected[:], d.Sum(nil), "size %d", size)
+	}
+
+	t.Run("caller buffer", func(t *testing.T) {
+		buf := make([]byte, 1000)
+		d := New(WithBuffer(buf))
+		for j := 0; j < len(data); j += 10 {
+			_, _ = d.Write(data[j : j+10])
+		}
+		require.Equal(t, expected[:], d.Sum(nil))
+	})
+}
+
+func TestClone(t *testing.T) {
+	data := newBuffer()[:1000]
+	expected := Sum(data)
+
+	d := NewDigest()
+	_, _ = d.Write(data[:333])
+
+	c := d.Clone()
+	_, _ = d.Write([]byte{1, 2, 3})
+	_, _ = c.Write(data[333:])
+	require.Equal(t, expected, c.Checksum())
+	require.NotEqual(t, expected, d.Checksum())
+}
+
+func TestZeroDigest(t *testing.T) {
+	data := newBuffer()[:1000]
+	expected := Sum(data)
+
+	var d Digest
+	require.Equal(t, Sum(nil), d.Checksum())
+
+	d = Digest{}
+	_, _ = d.Write(data[:10])
+	_, _ = d.Write(data[10:])
+	require.Equal(t, expected[:], d.Sum(nil))
+
+	d = Digest{}
+	state, err := d.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, NewDigest().UnmarshalBinary(state))
+
+	d = Digest{}
+	c := d.Clone()
+	_, _ = c.Write(data)
+	require.Equal(t, expected, c.Checksum())
+}
+
+func TestMarshalState(t *testing.T) {
+	data := newBuffer()[:1000]
+	expected := Sum(data)
+
+	d := NewDigest()
+	_, _ = d.Write(data[:333])
+
+	state, err := d.MarshalBinary()
+	require.NoError(t, err)
+
+	r := new(Digest)
+	require.NoError(t, r.UnmarshalBinary(state))
+	_, _ = r.Write(data[333:])
+	require.Equal(t, expected, r.Checksum())
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Error(t, r.UnmarshalBinary(state[:len(state)-1]))
+		require.Error(t, r.UnmarshalBinary(append([]byte{0}, state[1:]...)))
+
+		bad := append([]byte{}, state...)
+		bad[len(stateMagic)] |= 0x80
+		require.Error(t, r.UnmarshalBinary(bad))
+	})
+}
+
+func TestLargeWrites(t *testing.T) {
+	data := newBuffer()
+
+	var expected [][]byte
+	for _, size := range []int{4095, 4096, 4097, 3*4096 + 17} {
+		expected = append(expected, sumNoFastPath(data[:size]))
+	}
+
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			for i, size := range []int{4095, 4096, 4097, 3*4096 + 17} {
+				d := New()
+				_, _ = d.Write(data[:size])
+				require.Equal(t, expected[i], d.Sum(nil), "size %d", size)
+			}
+		})
+	}
+}
+
+func TestUnaligned(t *testing.T) {
+	data := newBuffer()[:3*4096+64]
+
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			for offset := 1; offset < 32; offset += 3 {
+				for _, size := range []int{1, 31, 4095, 4097, 2 * 4096} {
+					part := data[offset : offset+size]
+					expected := sumNoFastPath(part)
+
+					// Pending data in the buffer must not affect large writes.
+					split := offset % size
+					d := New()
+					_, _ = d.Write(part[:split])
+					_, _ = d.Write(part[split:])
+					require.Equal(t, expected, d.Sum(nil), "offset %d, size %d", offset, size)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	data := make([]byte, 1024)
+	sum := make([]byte, 0, Size)
+
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			d := New()
+			allocs := testing.AllocsPerRun(10, func() {
+				d.Reset()
+				_, _ = d.Write(data)
+				sum = d.Sum(sum[:0])
+			})
+			require.Zero(t, allocs)
+
+			if raceEnabled {
+				return // Sum uses the pool
+			}
+			allocs = testing.AllocsPerRun(10, func() {
+				_ = Sum(data)
+			})
+			require.Zero(t, allocs)
+		})
+	}
+
+	t.Run("caller buffer", func(t *testing.T) {
+		buf := make([]byte, 256)
+		allocs := testing.AllocsPerRun(10, func() {
+			d := NewDigest(WithBuffer(buf))
+			_, _ = d.Write(data[:100])
+			_ = d.Checksum()
+		})
+		require.LessOrEqual(t, allocs, 1.0) // the digest itself
+	})
+
+	t.Run("concat", func(t *testing.T) {
+		var hs [][]byte
+		for i := 0; i < 10; i++ {
+			h := Sum(data[i:][:10])
+			hs = append(hs, h[:])
+		}
+		h, err := Concat(hs)
+		require.NoError(t, err)
+
+		dst := make([]byte, 0, Size)
+		allocs := testing.AllocsPerRun(10, func() {
+			dst, _ = AppendConcat(dst[:0], hs)
+		})
+		require.Zero(t, allocs)
+		require.Equal(t, h, dst)
+
+		allocs = testing.AllocsPerRun(10, func() {
+			_, _ = Validate(h, hs)
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func TestDigestLayout(t *testing.T) {
+	require.Zero(t, unsafe.Offsetof(Digest{}.x))
+	require.Zero(t, unsafe.Sizeof(Digest{})%cacheLineSize)
+	require.Zero(t, unsafe.Sizeof(workerResult{})%cacheLineSize)
+}
+
 func newBuffer() (data []byte) {
 	data = make([]byte, benchDataSize)
 
@@ -118,17 +336,84 @@ func newBuffer() (data []byte) {
 func BenchmarkSum(b *testing.B) {
 	data := newBuffer()
 
-	for i := range backends {
-		b.Run(backends[i].Name+" digest", func(b *testing.B) {
-			prepareArch(b, backends[i].arch)
+	for _, name := range Backends() {
+		b.Run(name+" digest", func(b *testing.B) {
+			prepareBackend(b, name)
 
 			b.ResetTimer()
 			b.ReportAllocs()
 			d := New()
+			sum := make([]byte, 0, Size)
 			for i := 0; i < b.N; i++ {
 				d.Reset()
 				_, _ = d.Write(data)
-				d.Sum(nil)
+				sum = d.Sum(sum[:0])
+			}
+			b.SetBytes(int64(len(data)))
+		})
+	}
+}
+
+func BenchmarkSumSmall(b *testing.B) {
+	data := newBuffer()
+
+	for _, n := range []int{1, 8, 32, 64} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = Sum(data[:n])
+			}
+			b.SetBytes(int64(n))
+		})
+	}
+}
+
+func BenchmarkSumConcurrent(b *testing.B) {
+	data := newBuffer()[:4096]
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.RunParallel(func(pb *testing.PB) {
+		d := New()
+		for pb.Next() {
+			_, _ = d.Write(data)
+		}
+	})
+}
+
+func BenchmarkBlock4096(b *testing.B) {
+	data := newBuffer()[:4096]
+
+	for _, name := range Backends() {
+		b.Run(name+" digest", func(b *testing.B) {
+			prepareBackend(b, name)
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			d := New()
+			for i := 0; i < b.N; i++ {
+				_, _ = d.Write(data)
+			}
+			b.SetBytes(int64(len(data)))
+		})
+	}
+}
+
+func BenchmarkSmallWrites(b *testing.B) {
+	data := newBuffer()
+
+	for _, name := range Backends() {
+		b.Run(name+" digest", func(b *testing.B) {
+			prepareBackend(b, name)
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			d := New()
+			for i := 0; i < b.N; i++ {
+				d.Reset()
+				for j := 0; j+8 <= len(data); j += 8 {
+					_, _ = d.Write(data[j : j+8])
+				}
 			}
 			b.SetBytes(int64(len(data)))
 		})
@@ -270,3 +555,85 @@ func TestSubtract(t *testing.T) {
 		require.Equal(t, b, r)
 	}
 }
+
+func BenchmarkSubtract(b *testing.B) {
+	c, _ := hex.DecodeString(testCasesSubtract[0].result)
+	a, _ := hex.DecodeString(testCasesSubtract[0].first)
+	bb, _ := hex.DecodeString(testCasesSubtract[0].second)
+
+	b.Run("left", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = SubtractL(c, a)
+		}
+	})
+	b.Run("right", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = SubtractR(c, bb)
+		}
+	})
+}
+
+func BenchmarkConcat(b *testing.B) {
+	data := newBuffer()
+	hs := make([][]byte, 10000)
+	for i := range hs {
+		h := Sum(data[i%len(data):][:32])
+		hs[i] = h[:]
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Concat(hs)
+	}
+}
+
+func TestHashString(t *testing.T) {
+	h := Hash(Sum(nil))
+	require.Equal(t, hex.EncodeToString(h[:]), h.String())
+}
+
+func TestHashValidate(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("data"), make([]byte, 1000)} {
+		require.NoError(t, Hash(Sum(data)).Validate())
+	}
+
+	h := Hash(Sum([]byte("data")))
+	h[Size-1] ^= 1
+	require.Error(t, h.Validate())
+
+	h = Hash(Sum([]byte("data")))
+	h[0] |= 0x80
+	require.Error(t, h.Validate())
+}
+
+func TestGob(t *testing.T) {
+	type job struct {
+		Name     string
+		Expected Hash
+		State    *Digest
+	}
+
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	d := NewDigest()
+	_, _ = d.Write(data[:300])
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(job{Name: "job", Expected: Sum(data), State: d}))
+
+	var j job
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&j))
+	require.Equal(t, "job", j.Name)
+	require.Equal(t, Hash(Sum(data)), j.Expected)
+
+	_, _ = j.State.Write(data[300:])
+	require.Equal(t, j.Expected[:], j.State.Sum(nil))
+
+	var h Hash
+	require.Error(t, h.GobDecode(make([]byte, Size-1)))
+	require.Error(t, new(Digest).GobDecode(make([]byte, stateSize)))
+}
@@ -0,0 +1,51 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeyV1 is the prefix of storage keys for hashes over GF(2^127) with
+// the standard generators. It is also the identifier of these parameters
+// in tagged hashes, other parameters have different identifiers (see Params),
+// so keys of different versions never collide in a single store.
+const KeyV1 byte = 0x01
+
+// KeySize is the length of a storage key.
+const KeySize = 1 + Size
+
+// ErrUnknownKeyVersion is returned by ParseKey for keys with unknown prefix.
+var ErrUnknownKeyVersion = errors.New("unknown key version")
+
+// Key returns content-addressed storage key of h: version prefix
+// followed by the hash.
+func (h Hash) Key() []byte {
+	return AppendKey(make([]byte, 0, KeySize), h)
+}
+
+// AppendKey appends storage key of h to dst and returns the extended slice.
+func AppendKey(dst []byte, h Hash) []byte {
+	dst = append(dst, KeyV1)
+	return append(dst, h[:]...)
+}
+
+// ParseKey returns hash stored in the storage key k. Both the version
+// and the hash itself are validated.
+func ParseKey(k []byte) (Hash, error) {
+	var h Hash
+
+	if len(k) == 0 {
+		return h, errors.New("empty key")
+	}
+	if k[0] != KeyV1 {
+		return h, fmt.Errorf("%w 0x%02x", ErrUnknownKeyVersion, k[0])
+	}
+	if len(k) != KeySize {
+		return h, fmt.Errorf("invalid key length: expected %d, got %d", KeySize, len(k))
+	}
+	copy(h[:], k[1:])
+	if err := h.Validate(); err != nil {
+		return Hash{}, fmt.Errorf("invalid key: %w", err)
+	}
+	return h, nil
+}
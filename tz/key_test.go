@@ -0,0 +1,33 @@
+package tz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	k := h.Key()
+	require.Len(t, k, KeySize)
+	require.Equal(t, KeyV1, k[0])
+	require.Equal(t, k, AppendKey(nil, h))
+	require.Equal(t, append([]byte("prefix"), k...), AppendKey([]byte("prefix"), h))
+
+	actual, err := ParseKey(k)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseKey(append([]byte{0x02}, h[:]...))
+		require.ErrorIs(t, err, ErrUnknownKeyVersion)
+
+		bad := append([]byte{}, k...)
+		bad[1] ^= 1
+		for _, k := range [][]byte{nil, k[:1], k[:len(k)-1], append(k[:len(k):len(k)], 0), bad} {
+			_, err := ParseKey(k)
+			require.Error(t, err, "%x", k)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package tz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// logStateMagic prefixes marshaled LogHash state.
+const logStateMagic = "tzlog\x01"
+
+// LogSegment is a segment of the log retained by LogHash.
+type LogSegment struct {
+	// Offset is the position of the segment in the log including
+	// detached segments.
+	Offset int64
+	Size   int64
+	Hash   Hash
+}
+
+// LogHash is the running hash of an append-only log consisting of segments.
+// Appending a segment combines its hash with the running one, detaching
+// the oldest segments, e.g. after log rotation, subtracts their hashes, so
+// the running hash is always the hash of retained data without rehashing it.
+// Hashes of retained segments are kept to be detached later. LogHash is not
+// safe for concurrent use.
+type LogHash struct {
+	// start is the offset of the first retained segment.
+	start    int64
+	size     int64
+	sum      Hash
+	segments []LogSegment
+}
+
+// NewLogHash returns LogHash of an empty log.
+func NewLogHash() *LogHash {
+	return &LogHash{sum: Sum(nil)}
+}
+
+// Append appends segment consisting of data.
+func (l *LogHash) Append(data []byte) {
+	_ = l.AppendHash(Sum(data), int64(len(data))) // hash is valid
+}
+
+// AppendHash appends segment of the given size with the hash h
+// computed elsewhere.
+func (l *LogHash) AppendHash(h Hash, size int64) error {
+	if size < 0 {
+		return errors.New("negative segment size")
+	}
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	s, _ := Concat([][]byte{l.sum[:], h[:]}) // both hashes are valid
+	copy(l.sum[:], s)
+	l.segments = append(l.segments, LogSegment{Offset: l.start + l.size, Size: size, Hash: h})
+	l.size += size
+	return nil
+}
+
+// Detach detaches n oldest segments.
+func (l *LogHash) Detach(n int) error {
+	if n < 0 || n > len(l.segments) {
+		return fmt.Errorf("can't detach %d of %d segments", n, len(l.segments))
+	}
+	for _, s := range l.segments[:n] {
+		r, _ := SubtractL(l.sum[:], s.Hash[:]) // both hashes are valid
+		copy(l.sum[:], r)
+		l.start += s.Size
+		l.size -= s.Size
+	}
+	l.segments = append(l.segments[:0:0], l.segments[n:]...)
+	return nil
+}
+
+// DetachBefore detaches all segments ending before or at offset and
+// returns their number.
+func (l *LogHash) DetachBefore(offset int64) int {
+	var n int
+	for n < len(l.segments) && l.segments[n].Offset+l.segments[n].Size <= offset {
+		n++
+	}
+	_ = l.Detach(n)
+	return n
+}
+
+// Sum returns the hash of retained segments.
+func (l *LogHash) Sum() Hash {
+	return l.sum
+}
+
+// Start returns the offset of the first retained byte of the log.
+func (l *LogHash) Start() int64 {
+	return l.start
+}
+
+// Size returns the size of retained segments.
+func (l *LogHash) Size() int64 {
+	return l.size
+}
+
+// Segments returns retained segments from the oldest one.
+func (l *LogHash) Segments() []LogSegment {
+	return append([]LogSegment(nil), l.segments...)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (l *LogHash) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(logStateMagic)+8+Size+4+len(l.segments)*(8+Size))
+	b = append(b, logStateMagic...)
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(l.start))
+	b = append(b, buf[:]...)
+	b = append(b, l.sum[:]...)
+	binary.BigEndian.PutUint32(buf[:], uint32(len(l.segments)))
+	b = append(b, buf[:4]...)
+	for _, s := range l.segments {
+		binary.BigEndian.PutUint64(buf[:], uint64(s.Size))
+		b = append(b, buf[:]...)
+		b = append(b, s.Hash[:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Segment
+// hashes are combined and checked against the saved running hash,
+// so corrupted state is detected.
+func (l *LogHash) UnmarshalBinary(data []byte) error {
+	const header = len(logStateMagic) + 8 + Size + 4
+
+	if len(data) < header || string(data[:len(logStateMagic)]) != logStateMagic {
+		return errors.New("invalid log hash state")
+	}
+	data = data[len(logStateMagic):]
+
+	var r LogHash
+	r.start = int64(binary.BigEndian.Uint64(data))
+	copy(r.sum[:], data[8:])
+	n := binary.BigEndian.Uint32(data[8+Size:])
+	data = data[8+Size+4:]
+	if r.start < 0 || uint64(len(data)) != uint64(n)*(8+Size) {
+		return errors.New("invalid log hash state")
+	}
+
+	hs := make([][]byte, n)
+	r.segments = make([]LogSegment, n)
+	offset := r.start
+	for i := range r.segments {
+		s := &r.segments[i]
+		s.Offset = offset
+		s.Size = int64(binary.BigEndian.Uint64(data))
+		copy(s.Hash[:], data[8:8+Size])
+		if s.Size < 0 {
+			return errors.New("invalid log hash state")
+		}
+		if err := s.Hash.Validate(); err != nil {
+			return fmt.Errorf("invalid log hash state: segment %d: %w", i, err)
+		}
+		hs[i] = s.Hash[:]
+		offset += s.Size
+		data = data[8+Size:]
+	}
+
+	r.size = offset - r.start
+	sum, err := Concat(hs)
+	if err != nil {
+		return fmt.Errorf("invalid log hash state: %w", err)
+	}
+	if string(sum) != string(r.sum[:]) {
+		return fmt.Errorf("invalid log hash state: %w", ErrChecksumMismatch)
+	}
+	*l = r
+	return nil
+}
@@ -0,0 +1,100 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogHash(t *testing.T) {
+	data := make([]byte, 10000)
+	_, _ = rand.Read(data)
+
+	l := NewLogHash()
+	require.Equal(t, Hash(Sum(nil)), l.Sum())
+
+	bounds := []int{0, 1000, 1500, 4000, 7000, 10000}
+	for i := 1; i < len(bounds); i++ {
+		l.Append(data[bounds[i-1]:bounds[i]])
+		require.Equal(t, Hash(Sum(data[:bounds[i]])), l.Sum())
+	}
+	require.EqualValues(t, 10000, l.Size())
+
+	require.NoError(t, l.Detach(2))
+	require.Equal(t, Hash(Sum(data[1500:])), l.Sum())
+	require.EqualValues(t, 1500, l.Start())
+	require.EqualValues(t, 8500, l.Size())
+
+	segs := l.Segments()
+	require.Len(t, segs, 3)
+	require.Equal(t, LogSegment{Offset: 1500, Size: 2500, Hash: Sum(data[1500:4000])}, segs[0])
+
+	require.Error(t, l.Detach(4))
+	require.Error(t, l.Detach(-1))
+
+	require.Equal(t, 0, l.DetachBefore(3999))
+	require.Equal(t, 1, l.DetachBefore(6999))
+	require.Equal(t, Hash(Sum(data[4000:])), l.Sum())
+
+	require.Error(t, l.AppendHash(Hash{}, 1))
+	require.Error(t, l.AppendHash(Sum(nil), -1))
+
+	t.Run("detach all", func(t *testing.T) {
+		l := NewLogHash()
+		l.Append(data[:100])
+		l.Append(data[100:200])
+		require.Equal(t, 2, l.DetachBefore(200))
+		require.Equal(t, Hash(Sum(nil)), l.Sum())
+		require.EqualValues(t, 200, l.Start())
+		require.EqualValues(t, 0, l.Size())
+	})
+}
+
+func TestLogHashMarshal(t *testing.T) {
+	data := make([]byte, 3000)
+	_, _ = rand.Read(data)
+
+	l := NewLogHash()
+	for i := 0; i < 3; i++ {
+		l.Append(data[i*1000 : (i+1)*1000])
+	}
+	require.NoError(t, l.Detach(1))
+
+	state, err := l.MarshalBinary()
+	require.NoError(t, err)
+
+	var r LogHash
+	require.NoError(t, r.UnmarshalBinary(state))
+	require.Equal(t, l.Sum(), r.Sum())
+	require.Equal(t, l.Start(), r.Start())
+	require.Equal(t, l.Size(), r.Size())
+	require.Equal(t, l.Segments(), r.Segments())
+
+	// Restored log can be continued.
+	r.Append(data[:10])
+	l.Append(data[:10])
+	require.Equal(t, l.Sum(), r.Sum())
+
+	t.Run("empty", func(t *testing.T) {
+		state, err := NewLogHash().MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, r.UnmarshalBinary(state))
+		require.Equal(t, Hash(Sum(nil)), r.Sum())
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		bad := append([]byte(nil), state...)
+		bad[len(bad)-1] ^= 1
+		require.Error(t, r.UnmarshalBinary(bad))
+
+		// Valid segment hash which doesn't match the running hash.
+		bad = append([]byte(nil), state...)
+		h := Sum(data[:1])
+		copy(bad[len(bad)-Size:], h[:])
+		require.ErrorIs(t, r.UnmarshalBinary(bad), ErrChecksumMismatch)
+
+		require.Error(t, r.UnmarshalBinary(state[:len(state)-1]))
+		require.Error(t, r.UnmarshalBinary(state[1:]))
+	})
+}
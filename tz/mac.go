@@ -0,0 +1,99 @@
+package tz
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// MinMACKeySize is the minimal size of the secret key of MACKey.
+const MinMACKeySize = 16
+
+// MACKey computes keyed tags of data, which are combinable like hashes,
+// but only by the key holder.
+//
+// The tag of data is K1 · H(data) · K2, where H is the Tillich-Zémor hash and
+// K1, K2 are SL2 elements derived from the key. If a and b are tags of two
+// pieces of data, the tag of their concatenation is a · M · b with the secret
+// M = (K1 · K2)⁻¹, so tags of parts can be combined with the key only.
+//
+// Security statement. Without the key and without any known tags, forging
+// a tag is as hard as guessing an SL2 element. However, the construction is
+// linear in the key: the key can be recovered from about three pairs of data
+// and their tags by solving linear equations. Thus tags must never be
+// available to parties who can see or choose the data, e.g. the data can be
+// kept in untrusted storage while tags are kept inside the trusted domain.
+// Unlike HMAC, this is not a general-purpose MAC.
+type MACKey struct {
+	k1, k2, m sl2
+}
+
+// NewMACKey derives MACKey from the secret key of at least MinMACKeySize bytes.
+func NewMACKey(key []byte) (*MACKey, error) {
+	if len(key) < MinMACKeySize {
+		return nil, errors.New("MAC key is too short")
+	}
+
+	var (
+		k       MACKey
+		p       sl2
+		t       [2]GF127
+		k1Input = append([]byte("tz-mac\x01"), key...)
+		k2Input = append([]byte("tz-mac\x02"), key...)
+	)
+	k.k1, k.k2 = sumSL2(k1Input), sumSL2(k2Input)
+	mulSL2(&k.k1, &k.k2, &p)
+	inv(&p, &k.m, &t)
+	return &k, nil
+}
+
+// Sum returns the tag of data.
+func (k *MACKey) Sum(data []byte) Hash {
+	h := sumSL2(data)
+	return k.tag(&h)
+}
+
+// Tag returns the tag of data with the hash h.
+func (k *MACKey) Tag(h Hash) (Hash, error) {
+	var c sl2
+	if err := h.Validate(); err != nil {
+		return Hash{}, err
+	}
+	_ = c.UnmarshalBinary(h[:])
+	return k.tag(&c), nil
+}
+
+func (k *MACKey) tag(h *sl2) Hash {
+	var r sl2
+	mulSL2(&k.k1, h, &r)
+	mulSL2(&r, &k.k2, &r)
+	return r.Bytes()
+}
+
+// Verify checks in constant time that tag is the tag of data.
+func (k *MACKey) Verify(data []byte, tag Hash) bool {
+	expected := k.Sum(data)
+	return subtle.ConstantTimeCompare(expected[:], tag[:]) == 1
+}
+
+// Concat returns the tag of concatenation of data with the given tags.
+// Zero tags result in the tag of empty data.
+func (k *MACKey) Concat(tags ...Hash) (Hash, error) {
+	if len(tags) == 0 {
+		return k.tag(&id), nil
+	}
+
+	var r, c sl2
+	for i := range tags {
+		if err := tags[i].Validate(); err != nil {
+			return Hash{}, err
+		}
+		_ = c.UnmarshalBinary(tags[i][:])
+		if i == 0 {
+			r = c
+			continue
+		}
+		mulSL2(&r, &k.m, &r)
+		mulSL2(&r, &c, &r)
+	}
+	return r.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package tz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMAC(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	k, err := NewMACKey(key)
+	require.NoError(t, err)
+
+	a, b, c := []byte("first part"), []byte("second part"), []byte("third")
+	ta, tb, tc := k.Sum(a), k.Sum(b), k.Sum(c)
+	require.True(t, k.Verify(a, ta))
+	require.False(t, k.Verify(b, ta))
+	require.NoError(t, ta.Validate())
+
+	h := Hash(Sum(a))
+	require.NotEqual(t, h, ta)
+	tag, err := k.Tag(h)
+	require.NoError(t, err)
+	require.Equal(t, ta, tag)
+
+	whole := bytes.Join([][]byte{a, b, c}, nil)
+	actual, err := k.Concat(ta, tb, tc)
+	require.NoError(t, err)
+	require.Equal(t, k.Sum(whole), actual)
+	require.True(t, k.Verify(whole, actual))
+
+	// Tags can't be combined like hashes.
+	hs, err := Concat([][]byte{ta[:], tb[:], tc[:]})
+	require.NoError(t, err)
+	require.NotEqual(t, actual[:], hs)
+
+	actual, err = k.Concat()
+	require.NoError(t, err)
+	require.Equal(t, k.Sum(nil), actual)
+	actual, err = k.Concat(ta)
+	require.NoError(t, err)
+	require.Equal(t, ta, actual)
+
+	other, err := NewMACKey(append(key, 0))
+	require.NoError(t, err)
+	require.False(t, other.Verify(a, ta))
+
+	_, err = NewMACKey(key[:MinMACKeySize-1])
+	require.Error(t, err)
+	_, err = k.Tag(Hash{1})
+	require.Error(t, err)
+	_, err = k.Concat(ta, Hash{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package tz
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Operations reported to Metrics.
+const (
+	OpSum      = "sum"
+	OpConcat   = "concat"
+	OpSubtract = "subtract"
+	OpValidate = "validate"
+)
+
+// Metrics receives hashing statistics, e.g. to export them to Prometheus.
+// Methods are called synchronously from hashing code, possibly concurrently,
+// so they must be cheap and safe for concurrent use.
+type Metrics interface {
+	// AddBytes is called with the number of bytes written to a digest
+	// using the named backend. It is a natural counter of hashing cost
+	// and backend usage.
+	AddBytes(backend string, n int)
+	// AddOperation is called once per Sum, Concat, Subtract or Validate
+	// call with one of Op* constants.
+	AddOperation(op string)
+	// ObserveSum is called with the duration of every one-shot Sum
+	// and SumParallel call. It is intended for latency histograms.
+	ObserveSum(backend string, d time.Duration)
+}
+
+// metricsHolder wraps Metrics, so that atomic.Value always
+// stores values of the same type.
+type metricsHolder struct {
+	m Metrics
+}
+
+var metrics atomic.Value
+
+// SetMetrics sets metrics receiver for all subsequent hashing.
+// nil disables metrics, which is the default.
+func SetMetrics(m Metrics) {
+	metrics.Store(metricsHolder{m: m})
+}
+
+func currentMetrics() Metrics {
+	h, _ := metrics.Load().(metricsHolder)
+	return h.m
+}
+
+func countOp(op string) {
+	addOp(currentMetrics(), op)
+}
+
+// addOp updates cumulative counters and reports op to m if it is not nil.
+func addOp(m Metrics, op string) {
+	switch op {
+	case OpSum:
+		atomic.AddUint64(&statSum, 1)
+	case OpConcat:
+		atomic.AddUint64(&statConcat, 1)
+	case OpSubtract:
+		atomic.AddUint64(&statSubtract, 1)
+	case OpValidate:
+		atomic.AddUint64(&statValidate, 1)
+	}
+	if m != nil {
+		m.AddOperation(op)
+	}
+}
+
+// addBytes updates cumulative counters and reports n to m if it is not nil.
+func addBytes(m Metrics, backend string, n int) {
+	atomic.AddUint64(&statBytes, uint64(n))
+	if m != nil {
+		m.AddBytes(backend, n)
+	}
+}
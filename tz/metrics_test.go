@@ -0,0 +1,73 @@
+package tz
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	mtx   sync.Mutex
+	bytes map[string]int
+	ops   map[string]int
+	sums  int
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{bytes: make(map[string]int), ops: make(map[string]int)}
+}
+
+func (m *testMetrics) AddBytes(backend string, n int) {
+	m.mtx.Lock()
+	m.bytes[backend] += n
+	m.mtx.Unlock()
+}
+
+func (m *testMetrics) AddOperation(op string) {
+	m.mtx.Lock()
+	m.ops[op]++
+	m.mtx.Unlock()
+}
+
+func (m *testMetrics) ObserveSum(backend string, d time.Duration) {
+	m.mtx.Lock()
+	m.sums++
+	m.mtx.Unlock()
+}
+
+func TestMetrics(t *testing.T) {
+	m := newTestMetrics()
+	SetMetrics(m)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	backend := ActiveBackend()
+
+	small := Sum([]byte("small"))
+	large := Sum(make([]byte, 10000))
+	require.Equal(t, map[string]int{backend: 10005}, m.bytes)
+	require.Equal(t, 2, m.sums)
+
+	d := NewDigest()
+	_, _ = d.Write(make([]byte, 100))
+	require.Equal(t, map[string]int{backend: 10105}, m.bytes)
+
+	c, err := Concat([][]byte{small[:], large[:]})
+	require.NoError(t, err)
+	_, err = SubtractL(c, small[:])
+	require.NoError(t, err)
+	_, err = Validate(c, [][]byte{small[:], large[:]})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]int{
+		OpSum:      2,
+		OpConcat:   1,
+		OpSubtract: 1,
+		OpValidate: 1,
+	}, m.ops)
+
+	SetMetrics(nil)
+	Sum([]byte("data"))
+	require.Equal(t, 2, m.sums)
+}
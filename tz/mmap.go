@@ -0,0 +1,66 @@
+//go:build !purego && !tinygo
+// +build !purego,!tinygo
+
+package tz
+
+import (
+	"errors"
+	"os"
+	"runtime/debug"
+
+	"github.com/nspcc-dev/tzhash/mmap"
+)
+
+// mmapWindow is the size of file range mapped at once. It limits address space
+// usage, which is important on 32-bit platforms. It must be a multiple of
+// the page size and is a variable for tests only.
+var mmapWindow int64 = 64 << 20
+
+var errFault = errors.New("fault while reading mapped file, was it truncated?")
+
+// memoryFault is implemented by runtime errors caused by invalid memory
+// accesses, while panic on fault is enabled.
+type memoryFault interface {
+	Addr() uintptr
+}
+
+// sumMapped writes length bytes of f starting at off to d using memory mapping.
+// If the file can't be mapped, false is returned and nothing is written to d.
+// Memory faults which happen if the file is truncated concurrently are
+// returned as errors, other panics are propagated.
+func sumMapped(f *os.File, off, length int64, d *Digest) (ok bool, err error) {
+	old := debug.SetPanicOnFault(true)
+	defer func() {
+		debug.SetPanicOnFault(old)
+		if r := recover(); r != nil {
+			if _, isFault := r.(memoryFault); !isFault {
+				panic(r)
+			}
+			ok, err = true, errFault
+		}
+	}()
+
+	for first := true; length > 0; first = false {
+		n := length
+		if n > mmapWindow {
+			n = mmapWindow
+		}
+		if err := sumWindow(f, off, n, d); err != nil {
+			return !first, err
+		}
+		off += n
+		length -= n
+	}
+	return true, nil
+}
+
+func sumWindow(f *os.File, off, length int64, d *Digest) error {
+	r, err := mmap.Map(f, off, length)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, _ = d.Write(r.Bytes())
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build !purego && !tinygo
+// +build !purego,!tinygo
+
+package tz
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumMappedWindows(t *testing.T) {
+	defer func(old int64) { mmapWindow = old }(mmapWindow)
+	mmapWindow = 2 * int64(os.Getpagesize())
+
+	testSumFileRange(t, int(mmapWindow))
+}
+
+func TestSumMappedFault(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mapping beyond EOF is only checked on Linux")
+	}
+
+	name := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(name, []byte("short"), 0o644))
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Pages beyond EOF can be mapped, but accessing them raises SIGBUS,
+	// the same as if the file was truncated after mapping.
+	size := 4 * int64(os.Getpagesize())
+	ok, err := sumMapped(f, 0, size, NewDigest())
+	require.True(t, ok)
+	require.ErrorIs(t, err, errFault)
+}
@@ -0,0 +1,57 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// msgpackBin8 is the MessagePack bin 8 format prefix.
+const msgpackBin8 = 0xc4
+
+// MarshalMsg implements msgp.Marshaler. Hash is appended to b
+// as a MessagePack binary.
+func (h Hash) MarshalMsg(b []byte) ([]byte, error) {
+	b = append(b, msgpackBin8, Size)
+	return append(b, h[:]...), nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler. Hash is decoded from
+// MessagePack binary of any width, the rest of b is returned.
+func (h *Hash) UnmarshalMsg(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, errors.New("unexpected end of MessagePack data")
+	}
+
+	var n int
+	switch b[0] {
+	case msgpackBin8:
+		n = 1
+	case msgpackBin8 + 1:
+		n = 2
+	case msgpackBin8 + 2:
+		n = 4
+	default:
+		return b, fmt.Errorf("unexpected MessagePack type 0x%02x", b[0])
+	}
+	if len(b) < 1+n {
+		return b, errors.New("unexpected end of MessagePack data")
+	}
+
+	var size uint64
+	for _, c := range b[1 : 1+n] {
+		size = size<<8 | uint64(c)
+	}
+	if size != Size {
+		return b, fmt.Errorf("invalid hash length: expected %d, got %d", Size, size)
+	}
+	if len(b) < 1+n+Size {
+		return b, errors.New("unexpected end of MessagePack data")
+	}
+	copy(h[:], b[1+n:])
+	return b[1+n+Size:], nil
+}
+
+// Msgsize implements msgp.Sizer.
+func (h Hash) Msgsize() int {
+	return 2 + Size
+}
@@ -0,0 +1,47 @@
+package tz
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpack(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	b, err := h.MarshalMsg([]byte{0x92}) // fixarray of 2
+	require.NoError(t, err)
+	require.Equal(t, "92c440"+h.String(), hex.EncodeToString(b))
+	require.Len(t, b[1:], h.Msgsize())
+
+	var actual Hash
+	rest, err := actual.UnmarshalMsg(append(b[1:], 0xc0))
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+	require.Equal(t, []byte{0xc0}, rest)
+
+	t.Run("wide", func(t *testing.T) {
+		for _, prefix := range [][]byte{{0xc5, 0, Size}, {0xc6, 0, 0, 0, Size}} {
+			var actual Hash
+			rest, err := actual.UnmarshalMsg(append(prefix, h[:]...))
+			require.NoError(t, err)
+			require.Empty(t, rest)
+			require.Equal(t, h, actual)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, b := range [][]byte{
+			nil,
+			{0xc4},
+			{0xc6, 0, 0},
+			b[1 : len(b)-1],
+			append([]byte{0xd9, Size}, h[:]...), // str 8
+			append([]byte{0xc4, Size - 1}, h[1:]...),
+		} {
+			var actual Hash
+			_, err := actual.UnmarshalMsg(b)
+			require.Error(t, err, "%x", b)
+		}
+	})
+}
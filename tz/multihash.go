@@ -0,0 +1,65 @@
+package tz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MultihashCode is the multihash function code of Tillich-Zémor hash.
+// There is no code for it in the multicodec table, so the first one
+// of the private use range is used.
+const MultihashCode = 0x300000
+
+// EncodeMultihash returns h in the multihash format: varint-encoded function
+// code and digest length followed by the digest.
+func EncodeMultihash(h Hash) []byte {
+	b := make([]byte, 0, 2*binary.MaxVarintLen64+Size)
+	b = appendUvarint(b, MultihashCode)
+	b = appendUvarint(b, Size)
+	return append(b, h[:]...)
+}
+
+// DecodeMultihash decodes multihash-encoded Tillich-Zémor hash.
+func DecodeMultihash(b []byte) (Hash, error) {
+	var h Hash
+
+	code, b, err := readUvarint(b)
+	if err != nil {
+		return h, fmt.Errorf("invalid multihash code: %w", err)
+	}
+	if code != MultihashCode {
+		return h, fmt.Errorf("unexpected multihash code 0x%x", code)
+	}
+
+	size, b, err := readUvarint(b)
+	if err != nil {
+		return h, fmt.Errorf("invalid multihash length: %w", err)
+	}
+	if size != Size || len(b) != Size {
+		return h, fmt.Errorf("invalid multihash length: expected %d, got %d", Size, len(b))
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// readUvarint decodes unsigned varint and returns the rest of b.
+// As multiformats require, only minimal encodings are accepted.
+func readUvarint(b []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(b)
+	switch {
+	case n == 0:
+		return 0, nil, errors.New("unexpected end of data")
+	case n < 0:
+		return 0, nil, errors.New("varint overflow")
+	case n > 1 && b[n-1] == 0:
+		return 0, nil, errors.New("varint is not minimally encoded")
+	}
+	return v, b[n:], nil
+}
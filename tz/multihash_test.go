@@ -0,0 +1,34 @@
+package tz
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultihash(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	b := EncodeMultihash(h)
+	require.Equal(t, "8080c001"+"40", hex.EncodeToString(b[:5]))
+	require.Len(t, b, 5+Size)
+
+	actual, err := DecodeMultihash(b)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, b := range [][]byte{
+			nil,
+			b[:4],
+			b[:len(b)-1],
+			append(b[:len(b):len(b)], 0),
+			append([]byte{0x12, 0x40}, h[:]...), // sha2-256
+			append([]byte{0x80, 0x80, 0xc0, 0x81, 0x00}, b[4:]...), // non-minimal
+		} {
+			_, err := DecodeMultihash(b)
+			require.Error(t, err, "%x", b)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package tz
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// chunkWorkTime is the desired amount of work for a single worker.
+	// Below this the cost of spawning goroutines becomes noticeable.
+	chunkWorkTime = 250 * time.Microsecond
+
+	minChunkSize = 4 * 1024
+	maxChunkSize = 4 * 1024 * 1024
+
+	chunkTuneSize = 16 * 1024
+
+	// minConcatParts is the minimal number of parts folded by
+	// a single ConcatParallel worker.
+	minConcatParts = 1024
+)
+
+var (
+	// chunkSize is set by SetParallelChunkSize.
+	chunkSize int64
+
+	chunkOnce  sync.Once
+	chunkTuned int
+)
+
+// SetParallelChunkSize sets the minimal amount of data hashed by a single
+// worker in SumParallel. Zero (default) enables auto-tuning: throughput
+// of the active backend is measured at first use and chunk size is chosen
+// so that every worker gets enough work to amortize goroutine overhead.
+// Negative values are treated as zero.
+func SetParallelChunkSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&chunkSize, int64(n))
+}
+
+func parallelChunkSize() int {
+	if n := atomic.LoadInt64(&chunkSize); n != 0 {
+		return int(n)
+	}
+	chunkOnce.Do(func() { chunkTuned = tuneChunkSize() })
+	return chunkTuned
+}
+
+// tuneChunkSize returns the amount of data hashed in chunkWorkTime
+// rounded to minChunkSize and clamped to [minChunkSize, maxChunkSize].
+func tuneChunkSize() int {
+	data := make([]byte, chunkTuneSize)
+	for i := range data {
+		data[i] = byte(i) | 1 // avoid zero-run fast path
+	}
+
+	// Backend is called directly so that tuning is not reported
+	// to metrics and tracer.
+	d := getDigest()
+	start := time.Now()
+	d.write(data)
+	elapsed := time.Since(start)
+	Put(d)
+	if elapsed <= 0 {
+		return maxChunkSize
+	}
+
+	n := int(int64(chunkTuneSize) * int64(chunkWorkTime) / int64(elapsed))
+	n = n / minChunkSize * minChunkSize
+	switch {
+	case n < minChunkSize:
+		return minChunkSize
+	case n > maxChunkSize:
+		return maxChunkSize
+	default:
+		return n
+	}
+}
+
+// SumParallel returns Tillich-Zémor checksum of data computed by
+// at most workers goroutines. If workers is not positive, GOMAXPROCS is used.
+// Number of workers is also limited so that every worker hashes at least
+// a chunk of data, see SetParallelChunkSize. Result is the same as the one of Sum.
+//
+// Data is split into contiguous shards, one per worker, and shard hashes
+// are combined using the homomorphic property. Every worker only touches its
+// own memory region and results of different workers are kept in separate
+// cache lines.
+func SumParallel(data []byte, workers int) [Size]byte {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if max := len(data) / parallelChunkSize(); workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		return Sum(data)
+	}
+
+	var (
+		m     = currentMetrics()
+		start time.Time
+	)
+	if m != nil {
+		start = time.Now()
+	}
+
+	var (
+		wg     sync.WaitGroup
+		res    = make([]workerResult, workers)
+		result = id
+	)
+
+	for i := 0; i < workers; i++ {
+		// Shard sizes differ by at most one byte and are never empty.
+		lo := i * len(data) / workers
+		hi := (i + 1) * len(data) / workers
+
+		wg.Add(1)
+		go func(i int, part []byte) {
+			defer wg.Done()
+			res[i].c = sumSL2(part)
+		}(i, data[lo:hi])
+	}
+	wg.Wait()
+
+	for i := range res {
+		mulSL2(&result, &res[i].c, &result)
+	}
+
+	addOp(m, OpSum)
+	if m != nil {
+		m.ObserveSum(ActiveBackend(), time.Since(start))
+	}
+	return result.Bytes()
+}
+
+// workerResult is a hash computed by a single SumParallel worker.
+// It is padded so that results of different workers never share a cache line.
+type workerResult struct {
+	c sl2
+	_ [cacheLineSize]byte
+}
+
+// sumSL2 returns hash of data as a matrix.
+func sumSL2(data []byte) sl2 {
+	d := getDigest()
+	_, _ = d.Write(data)
+	d.flush()
+	c := d.sl2()
+	Put(d)
+	return c
+}
+
+// ConcatParallel is like Concat, but combines hashes using at most workers
+// goroutines. If workers is not positive, GOMAXPROCS is used.
+// Parts are combined with a balanced binary tree: every subtree is computed
+// concurrently and the order of operands is preserved, so the result is
+// the same as the one of Concat.
+func ConcatParallel(hs [][]byte, workers int) ([]byte, error) {
+	var b sl2
+
+	countOp(OpConcat)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if err := concatTree(hs, workers, &b); err != nil {
+		return nil, err
+	}
+	return b.MarshalBinary()
+}
+
+// concatTree sets r to product of hs, splitting it in halves
+// while there are spare workers.
+func concatTree(hs [][]byte, workers int, r *sl2) error {
+	if workers <= 1 || len(hs) < 2*minConcatParts {
+		return fold(hs, r)
+	}
+
+	var (
+		wg          sync.WaitGroup
+		left, right sl2
+		lerr        error
+		mid         = len(hs) / 2
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lerr = concatTree(hs[:mid], workers/2, &left)
+	}()
+	rerr := concatTree(hs[mid:], workers-workers/2, &right)
+	wg.Wait()
+
+	if lerr != nil {
+		return lerr
+	} else if rerr != nil {
+		return rerr
+	}
+	mulSL2(&left, &right, r)
+	return nil
+}
@@ -0,0 +1,121 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumParallel(t *testing.T) {
+	const chunk = 16 * 1024
+
+	SetParallelChunkSize(chunk)
+	t.Cleanup(func() { SetParallelChunkSize(0) })
+
+	data := make([]byte, 5*chunk+123)
+	copy(data, newBuffer())
+
+	for _, size := range []int{0, 1, chunk, 2*chunk - 1, len(data)} {
+		expected := Sum(data[:size])
+		for _, workers := range []int{-1, 0, 1, 2, 3, 8} {
+			require.Equal(t, expected, SumParallel(data[:size], workers),
+				"size %d, workers %d", size, workers)
+		}
+	}
+}
+
+func TestSumParallelSmallChunks(t *testing.T) {
+	t.Cleanup(func() { SetParallelChunkSize(0) })
+
+	data := newBuffer()[:100]
+	testCases := []struct {
+		chunk, size int
+	}{
+		{1, 1}, {1, 2}, {1, 10}, {1, 13}, {1, 100},
+		{2, 7}, {3, 10}, {3, 11}, {7, 50}, {33, 100},
+	}
+	for _, tc := range testCases {
+		SetParallelChunkSize(tc.chunk)
+		expected := Sum(data[:tc.size])
+		for _, workers := range []int{2, 3, 6, 7, 9, 64} {
+			require.Equal(t, expected, SumParallel(data[:tc.size], workers),
+				"chunk %d, size %d, workers %d", tc.chunk, tc.size, workers)
+		}
+	}
+}
+
+func TestConcatParallel(t *testing.T) {
+	data := newBuffer()
+	hs := make([][]byte, 5*minConcatParts+17)
+	for i := range hs {
+		h := Sum(data[i:][:16])
+		hs[i] = h[:]
+	}
+
+	for _, n := range []int{0, 1, minConcatParts, 2 * minConcatParts, len(hs)} {
+		expected, err := Concat(hs[:n])
+		require.NoError(t, err)
+		for _, workers := range []int{-1, 0, 1, 2, 3, 8} {
+			actual, err := ConcatParallel(hs[:n], workers)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual, "parts %d, workers %d", n, workers)
+		}
+	}
+
+	t.Run("invalid part", func(t *testing.T) {
+		bad := append([][]byte{}, hs...)
+		bad[len(bad)-1] = bad[len(bad)-1][1:]
+		_, err := ConcatParallel(bad, 4)
+		require.Error(t, err)
+	})
+}
+
+func TestParallelChunkSize(t *testing.T) {
+	n := parallelChunkSize()
+	require.GreaterOrEqual(t, n, minChunkSize)
+	require.LessOrEqual(t, n, maxChunkSize)
+	require.Zero(t, n%minChunkSize)
+
+	SetParallelChunkSize(123)
+	t.Cleanup(func() { SetParallelChunkSize(0) })
+	require.Equal(t, 123, parallelChunkSize())
+}
+
+func TestTuneChunkSizeNotInstrumented(t *testing.T) {
+	m := newTestMetrics()
+	SetMetrics(m)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	n := tuneChunkSize()
+	require.GreaterOrEqual(t, n, minChunkSize)
+	require.Empty(t, m.bytes)
+	require.Empty(t, m.ops)
+	require.Zero(t, m.sums)
+}
+
+func BenchmarkSumParallel(b *testing.B) {
+	data := make([]byte, 16<<20)
+	_, _ = rand.New(rand.NewSource(0)).Read(data)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SumParallel(data, 0)
+	}
+	b.SetBytes(int64(len(data)))
+}
+
+func BenchmarkConcatParallel(b *testing.B) {
+	data := newBuffer()
+	hs := make([][]byte, 100000)
+	for i := range hs {
+		h := Sum(data[i%len(data):][:32])
+		hs[i] = h[:]
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ConcatParallel(hs, 0)
+	}
+}
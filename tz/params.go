@@ -0,0 +1,213 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+)
+
+// Identifiers of predefined parameter sets.
+const (
+	// ParamsClassicID identifies the classical Tillich-Zémor generators
+	// A = [[x, 1], [1, 0]] and B = [[x, x+1], [1, 1]] used by Sum.
+	// It is equal to KeyV1, so tagged hashes of this set are storage keys.
+	ParamsClassicID byte = KeyV1
+	// ParamsAltID identifies generators A = [[x, 1], [1, 0]] and
+	// B = [[x+1, 1], [1, 0]] used by some other implementations.
+	ParamsAltID byte = 0x02
+
+	// MinCustomParamsID is the minimal identifier of custom parameter sets,
+	// smaller ones are reserved for predefined sets.
+	MinCustomParamsID byte = 0x80
+)
+
+// TaggedSize is the size of a hash tagged with the parameter set identifier.
+const TaggedSize = 1 + Size
+
+// Params is a set of hash parameters: two SL2 generators over GF(2^127)
+// multiplied for zero and one bits of data respectively.
+//
+// Hashes computed with different parameters are unrelated, so Params
+// produce tagged hashes: the identifier of the set followed by the hash.
+// Tagged hashes of different sets are never accepted together.
+type Params struct {
+	id      byte
+	classic bool
+	a, b    sl2
+
+	// table[v] is the product of generators for bits of v, most significant
+	// bit first. It is computed on first use, the classical set uses
+	// the optimized backends instead.
+	once  sync.Once
+	table *[256]sl2
+}
+
+// ParamsClassic is the parameter set used by Sum.
+var ParamsClassic = &Params{id: ParamsClassicID, classic: true}
+
+// ParamsAlt is the alternative predefined parameter set, see ParamsAltID.
+var ParamsAlt = func() *Params {
+	var a, b sl2
+	a[0][0], a[0][1], a[1][0] = GF127{2, 0}, GF127{1, 0}, GF127{1, 0}
+	b[0][0], b[0][1], b[1][0] = GF127{3, 0}, GF127{1, 0}, GF127{1, 0}
+	return &Params{id: ParamsAltID, a: a, b: b}
+}()
+
+// ErrUnknownParams is returned for tagged hashes with unknown parameter set.
+var ErrUnknownParams = errors.New("unknown parameter set")
+
+// NewParams returns custom parameter set with generators a and b,
+// which must be valid SL2 elements encoded like hashes. id must not be
+// less than MinCustomParamsID.
+func NewParams(id byte, a, b Hash) (*Params, error) {
+	var ga, gb sl2
+	if id < MinCustomParamsID {
+		return nil, fmt.Errorf("identifier 0x%02x is reserved", id)
+	}
+	if err := a.Validate(); err != nil {
+		return nil, fmt.Errorf("generator A: %w", err)
+	}
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("generator B: %w", err)
+	}
+	if a == b {
+		return nil, errors.New("generators must differ")
+	}
+	_ = ga.UnmarshalBinary(a[:])
+	_ = gb.UnmarshalBinary(b[:])
+	return &Params{id: id, a: ga, b: gb}, nil
+}
+
+func (p *Params) initTable() {
+	p.table = new([256]sl2)
+	for v := 0; v < 256; v++ {
+		r := id
+		for i := 7; i >= 0; i-- {
+			if v>>uint(i)&1 == 0 {
+				mulSL2(&r, &p.a, &r)
+			} else {
+				mulSL2(&r, &p.b, &r)
+			}
+		}
+		p.table[v] = r
+	}
+}
+
+// LookupParams returns predefined parameter set with the identifier id.
+func LookupParams(id byte) (*Params, error) {
+	switch id {
+	case ParamsClassicID:
+		return ParamsClassic, nil
+	case ParamsAltID:
+		return ParamsAlt, nil
+	default:
+		return nil, fmt.Errorf("%w 0x%02x", ErrUnknownParams, id)
+	}
+}
+
+// ID returns the identifier of the parameter set.
+func (p *Params) ID() byte {
+	return p.id
+}
+
+// Sum returns tagged hash of data.
+func (p *Params) Sum(data []byte) []byte {
+	d := p.New()
+	_, _ = d.Write(data)
+	return d.Sum(nil)
+}
+
+// New returns hash.Hash computing tagged hashes.
+func (p *Params) New() hash.Hash {
+	if p.classic {
+		return taggedDigest{NewDigest(), p.id}
+	}
+	p.once.Do(p.initTable)
+	d := &paramsDigest{p: p}
+	d.Reset()
+	return d
+}
+
+// Concat returns tagged hash of concatenated data with the given tagged
+// hashes, which must belong to the parameter set.
+func (p *Params) Concat(hs [][]byte) ([]byte, error) {
+	var r, c sl2
+
+	r = id
+	for i := range hs {
+		h, err := p.Untag(hs[i])
+		if err != nil {
+			return nil, err
+		}
+		_ = c.UnmarshalBinary(h[:])
+		mulSL2(&r, &c, &r)
+	}
+	return p.tag(r.Bytes()), nil
+}
+
+// Untag returns the hash from the tagged one after checking that it
+// belongs to the parameter set and is valid.
+func (p *Params) Untag(tagged []byte) (Hash, error) {
+	var h Hash
+	if len(tagged) != TaggedSize {
+		return h, fmt.Errorf("invalid tagged hash length: expected %d, got %d", TaggedSize, len(tagged))
+	}
+	if tagged[0] != p.id {
+		return h, fmt.Errorf("hash of parameter set 0x%02x, expected 0x%02x", tagged[0], p.id)
+	}
+	copy(h[:], tagged[1:])
+	if err := h.Validate(); err != nil {
+		return Hash{}, err
+	}
+	return h, nil
+}
+
+func (p *Params) tag(h [Size]byte) []byte {
+	return append([]byte{p.id}, h[:]...)
+}
+
+// taggedDigest computes hashes with the classical parameters using
+// the optimized backends.
+type taggedDigest struct {
+	*Digest
+	id byte
+}
+
+func (d taggedDigest) Sum(in []byte) []byte {
+	return d.Digest.Sum(append(in, d.id))
+}
+
+func (d taggedDigest) Size() int {
+	return TaggedSize
+}
+
+// paramsDigest multiplies precomputed products of generators for every byte.
+type paramsDigest struct {
+	p *Params
+	r sl2
+}
+
+func (d *paramsDigest) Write(data []byte) (int, error) {
+	for _, v := range data {
+		mulSL2(&d.r, &d.p.table[v], &d.r)
+	}
+	return len(data), nil
+}
+
+func (d *paramsDigest) Sum(in []byte) []byte {
+	h := d.r.Bytes()
+	return append(append(in, d.p.id), h[:]...)
+}
+
+func (d *paramsDigest) Reset() {
+	d.r = id
+}
+
+func (d *paramsDigest) Size() int {
+	return TaggedSize
+}
+
+func (d *paramsDigest) BlockSize() int {
+	return 1
+}
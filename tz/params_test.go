@@ -0,0 +1,92 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+	h := Hash(Sum(data))
+
+	t.Run("classic", func(t *testing.T) {
+		tagged := ParamsClassic.Sum(data)
+		require.Equal(t, h.Key(), tagged)
+
+		// Table-based digest with the same generators.
+		var ga, gb sl2
+		ga[0][0], ga[0][1], ga[1][0] = GF127{2, 0}, GF127{1, 0}, GF127{1, 0}
+		gb[0][0], gb[0][1], gb[1][0], gb[1][1] = GF127{2, 0}, GF127{3, 0}, GF127{1, 0}, GF127{1, 0}
+		p, err := NewParams(MinCustomParamsID, ga.Bytes(), gb.Bytes())
+		require.NoError(t, err)
+		actual := p.Sum(data)
+		require.Equal(t, MinCustomParamsID, actual[0])
+		require.Equal(t, h[:], actual[1:])
+	})
+
+	for _, p := range []*Params{ParamsClassic, ParamsAlt} {
+		tagged := p.Sum(data)
+		require.Len(t, tagged, TaggedSize)
+		require.Equal(t, p.ID(), tagged[0])
+
+		d := p.New()
+		require.Equal(t, TaggedSize, d.Size())
+		_, _ = d.Write(data[:300])
+		_, _ = d.Write(data[300:])
+		require.Equal(t, tagged, d.Sum(nil))
+
+		c, err := p.Concat([][]byte{p.Sum(data[:123]), p.Sum(data[123:])})
+		require.NoError(t, err)
+		require.Equal(t, tagged, c)
+
+		c, err = p.Concat(nil)
+		require.NoError(t, err)
+		require.Equal(t, p.Sum(nil), c)
+
+		found, err := LookupParams(p.ID())
+		require.NoError(t, err)
+		require.Equal(t, p, found)
+	}
+
+	alt := ParamsAlt.Sum(data)
+	require.NotEqual(t, h[:], alt[1:])
+
+	_, err := ParamsClassic.Concat([][]byte{ParamsClassic.Sum(data), alt})
+	require.Error(t, err)
+	_, err = ParamsAlt.Untag(alt[1:])
+	require.Error(t, err)
+	bad := append([]byte{}, alt...)
+	bad[1] ^= 1
+	_, err = ParamsAlt.Untag(bad)
+	require.Error(t, err)
+
+	_, err = LookupParams(0x7F)
+	require.ErrorIs(t, err, ErrUnknownParams)
+}
+
+func TestNewParams(t *testing.T) {
+	a, b := Hash(Sum([]byte{1})), Hash(Sum([]byte{2}))
+
+	_, err := NewParams(ParamsAltID, a, b)
+	require.Error(t, err)
+	_, err = NewParams(MinCustomParamsID, a, a)
+	require.Error(t, err)
+	_, err = NewParams(MinCustomParamsID, Hash{}, b)
+	require.Error(t, err)
+	_, err = NewParams(MinCustomParamsID, a, Hash{})
+	require.Error(t, err)
+
+	p, err := NewParams(0xFF, a, b)
+	require.NoError(t, err)
+	p2, err := NewParams(0xFE, a, b)
+	require.NoError(t, err)
+
+	// Same generators, but different identifiers: hashes are domain-separated.
+	h1, h2 := p.Sum([]byte("data")), p2.Sum([]byte("data"))
+	require.Equal(t, h1[1:], h2[1:])
+	_, err = p.Concat([][]byte{h2})
+	require.Error(t, err)
+}
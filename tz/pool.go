@@ -0,0 +1,41 @@
+package tz
+
+import (
+	"hash"
+	"sync"
+)
+
+var digestPool = sync.Pool{
+	New: func() interface{} {
+		return NewDigest()
+	},
+}
+
+// Get returns a Tillich-Zémor digest from the package-level pool.
+// Returned digest is always in the initial state and uses the current backend.
+// It should be returned to the pool with Put when no longer needed.
+func Get() *Digest {
+	return getDigest()
+}
+
+// getDigest returns a digest from the pool. Pooled digests were reset
+// when they were put, so the backend is resolved again to respect
+// SetBackend calls made since then.
+func getDigest() *Digest {
+	d := digestPool.Get().(*Digest)
+	d.b = currentBackend()
+	return d
+}
+
+// Put resets h and returns it to the pool.
+// Hashes which were not obtained from this package and digests with
+// buffers set by WithBuffer or WithBufferSize are ignored.
+// h must not be used after Put.
+func Put(h hash.Hash) {
+	d, ok := h.(*Digest)
+	if !ok || !d.defaultBuf {
+		return
+	}
+	d.Reset()
+	digestPool.Put(d)
+}
@@ -0,0 +1,38 @@
+package tz
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	for _, tc := range testCases {
+		h := Get()
+		_, _ = h.Write(tc.input)
+		require.Equal(t, tc.hash, hex.EncodeToString(h.Sum(nil)))
+		Put(h)
+	}
+
+	t.Run("foreign hash", func(t *testing.T) {
+		require.NotPanics(t, func() { Put(sha256.New()) })
+	})
+
+	t.Run("caller buffer", func(t *testing.T) {
+		buf := make([]byte, hashBlockSize)
+		d := NewDigest(WithBuffer(buf))
+		Put(d)
+
+		// The digest must not be reused, otherwise buffered data
+		// would be written into buf.
+		for i := 0; i < 10; i++ {
+			h := Get()
+			require.NotSame(t, d, h)
+			_, _ = h.Write([]byte("abc"))
+			Put(h)
+		}
+		require.Equal(t, make([]byte, hashBlockSize), buf)
+	})
+}
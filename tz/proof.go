@@ -0,0 +1,141 @@
+package tz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// proofVersion is the first byte of marshaled InclusionProof.
+const proofVersion = 0x01
+
+// InclusionProof asserts that a chunk occupies bytes
+// [Offset, Offset+Length) of an object of Size bytes. It consists of hashes
+// of the object data before and after the chunk, so that
+// Concat(Prefix, chunk, Suffix) is the hash of the object.
+//
+// Hashes don't encode data length, so offsets are a claim of the prover
+// which isn't authenticated by the proof: it binds the chunk to the object
+// hash, but the verifier must trust the prover or verify the prefix
+// by other means to rely on the exact position.
+type InclusionProof struct {
+	Offset int64
+	Length int64
+	Size   int64
+	Prefix Hash
+	Suffix Hash
+}
+
+// Prove returns proof of inclusion of data[offset:offset+length] into data.
+func Prove(data []byte, offset, length int64) (*InclusionProof, error) {
+	size := int64(len(data))
+	if offset < 0 || length < 0 || offset > size || length > size-offset {
+		return nil, fmt.Errorf("range [%d, %d) is out of object bounds", offset, offset+length)
+	}
+	return &InclusionProof{
+		Offset: offset,
+		Length: length,
+		Size:   size,
+		Prefix: Sum(data[:offset]),
+		Suffix: Sum(data[offset+length:]),
+	}, nil
+}
+
+// ProveHashes returns proof of inclusion of the chunk with hash chunk into
+// the object with hash object given the hash of the object prefix before
+// the chunk. The suffix hash is recovered from them by subtraction,
+// so it needn't be known. The result is checked to be consistent.
+func ProveHashes(object, prefix, chunk Hash, offset, length, size int64) (*InclusionProof, error) {
+	rest, err := SubtractL(object[:], prefix[:])
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := SubtractL(rest, chunk[:])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &InclusionProof{Offset: offset, Length: length, Size: size, Prefix: prefix}
+	copy(p.Suffix[:], suffix)
+	if err := p.Verify(object, chunk); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Verify checks that the chunk with hash chunk is a part of the object
+// with hash object. ErrChecksumMismatch is returned if hashes don't match.
+func (p *InclusionProof) Verify(object, chunk Hash) error {
+	if p.Offset < 0 || p.Length < 0 || p.Offset > p.Size || p.Length > p.Size-p.Offset {
+		return fmt.Errorf("range [%d, %d) is out of object bounds", p.Offset, p.Offset+p.Length)
+	}
+	for _, h := range []Hash{p.Prefix, chunk, p.Suffix} {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var r, c sl2
+	_ = r.UnmarshalBinary(p.Prefix[:])
+	_ = c.UnmarshalBinary(chunk[:])
+	mulSL2(&r, &c, &r)
+	_ = c.UnmarshalBinary(p.Suffix[:])
+	mulSL2(&r, &c, &r)
+	if r.Bytes() != object {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// VerifyData is like Verify, but checks chunk data including its length.
+func (p *InclusionProof) VerifyData(object Hash, data []byte) error {
+	if int64(len(data)) != p.Length {
+		return fmt.Errorf("chunk length is %d, expected %d", len(data), p.Length)
+	}
+	return p.Verify(object, Sum(data))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is
+// the version byte, offset, length and size as unsigned varints,
+// then prefix and suffix hashes.
+func (p *InclusionProof) MarshalBinary() ([]byte, error) {
+	if p.Offset < 0 || p.Length < 0 || p.Size < 0 {
+		return nil, errors.New("negative proof range")
+	}
+
+	b := make([]byte, 1, 1+3*binary.MaxVarintLen64+2*Size)
+	b[0] = proofVersion
+
+	var buf [binary.MaxVarintLen64]byte
+	for _, v := range []int64{p.Offset, p.Length, p.Size} {
+		n := binary.PutUvarint(buf[:], uint64(v))
+		b = append(b, buf[:n]...)
+	}
+	b = append(b, p.Prefix[:]...)
+	return append(b, p.Suffix[:]...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *InclusionProof) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != proofVersion {
+		return errors.New("invalid inclusion proof version")
+	}
+	data = data[1:]
+
+	var r InclusionProof
+	for _, v := range []*int64{&r.Offset, &r.Length, &r.Size} {
+		u, n := binary.Uvarint(data)
+		if n <= 0 || u > 1<<63-1 {
+			return errors.New("invalid inclusion proof range")
+		}
+		*v = int64(u)
+		data = data[n:]
+	}
+	if len(data) != 2*Size {
+		return errors.New("invalid inclusion proof length")
+	}
+	copy(r.Prefix[:], data)
+	copy(r.Suffix[:], data[Size:])
+	*p = r
+	return nil
+}
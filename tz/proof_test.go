@@ -0,0 +1,88 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclusionProof(t *testing.T) {
+	data := make([]byte, 5000)
+	_, _ = rand.Read(data)
+	object := Hash(Sum(data))
+	chunk := data[1200:3100]
+
+	p, err := Prove(data, 1200, 1900)
+	require.NoError(t, err)
+	require.EqualValues(t, 5000, p.Size)
+	require.NoError(t, p.Verify(object, Sum(chunk)))
+	require.NoError(t, p.VerifyData(object, chunk))
+
+	t.Run("mismatch", func(t *testing.T) {
+		require.ErrorIs(t, p.Verify(object, Sum(data[1201:3101])), ErrChecksumMismatch)
+		require.ErrorIs(t, p.Verify(Sum(data[1:]), Sum(chunk)), ErrChecksumMismatch)
+		require.Error(t, p.VerifyData(object, chunk[1:]))
+
+		// Prefix and suffix must not be swapped.
+		swapped := *p
+		swapped.Prefix, swapped.Suffix = p.Suffix, p.Prefix
+		require.ErrorIs(t, swapped.Verify(object, Sum(chunk)), ErrChecksumMismatch)
+
+		bad := *p
+		bad.Length = bad.Size
+		require.Error(t, bad.Verify(object, Sum(chunk)))
+		require.Error(t, p.Verify(object, Hash{}))
+	})
+
+	t.Run("hashes", func(t *testing.T) {
+		q, err := ProveHashes(object, Sum(data[:1200]), Sum(chunk), 1200, 1900, 5000)
+		require.NoError(t, err)
+		require.Equal(t, p, q)
+
+		_, err = ProveHashes(object, Sum(data[:1201]), Sum(chunk), 1200, 1900, 5000)
+		require.NoError(t, err) // prefix is not authenticated, see InclusionProof
+		_, err = ProveHashes(object, Sum(data[:1200]), Sum(chunk), 1200, 1900, 3000)
+		require.Error(t, err)
+	})
+
+	t.Run("edges", func(t *testing.T) {
+		for _, r := range [][2]int64{{0, 0}, {0, 5000}, {5000, 0}, {0, 1}, {4999, 1}} {
+			p, err := Prove(data, r[0], r[1])
+			require.NoError(t, err)
+			require.NoError(t, p.VerifyData(object, data[r[0]:r[0]+r[1]]))
+		}
+		for _, r := range [][2]int64{{-1, 1}, {0, -1}, {5000, 1}, {1, 5000}} {
+			_, err := Prove(data, r[0], r[1])
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestInclusionProofMarshal(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	p, err := Prove(data, 300, 500)
+	require.NoError(t, err)
+
+	b, err := p.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, b, 1+2+2+2+2*Size)
+
+	var q InclusionProof
+	require.NoError(t, q.UnmarshalBinary(b))
+	require.Equal(t, *p, q)
+
+	require.Error(t, q.UnmarshalBinary(nil))
+	require.Error(t, q.UnmarshalBinary(b[:len(b)-1]))
+	require.Error(t, q.UnmarshalBinary(append(b, 0)))
+	bad := append([]byte(nil), b...)
+	bad[0] = 0x02
+	require.Error(t, q.UnmarshalBinary(bad))
+	require.Error(t, q.UnmarshalBinary([]byte{proofVersion, 0xFF}))
+
+	p.Offset = -1
+	_, err = p.MarshalBinary()
+	require.Error(t, err)
+}
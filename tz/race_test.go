@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package tz
+
+// raceEnabled is true if the race detector is enabled. It randomly drops
+// items put to sync.Pool, so pooled paths allocate.
+const raceEnabled = true
@@ -0,0 +1,78 @@
+package tz
+
+import (
+	"context"
+	"io"
+)
+
+// readBufferSize is the size of each of the buffers used by SumReader.
+const readBufferSize = 1 << 20
+
+// SumReader returns Tillich-Zémor checksum of the data read from r until EOF.
+// Reading is performed in a separate goroutine using two alternating buffers,
+// so that the next buffer is being filled while the previous one is hashed.
+func SumReader(r io.Reader) ([Size]byte, error) {
+	return SumReaderContext(context.Background(), r)
+}
+
+// SumReaderContext is like SumReader, but the span started by the Tracer,
+// if any, is a child of the one in ctx.
+func SumReaderContext(ctx context.Context, r io.Reader) ([Size]byte, error) {
+	sp := startSpan(ctx, "tz.SumReader", -1)
+	d := NewDigest()
+	c := &countingReader{r: r}
+	err := sumReader(c, d)
+	if sp != nil {
+		sp.End(c.n, err)
+	}
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	return d.checkSum(), nil
+}
+
+// sumReader writes data read from r until EOF to d.
+func sumReader(r io.Reader, d *Digest) error {
+	var (
+		readErr error
+		free    = make(chan []byte, 2)
+		full    = make(chan []byte, 2)
+	)
+
+	free <- make([]byte, readBufferSize)
+	free <- make([]byte, readBufferSize)
+
+	go func() {
+		defer close(full)
+		for buf := range free {
+			n, err := io.ReadFull(r, buf)
+			if n != 0 {
+				full <- buf[:n]
+			}
+			if err != nil {
+				if err != io.EOF && err != io.ErrUnexpectedEOF {
+					readErr = err
+				}
+				return
+			}
+		}
+	}()
+
+	for buf := range full {
+		_, _ = d.Write(buf)
+		free <- buf[:cap(buf)]
+	}
+	return readErr
+}
+
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,35 @@
+package tz
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type errReader struct {
+	n   int
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, r.err
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestSumReader(t *testing.T) {
+	expected := errors.New("read error")
+	_, err := SumReader(&errReader{n: 3 * readBufferSize, err: expected})
+	require.True(t, errors.Is(err, expected))
+
+	h, err := SumReader(&errReader{n: 100, err: io.EOF})
+	require.NoError(t, err)
+	require.Equal(t, Sum(make([]byte, 100)), h)
+}
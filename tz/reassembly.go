@@ -0,0 +1,152 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReassemblyError is returned by Reassembly when the chunks added so far
+// can't be completed to the expected object anymore. It wraps
+// ErrChecksumMismatch.
+type ReassemblyError struct {
+	// Chunk is the index of the chunk after which reassembly
+	// became impossible.
+	Chunk int
+}
+
+func (e *ReassemblyError) Error() string {
+	return fmt.Sprintf("reassembly is impossible after chunk %d", e.Chunk)
+}
+
+// Unwrap returns ErrChecksumMismatch.
+func (e *ReassemblyError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// Reassembly verifies that chunks added one by one reproduce the object
+// with the known hash. Only the product of chunk hashes is kept,
+// so chunks are never held in memory together.
+//
+// Hashes of some chunks can be known in advance, e.g. from the manifest
+// or other replicas. Every chunk with the known hash is checked as soon as
+// it is added, and once all the remaining chunks are known, the product
+// of chunks added so far is checked against the expected prefix of the object.
+// Without known hashes the object is verified by Finish only.
+type Reassembly struct {
+	expected Hash
+	known    []Hash
+	// suffix[i] is the product of known hashes of chunks [i, len(known)),
+	// it is set for i >= checkFrom only, when all these hashes are known.
+	suffix    []sl2
+	checkFrom int
+	prefix    sl2
+	n         int
+	err       error
+}
+
+// NewReassembly returns Reassembly of the object with the expected hash.
+// If known is not nil, its length is the number of chunks and non-zero
+// elements are known chunk hashes.
+func NewReassembly(expected Hash, known []Hash) (*Reassembly, error) {
+	r := &Reassembly{expected: expected, known: known, prefix: id}
+	if err := expected.Validate(); err != nil {
+		return nil, err
+	}
+	if known == nil {
+		return r, nil
+	}
+
+	r.suffix = make([]sl2, len(known)+1)
+	r.suffix[len(known)] = id
+	r.checkFrom = len(known)
+	for i := range known {
+		if known[i] == (Hash{}) {
+			continue
+		}
+		if err := known[i].Validate(); err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+	}
+	for ; r.checkFrom > 0 && known[r.checkFrom-1] != (Hash{}); r.checkFrom-- {
+		var c sl2
+		i := r.checkFrom - 1
+		_ = c.UnmarshalBinary(known[i][:])
+		mulSL2(&c, &r.suffix[i+1], &r.suffix[i])
+	}
+	if r.checkFrom == 0 && Hash(r.suffix[0].Bytes()) != r.expected {
+		return nil, errors.New("known chunks don't match the object")
+	}
+	return r, nil
+}
+
+// Add hashes the next chunk read from rd until EOF and adds it.
+func (r *Reassembly) Add(rd io.Reader) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	d := NewDigest()
+	if _, err := io.Copy(d, rd); err != nil {
+		return fmt.Errorf("chunk %d: %w", r.n, err)
+	}
+	return r.AddHash(d.Checksum())
+}
+
+// AddHash adds the next chunk with hash h computed elsewhere.
+// *ReassemblyError is returned if the object can't be reassembled anymore,
+// the error is sticky.
+func (r *Reassembly) AddHash(h Hash) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	i := r.n
+	if r.known != nil {
+		if i >= len(r.known) {
+			return r.fail(i)
+		}
+		if k := r.known[i]; k != (Hash{}) && k != h {
+			return r.fail(i)
+		}
+	}
+
+	var c sl2
+	if err := h.Validate(); err != nil {
+		return fmt.Errorf("chunk %d: %w", i, err)
+	}
+	_ = c.UnmarshalBinary(h[:])
+	mulSL2(&r.prefix, &c, &r.prefix)
+	r.n++
+
+	if r.suffix != nil && r.n >= r.checkFrom {
+		var p sl2
+		mulSL2(&r.prefix, &r.suffix[r.n], &p)
+		if Hash(p.Bytes()) != r.expected {
+			return r.fail(i)
+		}
+	}
+	return nil
+}
+
+// Finish checks that all chunks were added and reproduce the object.
+func (r *Reassembly) Finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.known != nil && r.n != len(r.known) {
+		return fmt.Errorf("expected %d chunks, got %d", len(r.known), r.n)
+	}
+	if Hash(r.prefix.Bytes()) != r.expected {
+		if r.n == 0 {
+			return ErrChecksumMismatch
+		}
+		return r.fail(r.n - 1)
+	}
+	return nil
+}
+
+func (r *Reassembly) fail(i int) error {
+	r.err = &ReassemblyError{Chunk: i}
+	return r.err
+}
@@ -0,0 +1,116 @@
+package tz
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReassembly(t *testing.T) {
+	chunks := make([][]byte, 5)
+	for i := range chunks {
+		chunks[i] = make([]byte, 100+i)
+		_, _ = rand.Read(chunks[i])
+	}
+	h := Hash(Sum(bytes.Join(chunks, nil)))
+
+	hashes := make([]Hash, len(chunks))
+	for i := range chunks {
+		hashes[i] = Sum(chunks[i])
+	}
+
+	// unknown hides known hashes with the specified indices.
+	unknown := func(is ...int) []Hash {
+		res := append([]Hash{}, hashes...)
+		for _, i := range is {
+			res[i] = Hash{}
+		}
+		return res
+	}
+
+	bad := append([]byte{}, chunks[3]...)
+	bad[0] ^= 1
+	corrupted := [][]byte{chunks[0], chunks[1], chunks[2], bad, chunks[4]}
+
+	testCases := []struct {
+		name  string
+		known []Hash
+		fail  int // -1 if the failure is detected by Finish
+	}{
+		{"no known hashes", nil, -1},
+		{"all unknown", unknown(0, 1, 2, 3, 4), 4},
+		{"corrupted known", hashes, 3},
+		{"last unknown", unknown(4), 3},
+		{"corrupted unknown", unknown(1, 3), 3},
+		{"suffix known", unknown(0, 1, 2, 3), 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReassembly(h, tc.known)
+			require.NoError(t, err)
+			for i := range chunks {
+				require.NoError(t, r.Add(bytes.NewReader(chunks[i])))
+			}
+			require.NoError(t, r.Finish())
+
+			r, err = NewReassembly(h, tc.known)
+			require.NoError(t, err)
+
+			var re *ReassemblyError
+			for i := range corrupted {
+				err = r.Add(bytes.NewReader(corrupted[i]))
+				if err != nil {
+					break
+				}
+			}
+			if tc.fail < 0 {
+				require.NoError(t, err)
+				err = r.Finish()
+				tc.fail = len(chunks) - 1
+			}
+			require.True(t, errors.As(err, &re), "got %v", err)
+			require.Equal(t, tc.fail, re.Chunk)
+			require.ErrorIs(t, err, ErrChecksumMismatch)
+			require.Equal(t, err, r.Finish())
+		})
+	}
+
+	t.Run("chunk count", func(t *testing.T) {
+		r, err := NewReassembly(h, unknown(0, 1, 2, 3, 4))
+		require.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, r.AddHash(hashes[i]))
+		}
+		require.Error(t, r.Finish())
+		require.NoError(t, r.AddHash(hashes[4]))
+		require.NoError(t, r.Finish())
+
+		var re *ReassemblyError
+		require.True(t, errors.As(r.AddHash(hashes[0]), &re))
+		require.Equal(t, 5, re.Chunk)
+	})
+	t.Run("empty", func(t *testing.T) {
+		r, err := NewReassembly(Sum(nil), nil)
+		require.NoError(t, err)
+		require.NoError(t, r.Finish())
+
+		r, err = NewReassembly(h, nil)
+		require.NoError(t, err)
+		require.ErrorIs(t, r.Finish(), ErrChecksumMismatch)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewReassembly(Hash{}, nil)
+		require.Error(t, err)
+		_, err = NewReassembly(h, []Hash{hashes[0], {1}})
+		require.Error(t, err)
+		_, err = NewReassembly(h, hashes[1:])
+		require.Error(t, err)
+
+		r, err := NewReassembly(h, nil)
+		require.NoError(t, err)
+		require.Error(t, r.AddHash(Hash{1}))
+	})
+}
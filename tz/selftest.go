@@ -0,0 +1,102 @@
+package tz
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrSelfTest is returned by SelfTest when a known-answer test fails.
+var ErrSelfTest = errors.New("self-test failed")
+
+// selfTestVectors are known answers verified against package tzref.
+var selfTestVectors = []struct {
+	input []byte
+	hash  string
+}{
+	{
+		[]byte{},
+		"00000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+	},
+	{
+		[]byte{0},
+		"00000000000000000000000000000151000000000000000000000000000000800000000000000000000000000000008000000000000000000000000000000051",
+	},
+	{
+		[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		"0000000000000000000001bb00ba00ba000000000000000000000101010101010000000000000000000000ff00ff00ff0000000000000000000000ba01bb01bb",
+	},
+	{
+		[]byte{4, 8, 15, 16, 23, 42, 255, 0, 127, 65, 32, 123, 42, 45, 201, 210, 213, 244},
+		"4db8a8e253903c70ab0efb65fe6de05a36d1dc9f567a147152d0148a86817b2062908d9b026a506007c1118e86901b672a39317c55ee3c10ac8efafa79efe8ee",
+	},
+	{
+		selfTestPattern(1031),
+		"7c2a0181e50ee301e3879aad4b648c4659146795a92b6d0d4e84b54e86f84830341c64458854e88d173f146f163450bc495750402b2ded2a8c53fa79d0f47726",
+	},
+}
+
+// selfTestPattern returns n bytes of deterministic data which covers
+// all byte values.
+func selfTestPattern(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*167 + 13)
+	}
+	return data
+}
+
+// SelfTest runs known-answer tests against the currently selected backend
+// and the matrix arithmetic used for combining hashes. It is intended
+// for power-on checks before a node starts to trust its own hashes:
+// a non-nil error wrapping ErrSelfTest means that the CPU or the assembly
+// code misbehaves and hashes computed in this process must not be used.
+func SelfTest() error {
+	name := ActiveBackend()
+	// Buffering is disabled, so data is passed to the backend as is.
+	d := NewDigest(WithBufferSize(0))
+	for i, tc := range selfTestVectors {
+		d.Reset()
+		_, _ = d.Write(tc.input)
+		if h := d.Checksum(); hex.EncodeToString(h[:]) != tc.hash {
+			return fmt.Errorf("%w: backend %s: vector %d", ErrSelfTest, name, i)
+		}
+
+		// Unaligned writes of different sizes must produce the same result.
+		d.Reset()
+		for data, n := tc.input, 1; len(data) != 0; n = n*2 + 1 {
+			if n > len(data) {
+				n = len(data)
+			}
+			_, _ = d.Write(data[:n])
+			data = data[n:]
+		}
+		if h := d.Checksum(); hex.EncodeToString(h[:]) != tc.hash {
+			return fmt.Errorf("%w: backend %s: vector %d split", ErrSelfTest, name, i)
+		}
+	}
+
+	// Homomorphism checks matrix multiplication and inversion.
+	var (
+		data     = selfTestVectors[len(selfTestVectors)-1].input
+		expected = selfTestVectors[len(selfTestVectors)-1].hash
+		hs       = make([][]byte, 0, 3)
+	)
+	for _, part := range [][]byte{data[:100], data[100:611], data[611:]} {
+		h := Sum(part)
+		hs = append(hs, h[:])
+	}
+	c, err := Concat(hs)
+	if err != nil || hex.EncodeToString(c) != expected {
+		return fmt.Errorf("%w: concatenation", ErrSelfTest)
+	}
+	a, err := SubtractR(c, hs[2])
+	if err != nil {
+		return fmt.Errorf("%w: subtraction", ErrSelfTest)
+	}
+	if b, err := SubtractL(a, hs[0]); err != nil || !bytes.Equal(b, hs[1]) {
+		return fmt.Errorf("%w: subtraction", ErrSelfTest)
+	}
+	return nil
+}
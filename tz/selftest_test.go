@@ -0,0 +1,24 @@
+package tz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	for _, name := range Backends() {
+		t.Run(name, func(t *testing.T) {
+			prepareBackend(t, name)
+			require.NoError(t, SelfTest())
+		})
+	}
+
+	t.Run("wrong answer", func(t *testing.T) {
+		saved := selfTestVectors[1].hash
+		t.Cleanup(func() { selfTestVectors[1].hash = saved })
+
+		selfTestVectors[1].hash = selfTestVectors[0].hash
+		require.ErrorIs(t, SelfTest(), ErrSelfTest)
+	})
+}
@@ -115,6 +115,11 @@ func (c *sl2) MulB() *sl2 {
 	return c
 }
 
+// mulSL2 sets c to a*b using the current backend, c may alias a or b.
+func mulSL2(a, b, c *sl2) {
+	currentBackend().mulSL2(a, b, c)
+}
+
 // Mul returns a * b in GL_2(GF(2^127))
 func (c *sl2) Mul(a, b *sl2) *sl2 {
 	var x [4]GF127
@@ -146,6 +151,16 @@ func inv(a, b *sl2, t *[2]GF127) {
 	gf127.Mul(&a[0][0], &a[1][1], &t[0])
 	gf127.Mul(&a[0][1], &a[1][0], &t[1])
 	gf127.Add(&t[0], &t[1], &t[0])
+
+	// Determinant of every Tillich-Zémor hash is 1, because both generators
+	// belong to SL_2. In this case the inverse is the adjugate matrix
+	// (signs don't matter in characteristic 2) and no field inversion is needed.
+	if t[0] == (GF127{1, 0}) {
+		b[0][0], b[1][1] = a[1][1], a[0][0]
+		b[0][1], b[1][0] = a[0][1], a[1][0]
+		return
+	}
+
 	gf127.Inv(&t[0], &t[1])
 
 	gf127.Mul(&t[1], &a[0][0], &b[1][1])
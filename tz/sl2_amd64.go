@@ -0,0 +1,25 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
+package tz
+
+import "golang.org/x/sys/cpu"
+
+// hasPCLMUL is true if AVX backends can multiply matrices with mulSL2AVX.
+var hasPCLMUL = cpu.X86.HasAVX && cpu.X86.HasPCLMULQDQ
+
+// mulSL2 sets c to x*y using the matrix multiplication of the backend.
+// c may alias x or y.
+func (b *backend) mulSL2(x, y, c *sl2) {
+	if b.pclmul {
+		mulSL2AVX(x, y, c)
+	} else {
+		c.Mul(x, y)
+	}
+}
+
+// mulSL2AVX multiplies matrices computing one PCLMULQDQ-based product
+// of field elements at a time.
+//
+//go:noescape
+func mulSL2AVX(a, b, c *sl2)
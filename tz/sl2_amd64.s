@@ -0,0 +1,71 @@
+//go:build amd64 && !generic && !purego && !tinygo
+// +build amd64,!generic,!purego,!tinygo
+
+#include "textflag.h"
+
+// prod2 sets LO . HI to unreduced A1*B1 + A2*B2 using Karatsuba
+// multiplication. Products are summed before the reduction, because
+// reduction is linear.
+#define prod2(A1, B1, A2, B2, LO, HI, MID, T1, T2) \
+	VPCLMULQDQ  $0x00, A1, B1, LO  \
+	VPCLMULQDQ  $0x11, A1, B1, HI  \
+	VPUNPCKLQDQ B1, A1, T1         \
+	VPUNPCKHQDQ B1, A1, MID        \
+	VPXOR       T1, MID, MID       \
+	VPCLMULQDQ  $0x10, MID, MID, MID \
+	VPCLMULQDQ  $0x00, A2, B2, T1  \
+	VPXOR       T1, LO, LO         \
+	VPCLMULQDQ  $0x11, A2, B2, T1  \
+	VPXOR       T1, HI, HI         \
+	VPUNPCKLQDQ B2, A2, T1         \
+	VPUNPCKHQDQ B2, A2, T2         \
+	VPXOR       T1, T2, T2         \
+	VPCLMULQDQ  $0x10, T2, T2, T2  \
+	VPXOR       T2, MID, MID       \
+	VPXOR       LO, MID, MID       \
+	VPXOR       HI, MID, MID       \
+	VPSLLDQ     $8, MID, T1        \
+	VPXOR       T1, LO, LO         \
+	VPSRLDQ     $8, MID, MID       \
+	VPXOR       MID, HI, HI
+
+// reduce sets LO to LO . HI modulo x^127 + x^63 + 1, HI and T are clobbered.
+#define reduce(LO, HI, T) \
+	VPALIGNR    $8, LO, HI, T \
+	VPXOR       HI, T, T      \
+	VPSLLQ      $1, HI, HI    \
+	VPXOR       HI, LO, LO    \
+	VPUNPCKHQDQ T, HI, HI     \
+	VPXOR       HI, LO, LO    \
+	VPSRLQ      $63, T, T     \
+	VPXOR       T, LO, LO     \
+	VPUNPCKLQDQ T, T, HI      \
+	VPSLLQ      $63, HI, HI   \
+	VPXOR       HI, LO, LO
+
+#define mulElement(A1, B1, A2, B2, offset) \
+	prod2(A1, B1, A2, B2, X8, X9, X10, X11, X12) \
+	reduce(X8, X9, X10)                          \
+	VMOVDQU X8, offset(CX)
+
+// func mulSL2AVX(a, b, c *sl2)
+// Both operands are loaded before any result is stored, so c may alias a or b.
+TEXT ·mulSL2AVX(SB), NOSPLIT, $0
+	MOVQ a+0(FP), AX
+	MOVQ b+8(FP), BX
+	MOVQ c+16(FP), CX
+
+	VMOVDQU (AX), X0   // a00
+	VMOVDQU 16(AX), X1 // a01
+	VMOVDQU 32(AX), X2 // a10
+	VMOVDQU 48(AX), X3 // a11
+	VMOVDQU (BX), X4   // b00
+	VMOVDQU 16(BX), X5 // b01
+	VMOVDQU 32(BX), X6 // b10
+	VMOVDQU 48(BX), X7 // b11
+
+	mulElement(X0, X4, X1, X6, 0)  // c00 = a00*b00 + a01*b10
+	mulElement(X0, X5, X1, X7, 16) // c01 = a00*b01 + a01*b11
+	mulElement(X2, X4, X3, X6, 32) // c10 = a10*b00 + a11*b10
+	mulElement(X2, X5, X3, X7, 48) // c11 = a10*b01 + a11*b11
+	RET
@@ -0,0 +1,9 @@
+//go:build !(amd64 && !generic && !purego && !tinygo)
+// +build !amd64 generic purego tinygo
+
+package tz
+
+// mulSL2 sets c to x*y, c may alias x or y.
+func (b *backend) mulSL2(x, y, c *sl2) {
+	c.Mul(x, y)
+}
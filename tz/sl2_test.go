@@ -57,4 +57,62 @@ func TestInv(t *testing.T) {
 
 		require.Equal(t, id, *c)
 	}
+
+	// Matrices outside of SL2 require field inversion.
+	for i := 0; i < 5; i++ {
+		a = random()
+		gf127.Mul10(&a[0][0], &a[0][0])
+		gf127.Mul10(&a[0][1], &a[0][1])
+		b = Inv(a)
+		c = c.Mul(a, b)
+
+		require.Equal(t, id, *c)
+	}
+}
+
+func TestMulSL2(t *testing.T) {
+	for _, name := range Backends() {
+		t.Run(name, func(t *testing.T) {
+			prepareBackend(t, name)
+
+			for i := 0; i < 100; i++ {
+				a, b := random(), random()
+
+				var expected, actual sl2
+				expected.Mul(a, b)
+				mulSL2(a, b, &actual)
+				require.Equal(t, expected, actual)
+
+				// Result may alias operands.
+				c, d := *a, *b
+				mulSL2(&c, b, &c)
+				mulSL2(a, &d, &d)
+				require.Equal(t, expected, c)
+				require.Equal(t, expected, d)
+			}
+		})
+	}
+	t.Run("generic without PCLMULQDQ", func(t *testing.T) {
+		prepareBackend(t, "generic")
+		require.False(t, currentBackend().pclmul)
+	})
+}
+
+func TestFold(t *testing.T) {
+	hs := make([][]byte, 4*foldLanes+3)
+	for i := range hs {
+		hs[i], _ = random().MarshalBinary()
+	}
+
+	for n := 0; n <= len(hs); n++ {
+		var expected, actual, c sl2
+
+		expected = id
+		for _, h := range hs[:n] {
+			require.NoError(t, c.UnmarshalBinary(h))
+			expected.Mul(&expected, &c)
+		}
+		require.NoError(t, fold(hs[:n], &actual))
+		require.Equal(t, expected, actual, "n=%d", n)
+	}
 }
@@ -0,0 +1,47 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Value implements driver.Valuer, hash is stored as raw bytes
+// which is suitable for BYTEA and BLOB columns.
+func (h Hash) Value() (driver.Value, error) {
+	return h[:], nil
+}
+
+// Scan implements sql.Scanner. Raw bytes (BYTEA, BLOB) and hex text
+// optionally prefixed with "\x" (PostgreSQL BYTEA hex output) are supported.
+func (h *Hash) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == Size {
+			copy(h[:], v)
+			return nil
+		}
+		return h.scanHex(string(v))
+	case string:
+		return h.scanHex(v)
+	case nil:
+		return fmt.Errorf("can't scan NULL into %T", h)
+	default:
+		return fmt.Errorf("can't scan %T into %T", src, h)
+	}
+}
+
+func (h *Hash) scanHex(s string) error {
+	s = strings.TrimPrefix(s, `\x`)
+	if len(s) != 2*Size {
+		return fmt.Errorf("invalid hash length: expected %d bytes", Size)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return fmt.Errorf("invalid hash: %w", err)
+	}
+	return nil
+}
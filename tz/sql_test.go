@@ -0,0 +1,47 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ driver.Valuer = Hash{}
+	_ sql.Scanner   = (*Hash)(nil)
+)
+
+func TestHashSQL(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	v, err := h.Value()
+	require.NoError(t, err)
+	require.Equal(t, h[:], v)
+
+	for _, src := range []interface{}{
+		v,
+		h.String(),
+		[]byte(h.String()),
+		`\x` + h.String(),
+	} {
+		var actual Hash
+		require.NoError(t, actual.Scan(src), "%T", src)
+		require.Equal(t, h, actual)
+	}
+
+	for _, src := range []interface{}{
+		nil,
+		42,
+		h[1:],
+		h.String()[2:],
+		"zz" + h.String()[2:],
+	} {
+		var actual Hash
+		require.Error(t, actual.Scan(src), "%v", src)
+	}
+}
@@ -0,0 +1,47 @@
+package tz
+
+import "sync/atomic"
+
+// Cumulative counters reported by ReadStats.
+var (
+	statBytes    uint64
+	statSum      uint64
+	statConcat   uint64
+	statSubtract uint64
+	statValidate uint64
+)
+
+// Stats describes hashing implementation used by the process and its
+// cumulative usage. It is suitable for expvar and debug handlers:
+//
+//	expvar.Publish("tzhash", expvar.Func(func() interface{} { return tz.ReadStats() }))
+type Stats struct {
+	// Backend is the name of the backend currently used.
+	Backend string `json:"backend"`
+	// Backends are names of all backends supported by the CPU.
+	Backends []string `json:"backends"`
+	// CPUFeatures are CPU features taken into account when choosing a backend.
+	CPUFeatures []string `json:"cpuFeatures"`
+	// BytesHashed is the number of bytes hashed since the process start.
+	BytesHashed uint64 `json:"bytesHashed"`
+	// Operations are the numbers of operations performed since the process
+	// start by Op* constants, see Metrics.
+	Operations map[string]uint64 `json:"operations"`
+}
+
+// ReadStats returns current Stats. Counters are read independently,
+// so they can be slightly inconsistent if hashing is in progress.
+func ReadStats() Stats {
+	return Stats{
+		Backend:     ActiveBackend(),
+		Backends:    Backends(),
+		CPUFeatures: CPUFeatures(),
+		BytesHashed: atomic.LoadUint64(&statBytes),
+		Operations: map[string]uint64{
+			OpSum:      atomic.LoadUint64(&statSum),
+			OpConcat:   atomic.LoadUint64(&statConcat),
+			OpSubtract: atomic.LoadUint64(&statSubtract),
+			OpValidate: atomic.LoadUint64(&statValidate),
+		},
+	}
+}
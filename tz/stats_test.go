@@ -0,0 +1,33 @@
+package tz
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStats(t *testing.T) {
+	before := ReadStats()
+	require.Equal(t, ActiveBackend(), before.Backend)
+	require.Equal(t, Backends(), before.Backends)
+	require.Equal(t, CPUFeatures(), before.CPUFeatures)
+
+	h := Sum(make([]byte, 100))
+	_, _ = NewDigest().Write(make([]byte, 10))
+	_, err := Concat([][]byte{h[:], h[:]})
+	require.NoError(t, err)
+
+	// Tests can run in parallel with other hashing, so only lower bounds are known.
+	after := ReadStats()
+	require.GreaterOrEqual(t, after.BytesHashed-before.BytesHashed, uint64(110))
+	require.GreaterOrEqual(t, after.Operations[OpSum]-before.Operations[OpSum], uint64(1))
+	require.GreaterOrEqual(t, after.Operations[OpConcat]-before.Operations[OpConcat], uint64(1))
+
+	data, err := json.Marshal(after)
+	require.NoError(t, err)
+
+	var actual Stats
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Equal(t, after, actual)
+}
@@ -0,0 +1,75 @@
+package tz
+
+import "io"
+
+// HashingWriter forwards writes to the underlying writer
+// and computes Tillich-Zémor checksum of the data written.
+type HashingWriter struct {
+	w io.Writer
+	d *Digest
+}
+
+// NewHashingWriter returns HashingWriter writing to w.
+func NewHashingWriter(w io.Writer) *HashingWriter {
+	return &HashingWriter{w: w, d: NewDigest()}
+}
+
+// Write implements io.Writer. Only the data accepted by the underlying
+// writer is hashed.
+func (h *HashingWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	_, _ = h.d.Write(p[:n])
+	return n, err
+}
+
+// Sum returns checksum of the data written so far.
+func (h *HashingWriter) Sum() [Size]byte {
+	return h.d.checkSum()
+}
+
+// HashingReader computes Tillich-Zémor checksum of the data
+// read from the underlying reader.
+type HashingReader struct {
+	r        io.Reader
+	d        *Digest
+	expected *[Size]byte
+	eof      bool
+}
+
+// NewHashingReader returns HashingReader reading from r.
+func NewHashingReader(r io.Reader) *HashingReader {
+	return &HashingReader{r: r, d: NewDigest()}
+}
+
+// NewVerifyingReader returns HashingReader reading from r, which returns
+// ErrChecksumMismatch instead of io.EOF if the checksum of the data
+// differs from expected.
+func NewVerifyingReader(r io.Reader, expected [Size]byte) *HashingReader {
+	h := NewHashingReader(r)
+	h.expected = &expected
+	return h
+}
+
+// Read implements io.Reader.
+func (h *HashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	_, _ = h.d.Write(p[:n])
+	if err == io.EOF {
+		h.eof = true
+		if h.expected != nil && *h.expected != h.d.checkSum() {
+			err = ErrChecksumMismatch
+		}
+	}
+	return n, err
+}
+
+// Sum returns checksum of the data read so far.
+func (h *HashingReader) Sum() [Size]byte {
+	return h.d.checkSum()
+}
+
+// EOF returns true if the underlying reader has reached EOF,
+// so that Sum returns checksum of all its data.
+func (h *HashingReader) EOF() bool {
+	return h.eof
+}
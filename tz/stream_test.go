@@ -0,0 +1,89 @@
+package tz
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// limitedWriter accepts at most n bytes.
+type limitedWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n, _ := w.Buffer.Write(p[:w.n])
+		w.n = 0
+		return n, errors.New("no space left")
+	}
+	w.n -= len(p)
+	return w.Buffer.Write(p)
+}
+
+func TestHashingWriter(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	t.Run("all", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		w := NewHashingWriter(&buf)
+		require.Equal(t, Sum(nil), w.Sum())
+
+		n, err := io.Copy(w, bytes.NewReader(data))
+		require.NoError(t, err)
+		require.EqualValues(t, len(data), n)
+		require.Equal(t, data, buf.Bytes())
+		require.Equal(t, Sum(data), w.Sum())
+	})
+	t.Run("short write", func(t *testing.T) {
+		lw := &limitedWriter{n: 300}
+
+		w := NewHashingWriter(lw)
+		n, err := w.Write(data)
+		require.Error(t, err)
+		require.Equal(t, 300, n)
+		require.Equal(t, Sum(data[:300]), w.Sum())
+	})
+}
+
+func TestHashingReader(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	t.Run("all", func(t *testing.T) {
+		r := NewHashingReader(bytes.NewReader(data))
+		require.False(t, r.EOF())
+
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+		require.True(t, r.EOF())
+		require.Equal(t, Sum(data), r.Sum())
+	})
+	t.Run("verify", func(t *testing.T) {
+		r := NewVerifyingReader(bytes.NewReader(data), Sum(data))
+		_, err := io.Copy(io.Discard, r)
+		require.NoError(t, err)
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		r := NewVerifyingReader(bytes.NewReader(data), Sum(data[1:]))
+		_, err := io.Copy(io.Discard, r)
+		require.True(t, errors.Is(err, ErrChecksumMismatch))
+		require.True(t, r.EOF())
+	})
+	t.Run("partial", func(t *testing.T) {
+		r := NewVerifyingReader(bytes.NewReader(data), Sum(data))
+		buf := make([]byte, 100)
+		_, err := io.ReadFull(r, buf)
+		require.NoError(t, err)
+		require.False(t, r.EOF())
+		require.Equal(t, Sum(data[:100]), r.Sum())
+	})
+}
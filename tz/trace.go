@@ -0,0 +1,55 @@
+package tz
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// DefaultTraceThreshold is the default minimal size of data hashing of which
+// is traced.
+const DefaultTraceThreshold = 1 << 20
+
+// Tracer starts spans around large hashing operations, so that hashing time
+// can be told apart from I/O in distributed traces. It is intended to be
+// a thin adapter over OpenTelemetry or similar tracer.
+type Tracer interface {
+	// Start starts a span named after the hashing function, e.g. "tz.Sum".
+	// ctx carries the parent span. backend is the name of the backend used.
+	Start(ctx context.Context, name string, backend string) TraceSpan
+}
+
+// TraceSpan is a span started by Tracer.
+type TraceSpan interface {
+	// End finishes the span. n is the number of bytes hashed, err is
+	// the operation error, if any.
+	End(n int64, err error)
+}
+
+type tracerHolder struct {
+	t         Tracer
+	threshold int64
+}
+
+var tracer atomic.Value
+
+// SetTracer sets tracer for Sum, SumReader and SumFile calls and their
+// context-aware variants. Only operations on at least threshold bytes
+// are traced, readers of unknown size are always traced. If threshold
+// is not positive, DefaultTraceThreshold is used. nil disables tracing,
+// which is the default.
+func SetTracer(t Tracer, threshold int64) {
+	if threshold <= 0 {
+		threshold = DefaultTraceThreshold
+	}
+	tracer.Store(tracerHolder{t: t, threshold: threshold})
+}
+
+// startSpan returns a started span if operation on size bytes must be traced.
+// Negative size means it is unknown.
+func startSpan(ctx context.Context, name string, size int64) TraceSpan {
+	h, _ := tracer.Load().(tracerHolder)
+	if h.t == nil || size >= 0 && size < h.threshold {
+		return nil
+	}
+	return h.t.Start(ctx, name, ActiveBackend())
+}
@@ -0,0 +1,76 @@
+package tz
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+type testSpan struct {
+	name, backend, parent string
+	n                     int64
+	err                   error
+	ended                 bool
+}
+
+type testTracer struct {
+	mtx   sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, backend string) TraceSpan {
+	parent, _ := ctx.Value(ctxKey{}).(string)
+	s := &testSpan{name: name, backend: backend, parent: parent}
+
+	t.mtx.Lock()
+	t.spans = append(t.spans, s)
+	t.mtx.Unlock()
+	return s
+}
+
+func (s *testSpan) End(n int64, err error) {
+	s.n, s.err, s.ended = n, err, true
+}
+
+func TestTracer(t *testing.T) {
+	tr := new(testTracer)
+	SetTracer(tr, 1000)
+	t.Cleanup(func() { SetTracer(nil, 0) })
+
+	data := make([]byte, 1000)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "parent")
+	backend := ActiveBackend()
+
+	Sum(data[:999])
+	require.Empty(t, tr.spans)
+
+	require.Equal(t, Sum(data[:999]), SumContext(ctx, data[:999]))
+	require.Empty(t, tr.spans)
+
+	h := Sum(data)
+	require.Equal(t, h, SumContext(ctx, data))
+	require.Equal(t, []*testSpan{
+		{name: "tz.Sum", backend: backend, n: 1000, ended: true},
+		{name: "tz.Sum", backend: backend, parent: "parent", n: 1000, ended: true},
+	}, tr.spans)
+
+	tr.spans = nil
+	actual, err := SumReaderContext(ctx, bytes.NewReader(data[:10]))
+	require.NoError(t, err)
+	require.Equal(t, Sum(data[:10]), actual)
+	require.Equal(t, []*testSpan{
+		{name: "tz.SumReader", backend: backend, parent: "parent", n: 10, ended: true},
+	}, tr.spans)
+
+	t.Run("disabled", func(t *testing.T) {
+		tr.spans = nil
+		SetTracer(nil, 0)
+		Sum(data)
+		require.Empty(t, tr.spans)
+	})
+}
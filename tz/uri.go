@@ -0,0 +1,112 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URIScheme is the scheme of Tillich-Zémor hash references.
+const URIScheme = "tz"
+
+// URIVersion is the current version of Tillich-Zémor algorithm parameters
+// used in references.
+const URIVersion = 1
+
+// URI is a self-describing reference to the data by its hash.
+type URI struct {
+	Hash Hash
+	// Salt is XOR salt applied to the data before hashing, if any.
+	Salt []byte
+	// Length is the data size. It is omitted if zero.
+	Length uint64
+}
+
+// FormatURI returns the reference in the "tz:<version>:<hex>" form with
+// hex-encoded salt and decimal length added as "salt" and "length" query
+// parameters if present.
+func FormatURI(u URI) string {
+	var b strings.Builder
+
+	b.WriteString(URIScheme + ":" + strconv.Itoa(URIVersion) + ":")
+	b.WriteString(u.Hash.String())
+
+	sep := byte('?')
+	if len(u.Salt) != 0 {
+		b.WriteByte(sep)
+		b.WriteString("salt=" + hex.EncodeToString(u.Salt))
+		sep = '&'
+	}
+	if u.Length != 0 {
+		b.WriteByte(sep)
+		b.WriteString("length=" + strconv.FormatUint(u.Length, 10))
+	}
+	return b.String()
+}
+
+// ParseURI parses the reference returned by FormatURI. Only the current
+// version is supported; unknown and repeated parameters are rejected.
+func ParseURI(s string) (URI, error) {
+	var u URI
+
+	rest := strings.TrimPrefix(s, URIScheme+":")
+	if len(rest) == len(s) {
+		return u, fmt.Errorf("URI scheme must be %q", URIScheme)
+	}
+
+	i := strings.IndexByte(rest, ':')
+	if i < 0 {
+		return u, errors.New("missing URI version")
+	}
+	version, err := strconv.ParseUint(rest[:i], 10, 32)
+	if err != nil {
+		return u, fmt.Errorf("invalid URI version: %w", err)
+	}
+	if version != URIVersion {
+		return u, fmt.Errorf("unsupported URI version %d", version)
+	}
+	rest = rest[i+1:]
+
+	var query string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+	if len(rest) != 2*Size {
+		return u, errors.New("invalid hash length")
+	}
+	if _, err := hex.Decode(u.Hash[:], []byte(rest)); err != nil {
+		return u, fmt.Errorf("invalid hash: %w", err)
+	}
+	if err := u.Hash.Validate(); err != nil {
+		return u, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return u, fmt.Errorf("invalid URI parameters: %w", err)
+	}
+	for k, v := range params {
+		if len(v) != 1 {
+			return u, fmt.Errorf("repeated URI parameter %q", k)
+		}
+		switch k {
+		case "salt":
+			if u.Salt, err = hex.DecodeString(v[0]); err != nil || len(u.Salt) == 0 {
+				return u, fmt.Errorf("invalid salt %q", v[0])
+			}
+		case "length":
+			if u.Length, err = strconv.ParseUint(v[0], 10, 64); err != nil {
+				return u, fmt.Errorf("invalid length: %w", err)
+			}
+		default:
+			return u, fmt.Errorf("unknown URI parameter %q", k)
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,61 @@
+//go:build !tinygo
+// +build !tinygo
+
+package tz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURI(t *testing.T) {
+	h := Hash(Sum([]byte("data")))
+
+	testCases := []struct {
+		u   URI
+		str string
+	}{
+		{URI{Hash: h}, "tz:1:" + h.String()},
+		{URI{Hash: h, Salt: []byte{0xab, 0xcd}}, "tz:1:" + h.String() + "?salt=abcd"},
+		{URI{Hash: h, Length: 4}, "tz:1:" + h.String() + "?length=4"},
+		{URI{Hash: h, Salt: []byte{1}, Length: 4}, "tz:1:" + h.String() + "?salt=01&length=4"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.str, FormatURI(tc.u))
+
+		u, err := ParseURI(tc.str)
+		require.NoError(t, err)
+		require.Equal(t, tc.u, u)
+	}
+
+	t.Run("parameter order", func(t *testing.T) {
+		u, err := ParseURI("tz:1:" + h.String() + "?length=4&salt=01")
+		require.NoError(t, err)
+		require.Equal(t, URI{Hash: h, Salt: []byte{1}, Length: 4}, u)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		bad := Hash(Sum([]byte("data")))
+		bad[0] ^= 1
+		for _, s := range []string{
+			"",
+			"tz:",
+			"tz:1",
+			"urn:1:" + h.String(),
+			"tz:2:" + h.String(),
+			"tz:x:" + h.String(),
+			"tz:1:" + h.String()[2:],
+			"tz:1:" + h.String() + "00",
+			"tz:1:" + bad.String(),
+			"tz:1:" + h.String() + "?salt=xyz",
+			"tz:1:" + h.String() + "?salt=",
+			"tz:1:" + h.String() + "?length=-1",
+			"tz:1:" + h.String() + "?length=1&length=2",
+			"tz:1:" + h.String() + "?chunk=1",
+			"tz:1:" + h.String() + "?%zz",
+		} {
+			_, err := ParseURI(s)
+			require.Error(t, err, s)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package tz
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+)
+
+// zeroRunSize is the minimal length of a zero run which is processed
+// by multiplying digest state by precomputed matrices instead of
+// processing it bit-by-bit.
+const zeroRunSize = 256
+
+var (
+	zeroOnce sync.Once
+
+	// zeroPowers[i] is a hash of 2^i zero bytes.
+	zeroPowers [64]sl2
+)
+
+func initZeroPowers() {
+	d := new(Digest)
+	d.Reset()
+	_, _ = writeGeneric(d, []byte{0})
+	zeroPowers[0] = d.sl2()
+
+	for i := 1; i < len(zeroPowers); i++ {
+		zeroPowers[i].Mul(&zeroPowers[i-1], &zeroPowers[i-1])
+	}
+}
+
+// zeroHash returns hash of n zero bytes.
+func zeroHash(n uint64) sl2 {
+	zeroOnce.Do(initZeroPowers)
+
+	r := id
+	for i := 0; n != 0; i, n = i+1, n>>1 {
+		if n&1 != 0 {
+			r.Mul(&r, &zeroPowers[i])
+		}
+	}
+	return r
+}
+
+// SumZeros returns Tillich-Zémor checksum of n zero bytes.
+// It is computed in O(log n) time by multiplying precomputed
+// hashes of power-of-two zero runs.
+func SumZeros(n uint64) [Size]byte {
+	c := zeroHash(n)
+	return c.Bytes()
+}
+
+// mulZeros updates digest state as if n zero bytes were written.
+func (d *Digest) mulZeros(n int) {
+	c := d.sl2()
+	z := zeroHash(uint64(n))
+	c.Mul(&c, &z)
+	d.setSL2(&c)
+}
+
+// sl2 returns digest state as a matrix.
+func (d *Digest) sl2() sl2 {
+	return sl2{
+		{d.x[0], d.x[2]},
+		{d.x[1], d.x[3]},
+	}
+}
+
+func (d *Digest) setSL2(c *sl2) {
+	d.x[0], d.x[2] = c[0][0], c[0][1]
+	d.x[1], d.x[3] = c[1][0], c[1][1]
+}
+
+// zeroRun returns position and length of the first run
+// of at least min zero bytes in data. If there is no such run,
+// returned length is 0. Data is scanned word-by-word, so min must be
+// at least 8: shorter runs inside a single word are not detected.
+func zeroRun(data []byte, min int) (int, int) {
+	var i, start = 0, -1
+
+	for ; i+8 <= len(data); i += 8 {
+		w := binary.LittleEndian.Uint64(data[i:])
+		if w == 0 {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		// The current run ends at the first non-zero byte of w.
+		if start >= 0 {
+			if end := i + bits.TrailingZeros64(w)/8; end-start >= min {
+				return start, end - start
+			}
+		}
+
+		// The next run can start only after the last non-zero byte of w.
+		if z := bits.LeadingZeros64(w) / 8; z != 0 {
+			start = i + 8 - z
+		} else {
+			start = -1
+		}
+	}
+
+	for ; i < len(data); i++ {
+		if data[i] == 0 {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= min {
+			return start, i - start
+		}
+		start = -1
+	}
+
+	if start >= 0 && len(data)-start >= min {
+		return start, len(data) - start
+	}
+	return 0, 0
+}
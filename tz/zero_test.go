@@ -0,0 +1,105 @@
+package tz
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func zeroRunNaive(data []byte, min int) (int, int) {
+	for i := 0; i < len(data); i++ {
+		j := i
+		for j < len(data) && data[j] == 0 {
+			j++
+		}
+		if j-i >= min {
+			return i, j - i
+		}
+		i = j
+	}
+	return 0, 0
+}
+
+// sparseBuffer returns random data interleaved with zero runs of different lengths.
+func sparseBuffer(r *rand.Rand) []byte {
+	var data []byte
+	for i := 0; i < 20; i++ {
+		part := make([]byte, r.Intn(600))
+		if r.Intn(2) == 0 {
+			_, _ = r.Read(part)
+		}
+		data = append(data, part...)
+	}
+	return data
+}
+
+func TestZeroRun(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		data := sparseBuffer(r)
+		for _, min := range []int{8, 9, 15, 16, 17, zeroRunSize} {
+			expI, expN := zeroRunNaive(data, min)
+			actI, actN := zeroRun(data, min)
+			require.Equal(t, expN, actN)
+			if expN != 0 {
+				require.Equal(t, expI, actI)
+			}
+		}
+	}
+}
+
+func TestZeroFastPath(t *testing.T) {
+	for _, name := range Backends() {
+		t.Run(name+" digest", func(t *testing.T) {
+			prepareBackend(t, name)
+
+			r := rand.New(rand.NewSource(0))
+			for i := 0; i < 10; i++ {
+				data := sparseBuffer(r)
+
+				expected := NewDigest()
+				_, _ = expected.b.write(expected, data)
+
+				actual := NewDigest()
+				_, _ = actual.Write(data)
+				require.Equal(t, expected.Sum(nil), actual.Sum(nil))
+			}
+		})
+	}
+}
+
+func TestZeroHash(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 255, 256, 1000, 4096} {
+		expected := sl2{}
+		require.NoError(t, expected.UnmarshalBinary(sumNoFastPath(make([]byte, n))))
+
+		actual := zeroHash(uint64(n))
+		require.Equal(t, expected, actual, "n = %d", n)
+	}
+}
+
+func TestSumZeros(t *testing.T) {
+	for _, n := range []int{0, 1, 1000, 1 << 20} {
+		require.Equal(t, Sum(make([]byte, n)), SumZeros(uint64(n)), "n = %d", n)
+	}
+}
+
+// sumNoFastPath computes hash of data using only generic backend.
+func sumNoFastPath(data []byte) []byte {
+	d := NewDigest()
+	_, _ = writeGeneric(d, data)
+	return d.Sum(nil)
+}
+
+func BenchmarkZeros(b *testing.B) {
+	data := make([]byte, benchDataSize)
+
+	b.ReportAllocs()
+	d := New()
+	for i := 0; i < b.N; i++ {
+		d.Reset()
+		_, _ = d.Write(data)
+	}
+	b.SetBytes(int64(len(data)))
+}
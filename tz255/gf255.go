@@ -0,0 +1,117 @@
+package tz255
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// gf255 is an element of GF(2^255) modulo x^255 + x^52 + 1 stored as
+// little-endian 64-bit words. The most significant bit is always zero.
+type gf255 [4]uint64
+
+const (
+	elementSize = 32
+	msb64       = uint64(1) << 63
+
+	// reduction is x^52 + 1, which is equal to x^255 in the field.
+	reduction = uint64(1)<<52 | 1
+)
+
+var one = gf255{1, 0, 0, 0}
+
+func add(a, b, c *gf255) {
+	c[0] = a[0] ^ b[0]
+	c[1] = a[1] ^ b[1]
+	c[2] = a[2] ^ b[2]
+	c[3] = a[3] ^ b[3]
+}
+
+// mulX sets b to a*x.
+func mulX(a, b *gf255) {
+	carry := -(a[3] >> 62 & 1) // bit 254 becomes bit 255
+	b[3] = (a[3]<<1 | a[2]>>63) &^ msb64
+	b[2] = a[2]<<1 | a[1]>>63
+	b[1] = a[1]<<1 | a[0]>>63
+	b[0] = a[0]<<1 ^ carry&reduction
+}
+
+// mul sets c to a*b. It uses left-to-right comb method with 4-bit windows:
+// a is multiplied by every 4-bit polynomial once, then the product is
+// accumulated nibble by nibble of b.
+func mul(a, b, c *gf255) {
+	var (
+		t [16][5]uint64
+		r [8]uint64
+	)
+
+	t[1] = [5]uint64{a[0], a[1], a[2], a[3], 0}
+	for i := 2; i < 16; i += 2 {
+		// t[i] = t[i/2]*x, t[i+1] = t[i] + a.
+		p := &t[i/2]
+		t[i] = [5]uint64{p[0] << 1, p[1]<<1 | p[0]>>63, p[2]<<1 | p[1]>>63, p[3]<<1 | p[2]>>63, p[4]<<1 | p[3]>>63}
+		t[i+1] = [5]uint64{t[i][0] ^ a[0], t[i][1] ^ a[1], t[i][2] ^ a[2], t[i][3] ^ a[3], t[i][4]}
+	}
+
+	for j := 60; j >= 0; j -= 4 {
+		for k := 0; k < 4; k++ {
+			u := &t[b[k]>>uint(j)&15]
+			r[k] ^= u[0]
+			r[k+1] ^= u[1]
+			r[k+2] ^= u[2]
+			r[k+3] ^= u[3]
+			r[k+4] ^= u[4]
+		}
+		if j != 0 {
+			for k := 7; k > 0; k-- {
+				r[k] = r[k]<<4 | r[k-1]>>60
+			}
+			r[0] <<= 4
+		}
+	}
+	reduce(&r, c)
+}
+
+// reduce sets c to r modulo x^255 + x^52 + 1. The degree of r is at most 508.
+func reduce(r *[8]uint64, c *gf255) {
+	// h = r >> 255 has degree at most 253, h*x^255 = h*(x^52 + 1).
+	var h [4]uint64
+	for i := range h {
+		h[i] = r[i+3]>>63 | r[i+4]<<1
+	}
+	r[3] &^= msb64
+
+	// r += h + h*x^52, the latter can exceed 255 bits by at most 51 bits.
+	r[0] ^= h[0] ^ h[0]<<52
+	r[1] ^= h[1] ^ (h[1]<<52 | h[0]>>12)
+	r[2] ^= h[2] ^ (h[2]<<52 | h[1]>>12)
+	r[3] ^= h[3] ^ (h[3]<<52 | h[2]>>12)
+	g := h[3]>>12<<1 | r[3]>>63
+	r[3] &^= msb64
+
+	// g has degree at most 51, so g + g*x^52 fits into the first two words.
+	r[0] ^= g ^ g<<52
+	r[1] ^= g >> 12
+
+	c[0], c[1], c[2], c[3] = r[0], r[1], r[2], r[3]
+}
+
+// bytes returns big-endian representation of the element.
+func (a *gf255) bytes() [elementSize]byte {
+	var buf [elementSize]byte
+	binary.BigEndian.PutUint64(buf[0:], a[3])
+	binary.BigEndian.PutUint64(buf[8:], a[2])
+	binary.BigEndian.PutUint64(buf[16:], a[1])
+	binary.BigEndian.PutUint64(buf[24:], a[0])
+	return buf
+}
+
+func (a *gf255) unmarshal(data []byte) error {
+	a[3] = binary.BigEndian.Uint64(data[0:])
+	a[2] = binary.BigEndian.Uint64(data[8:])
+	a[1] = binary.BigEndian.Uint64(data[16:])
+	a[0] = binary.BigEndian.Uint64(data[24:])
+	if a[3]&msb64 != 0 {
+		return errors.New("MSB must be zero")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package tz255
+
+import "errors"
+
+// sl2 is an element of SL_2(GF(2^255)).
+type sl2 [2][2]gf255
+
+var id = sl2{
+	{one, gf255{}},
+	{gf255{}, one},
+}
+
+// mulSL2 sets c to a*b. c can be the same as a or b.
+func mulSL2(a, b, c *sl2) {
+	var r sl2
+	var t gf255
+
+	mul(&a[0][0], &b[0][0], &r[0][0])
+	mul(&a[0][1], &b[1][0], &t)
+	add(&r[0][0], &t, &r[0][0])
+
+	mul(&a[0][0], &b[0][1], &r[0][1])
+	mul(&a[0][1], &b[1][1], &t)
+	add(&r[0][1], &t, &r[0][1])
+
+	mul(&a[1][0], &b[0][0], &r[1][0])
+	mul(&a[1][1], &b[1][0], &t)
+	add(&r[1][0], &t, &r[1][0])
+
+	mul(&a[1][0], &b[0][1], &r[1][1])
+	mul(&a[1][1], &b[1][1], &t)
+	add(&r[1][1], &t, &r[1][1])
+
+	*c = r
+}
+
+// inv sets b to a^-1. Determinant of a must be 1, so the inverse
+// is the adjugate matrix (signs don't matter in characteristic 2).
+func inv(a, b *sl2) {
+	b[0][0], b[1][1] = a[1][1], a[0][0]
+	b[0][1], b[1][0] = a[0][1], a[1][0]
+}
+
+// det returns the determinant of a.
+func det(a *sl2) gf255 {
+	var x, y gf255
+	mul(&a[0][0], &a[1][1], &x)
+	mul(&a[0][1], &a[1][0], &y)
+	add(&x, &y, &x)
+	return x
+}
+
+func (c *sl2) bytes() [Size]byte {
+	var buf [Size]byte
+	for i := 0; i < 4; i++ {
+		e := c[i/2][i%2].bytes()
+		copy(buf[i*elementSize:], e[:])
+	}
+	return buf
+}
+
+// unmarshal decodes c from data checking that it belongs to SL_2.
+func (c *sl2) unmarshal(data []byte) error {
+	if len(data) != Size {
+		return errors.New("invalid hash length")
+	}
+	for i := 0; i < 4; i++ {
+		if err := c[i/2][i%2].unmarshal(data[i*elementSize:]); err != nil {
+			return err
+		}
+	}
+	if det(c) != one {
+		return errors.New("determinant must be 1")
+	}
+	return nil
+}
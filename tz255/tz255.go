@@ -0,0 +1,183 @@
+// Package tz255 implements Tillich-Zémor hash over GF(2^255) modulo
+// x^255 + x^52 + 1 for deployments which need a bigger security margin
+// than the one of package tz.
+//
+// The construction is the same: the hash of data is the product of
+// generators A = [[x, 1], [1, 0]] and B = [[x, x+1], [1, 1]] chosen by data
+// bits, so hashes are concatenable and subtractable like in package tz,
+// and the API follows the one of package tz. Hashes of the two packages
+// are not interchangeable.
+//
+// This is a reference-speed implementation: data is processed bit by bit in
+// pure Go, so hashing is several times slower than with assembly backends of
+// package tz and is comparable to its generic backend. It suits hashing
+// of metadata and moderate amounts of data rather than bulk storage.
+package tz255
+
+import (
+	"encoding/hex"
+	"hash"
+)
+
+// Size is the size of Tillich-Zémor checksum over GF(2^255) in bytes.
+const Size = 4 * elementSize
+
+// Hash is a checksum as returned by Sum.
+type Hash [Size]byte
+
+// String returns hex-encoded hash.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Validate checks that h can be a hash: all matrix elements belong
+// to GF(2^255) and the determinant is 1.
+func (h Hash) Validate() error {
+	var c sl2
+	return c.unmarshal(h[:])
+}
+
+// Digest computes the checksum incrementally. It implements hash.Hash.
+type Digest struct {
+	// Matrix elements are stored by columns: c00, c10, c01, c11.
+	x [4]gf255
+}
+
+var _ hash.Hash = (*Digest)(nil)
+
+// New returns a new hash.Hash computing the checksum.
+func New() hash.Hash {
+	return NewDigest()
+}
+
+// NewDigest returns a new Digest.
+func NewDigest() *Digest {
+	d := new(Digest)
+	d.Reset()
+	return d
+}
+
+// Sum returns the checksum of data.
+func Sum(data []byte) [Size]byte {
+	var d Digest
+	d.Reset()
+	_, _ = d.Write(data)
+	return d.Checksum()
+}
+
+// Reset implements hash.Hash.
+func (d *Digest) Reset() {
+	d.x = [4]gf255{one, {}, {}, one}
+}
+
+// Write implements hash.Hash. It never returns an error.
+func (d *Digest) Write(data []byte) (int, error) {
+	// Rows of the matrix are multiplied by generators independently.
+	mulRowRight(&d.x[0], &d.x[2], data)
+	mulRowRight(&d.x[1], &d.x[3], data)
+	return len(data), nil
+}
+
+// mulRowRight multiplies matrix row (c0, c1) by A for every zero bit
+// of data and by B for every set bit from the right:
+// c0, c1 = c0*x + c1, c0 + (c0*x + c1) & mask
+// where mask has all bits set for B. Row elements are kept in
+// local variables, masking is used instead of branching because input
+// bits are random and mispredicted branches are expensive.
+func mulRowRight(c0, c1 *gf255, data []byte) {
+	a0, a1, a2, a3 := c0[0], c0[1], c0[2], c0[3]
+	b0, b1, b2, b3 := c1[0], c1[1], c1[2], c1[3]
+	for _, v := range data {
+		for i := 7; i >= 0; i-- {
+			mask := -uint64(v >> uint(i) & 1)
+
+			// t = c0*x + c1
+			carry := -(a3 >> 62 & 1)
+			t3 := (a3<<1|a2>>63)&^msb64 ^ b3
+			t2 := (a2<<1 | a1>>63) ^ b2
+			t1 := (a1<<1 | a0>>63) ^ b1
+			t0 := (a0<<1 ^ carry&reduction) ^ b0
+
+			b0, b1, b2, b3 = a0^t0&mask, a1^t1&mask, a2^t2&mask, a3^t3&mask
+			a0, a1, a2, a3 = t0, t1, t2, t3
+		}
+	}
+	c0[0], c0[1], c0[2], c0[3] = a0, a1, a2, a3
+	c1[0], c1[1], c1[2], c1[3] = b0, b1, b2, b3
+}
+
+// Sum implements hash.Hash.
+func (d *Digest) Sum(in []byte) []byte {
+	h := d.Checksum()
+	return append(in, h[:]...)
+}
+
+// Checksum returns the checksum of the data written so far.
+func (d *Digest) Checksum() [Size]byte {
+	c := sl2{{d.x[0], d.x[2]}, {d.x[1], d.x[3]}}
+	return c.bytes()
+}
+
+// Size implements hash.Hash.
+func (d *Digest) Size() int {
+	return Size
+}
+
+// BlockSize implements hash.Hash.
+func (d *Digest) BlockSize() int {
+	return 1
+}
+
+// Concat performs combining of hashes based on homomorphic property.
+func Concat(hs [][]byte) ([]byte, error) {
+	var r, c sl2
+
+	r = id
+	for i := range hs {
+		if err := c.unmarshal(hs[i]); err != nil {
+			return nil, err
+		}
+		mulSL2(&r, &c, &r)
+	}
+	b := r.bytes()
+	return b[:], nil
+}
+
+// Validate checks if hashes in hs combined are equal to h.
+func Validate(h []byte, hs [][]byte) (bool, error) {
+	c, err := Concat(hs)
+	if err != nil {
+		return false, err
+	}
+	return string(c) == string(h), nil
+}
+
+// SubtractR returns hash a, such that Concat(a, b) == c.
+func SubtractR(c, b []byte) (a []byte, err error) {
+	var r, p sl2
+	if err = r.unmarshal(c); err != nil {
+		return nil, err
+	}
+	if err = p.unmarshal(b); err != nil {
+		return nil, err
+	}
+	inv(&p, &p)
+	mulSL2(&r, &p, &r)
+	res := r.bytes()
+	return res[:], nil
+}
+
+// SubtractL returns hash b, such that Concat(a, b) == c.
+func SubtractL(c, a []byte) (b []byte, err error) {
+	var r, p sl2
+	if err = r.unmarshal(c); err != nil {
+		return nil, err
+	}
+	if err = p.unmarshal(a); err != nil {
+		return nil, err
+	}
+	inv(&p, &p)
+	mulSL2(&p, &r, &r)
+	res := r.bytes()
+	return res[:], nil
+}
@@ -0,0 +1,157 @@
+package tz255
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomElement() gf255 {
+	return gf255{rand.Uint64(), rand.Uint64(), rand.Uint64(), rand.Uint64() >> 1}
+}
+
+// mulSlow multiplies elements bit by bit.
+func mulSlow(a, b *gf255) gf255 {
+	var r gf255
+	d := *a
+	for i := 0; i < 255; i++ {
+		if b[i/64]>>(uint(i)%64)&1 != 0 {
+			add(&r, &d, &r)
+		}
+		mulX(&d, &d)
+	}
+	return r
+}
+
+func TestField(t *testing.T) {
+	// x^255 = x^52 + 1.
+	x := gf255{2}
+	p := one
+	for i := 0; i < 255; i++ {
+		mul(&p, &x, &p)
+	}
+	require.Equal(t, gf255{1<<52 | 1}, p)
+
+	for i := 0; i < 1000; i++ {
+		a, b, c := randomElement(), randomElement(), randomElement()
+
+		var ab, ba gf255
+		mul(&a, &b, &ab)
+		mul(&b, &a, &ba)
+		require.Equal(t, mulSlow(&a, &b), ab)
+		require.Equal(t, ab, ba)
+		require.Zero(t, ab[3]&msb64)
+
+		// a*(b+c) = a*b + a*c
+		var s, l, r gf255
+		add(&b, &c, &s)
+		mul(&a, &s, &l)
+		mul(&a, &c, &r)
+		add(&r, &ab, &r)
+		require.Equal(t, l, r)
+	}
+}
+
+func TestSum(t *testing.T) {
+	all := make([]byte, 512)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	testCases := []struct {
+		data     []byte
+		expected string
+	}{
+		{nil, "0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000001"},
+		{[]byte("abc"), "0000000000000000000000000000000000000000000000000000000001cfbf62" +
+			"000000000000000000000000000000000000000000000000000000000146e6f1" +
+			"0000000000000000000000000000000000000000000000000000000000d91897" +
+			"00000000000000000000000000000000000000000000000000000000008ebe73"},
+		{all, "0de6683d4995832d2c31135ad6de8bd17aad374b991fc138448f7a850cb28324" +
+			"1a32a105e3a9cdf732f2a442ffa8d181588b7348ce86eeaa05294925040a8df2" +
+			"24dee807915b4d56e8cec4adcc75a8b9a244079567e4e0de57a95bb7e65d3db1" +
+			"5f91f6245982a7fb60e799ac0894586e86855dc7ea3c0bd0bfa0d7523b885991"},
+	}
+	for _, tc := range testCases {
+		h := Hash(Sum(tc.data))
+		require.Equal(t, tc.expected, h.String())
+		require.NoError(t, h.Validate())
+
+		d := NewDigest()
+		for i := range tc.data {
+			_, _ = d.Write(tc.data[i : i+1])
+		}
+		require.Equal(t, tc.expected, hex.EncodeToString(d.Sum(nil)))
+	}
+}
+
+func TestHomomorphism(t *testing.T) {
+	data := make([]byte, 300)
+	_, _ = rand.Read(data)
+
+	a, b, c := Sum(data[:100]), Sum(data[100:250]), Sum(data[250:])
+	whole := Sum(data)
+
+	actual, err := Concat([][]byte{a[:], b[:], c[:]})
+	require.NoError(t, err)
+	require.Equal(t, whole[:], actual)
+
+	ok, err := Validate(whole[:], [][]byte{a[:], b[:], c[:]})
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = Validate(whole[:], [][]byte{b[:], a[:], c[:]})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ab, err := Concat([][]byte{a[:], b[:]})
+	require.NoError(t, err)
+	actual, err = SubtractR(whole[:], c[:])
+	require.NoError(t, err)
+	require.Equal(t, ab, actual)
+
+	bc, err := Concat([][]byte{b[:], c[:]})
+	require.NoError(t, err)
+	actual, err = SubtractL(whole[:], a[:])
+	require.NoError(t, err)
+	require.Equal(t, bc, actual)
+
+	empty, err := Concat(nil)
+	require.NoError(t, err)
+	e := Sum(nil)
+	require.Equal(t, e[:], empty)
+
+	_, err = Concat([][]byte{a[:1]})
+	require.Error(t, err)
+	_, err = SubtractL(whole[:], make([]byte, Size))
+	require.Error(t, err)
+	_, err = SubtractR(make([]byte, Size), a[:])
+	require.Error(t, err)
+
+	var bad Hash
+	bad[0] = 0x80
+	require.Error(t, bad.Validate())
+}
+
+func BenchmarkSum(b *testing.B) {
+	data := make([]byte, 64<<10)
+	_, _ = rand.Read(data)
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		_ = Sum(data)
+	}
+}
+
+func BenchmarkConcat(b *testing.B) {
+	x, y := Sum([]byte{1}), Sum([]byte{2})
+	hs := [][]byte{x[:], y[:]}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = Concat(hs)
+	}
+}
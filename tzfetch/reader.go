@@ -0,0 +1,218 @@
+// Package tzfetch downloads large objects from range-addressable storage,
+// like cloud object stores, and verifies them on the fly. Ranges are fetched
+// concurrently and hashed as they arrive, data is returned in order and
+// the combination of range hashes is checked against the object hash,
+// so no separate verification pass is needed.
+package tzfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nspcc-dev/tzhash/piece"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Defaults used by NewReader.
+const (
+	DefaultChunkSize   = 8 << 20
+	DefaultConcurrency = 4
+)
+
+// RangeFunc returns reader of length bytes of the object starting at off,
+// e.g. the body of HTTP response to the range GET request.
+type RangeFunc func(ctx context.Context, off, length int64) (io.ReadCloser, error)
+
+// Option configures Reader.
+type Option func(*config)
+
+type config struct {
+	chunkSize   int64
+	concurrency int
+	manifest    *piece.Manifest
+}
+
+// WithChunkSize sets the size of ranges fetched.
+func WithChunkSize(n int64) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.chunkSize = n
+		}
+	}
+}
+
+// WithConcurrency sets the maximal number of ranges fetched concurrently.
+// It also limits the number of ranges buffered ahead of the reader.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithManifest makes Reader fetch pieces of the manifest and verify every
+// one of them as soon as it arrives, so corruption is detected early.
+// Chunk size option is ignored.
+func WithManifest(m *piece.Manifest) Option {
+	return func(c *config) {
+		c.manifest = m
+	}
+}
+
+// Reader reads the object fetched with RangeFunc. It returns
+// tz.ErrChecksumMismatch instead of io.EOF if the data doesn't match
+// the expected hash. Data is returned before the object is verified
+// as a whole, so it must not be trusted until io.EOF is returned.
+type Reader struct {
+	cancel   context.CancelFunc
+	expected tz.Hash
+	slots    chan chan chunk
+	// stopped is set by dispatch before closing slots if fetching was
+	// cancelled before all chunks were started.
+	stopped error
+
+	cur    []byte
+	hashes [][]byte
+	err    error
+}
+
+type chunk struct {
+	data []byte
+	hash tz.Hash
+	err  error
+}
+
+// NewReader starts fetching the object of the specified size and returns
+// Reader of its data. Close must be called to stop fetching if the reader
+// isn't read until the end.
+func NewReader(ctx context.Context, fetch RangeFunc, size int64, expected tz.Hash, opts ...Option) (*Reader, error) {
+	c := &config{chunkSize: DefaultChunkSize, concurrency: DefaultConcurrency}
+	for _, o := range opts {
+		o(c)
+	}
+	if size < 0 {
+		return nil, errors.New("negative size")
+	}
+	if m := c.manifest; m != nil {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		if m.Size != size || m.Root != expected {
+			return nil, errors.New("manifest doesn't match the object")
+		}
+		c.chunkSize = m.PieceSize
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Reader{
+		cancel:   cancel,
+		expected: expected,
+		slots:    make(chan chan chunk, c.concurrency-1),
+	}
+	go r.dispatch(ctx, fetch, size, c)
+	return r, nil
+}
+
+// dispatch starts fetching of every chunk in order. The number of chunks
+// fetched concurrently or waiting to be read is limited by the slots capacity
+// and the chunk being read.
+func (r *Reader) dispatch(ctx context.Context, fetch RangeFunc, size int64, c *config) {
+	defer close(r.slots)
+
+	for i, off := 0, int64(0); off < size; i, off = i+1, off+c.chunkSize {
+		n := c.chunkSize
+		if n > size-off {
+			n = size - off
+		}
+
+		ch := make(chan chunk, 1)
+		select {
+		case r.slots <- ch:
+		case <-ctx.Done():
+			r.stopped = ctx.Err()
+			return
+		}
+		go func(i int, off, n int64) {
+			ch <- fetchChunk(ctx, fetch, i, off, n, c.manifest)
+		}(i, off, n)
+	}
+}
+
+func fetchChunk(ctx context.Context, fetch RangeFunc, i int, off, n int64, m *piece.Manifest) chunk {
+	rc, err := fetch(ctx, off, n)
+	if err != nil {
+		return chunk{err: fmt.Errorf("fetch range %d-%d: %w", off, off+n-1, err)}
+	}
+	defer rc.Close()
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(rc, data); err != nil {
+		return chunk{err: fmt.Errorf("read range %d-%d: %w", off, off+n-1, err)}
+	}
+	if m != nil {
+		if err := m.VerifyPiece(i, data); err != nil {
+			return chunk{err: err}
+		}
+		return chunk{data: data, hash: m.Pieces[i]}
+	}
+	return chunk{data: data, hash: tz.Sum(data)}
+}
+
+// Read implements io.Reader.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		ch, ok := <-r.slots
+		if !ok {
+			if r.err = r.stopped; r.err == nil {
+				r.err = r.verify()
+			}
+			return 0, r.err
+		}
+		c := <-ch
+		if c.err != nil {
+			r.err = c.err
+			r.cancel()
+			return 0, r.err
+		}
+		r.cur = c.data
+		r.hashes = append(r.hashes, c.hash[:])
+	}
+
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+// verify is called after all chunks were read.
+func (r *Reader) verify() error {
+	r.cancel()
+
+	h := tz.Hash(tz.Sum(nil))
+	if len(r.hashes) != 0 {
+		c, err := tz.Concat(r.hashes)
+		if err != nil {
+			return err
+		}
+		copy(h[:], c)
+	}
+	if h != r.expected {
+		return tz.ErrChecksumMismatch
+	}
+	return io.EOF
+}
+
+// Close stops fetching. It always returns nil.
+func (r *Reader) Close() error {
+	r.cancel()
+	if r.err == nil {
+		r.err = errors.New("reader is closed")
+	}
+	return nil
+}
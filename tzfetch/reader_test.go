@@ -0,0 +1,147 @@
+package tzfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/piece"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+type storage struct {
+	data     []byte
+	inFlight int32
+	peak     int32
+
+	mtx   sync.Mutex
+	calls int
+}
+
+func (s *storage) fetch(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		p := atomic.LoadInt32(&s.peak)
+		if n <= p || atomic.CompareAndSwapInt32(&s.peak, p, n) {
+			break
+		}
+	}
+
+	s.mtx.Lock()
+	s.calls++
+	s.mtx.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(s.data[off : off+length])), nil
+}
+
+func TestReader(t *testing.T) {
+	data := make([]byte, 10*1000+17)
+	_, _ = rand.Read(data)
+	h := tz.Hash(tz.Sum(data))
+
+	for _, concurrency := range []int{1, 3} {
+		s := &storage{data: data}
+		r, err := NewReader(context.Background(), s.fetch, int64(len(data)), h,
+			WithChunkSize(1000), WithConcurrency(concurrency))
+		require.NoError(t, err)
+
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+		require.Equal(t, 11, s.calls)
+		require.LessOrEqual(t, int(s.peak), concurrency)
+		require.NoError(t, r.Close())
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		s := &storage{}
+		r, err := NewReader(context.Background(), s.fetch, 0, tz.Sum(nil))
+		require.NoError(t, err)
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Empty(t, actual)
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		bad := append([]byte{}, data...)
+		bad[5000] ^= 1
+
+		s := &storage{data: bad}
+		r, err := NewReader(context.Background(), s.fetch, int64(len(data)), h, WithChunkSize(1000))
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		require.ErrorIs(t, err, tz.ErrChecksumMismatch)
+	})
+	t.Run("manifest", func(t *testing.T) {
+		m, err := piece.Create(bytes.NewReader(data), 1000)
+		require.NoError(t, err)
+
+		bad := append([]byte{}, data...)
+		bad[5000] ^= 1
+
+		s := &storage{data: bad}
+		r, err := NewReader(context.Background(), s.fetch, int64(len(data)), h, WithManifest(m))
+		require.NoError(t, err)
+		actual, err := io.ReadAll(r)
+
+		var me *piece.MismatchError
+		require.True(t, errors.As(err, &me))
+		require.Equal(t, 5, me.Piece)
+		require.Equal(t, data[:5000], actual)
+
+		_, err = NewReader(context.Background(), s.fetch, int64(len(data))-1, h, WithManifest(m))
+		require.Error(t, err)
+	})
+	t.Run("fetch error", func(t *testing.T) {
+		errFetch := errors.New("fetch failed")
+		fetch := func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+			if off >= 3000 {
+				return nil, errFetch
+			}
+			return io.NopCloser(bytes.NewReader(data[off : off+length])), nil
+		}
+		r, err := NewReader(context.Background(), fetch, int64(len(data)), h, WithChunkSize(1000))
+		require.NoError(t, err)
+		actual, err := io.ReadAll(r)
+		require.ErrorIs(t, err, errFetch)
+		require.Equal(t, data[:3000], actual)
+	})
+	t.Run("short range", func(t *testing.T) {
+		fetch := func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data[off : off+length-1])), nil
+		}
+		r, err := NewReader(context.Background(), fetch, int64(len(data)), h, WithChunkSize(1000))
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		s := &storage{data: data}
+		r, err := NewReader(ctx, s.fetch, int64(len(data)), h, WithChunkSize(1000))
+		require.NoError(t, err)
+
+		_, err = io.ReadFull(r, make([]byte, 10))
+		require.NoError(t, err)
+		cancel()
+		_, err = io.ReadAll(r)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+	t.Run("close", func(t *testing.T) {
+		s := &storage{data: data}
+		r, err := NewReader(context.Background(), s.fetch, int64(len(data)), h, WithChunkSize(1000))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		_, err = r.Read(make([]byte, 1))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,149 @@
+// Package tzgrpc provides helpers verifying Tillich-Zémor checksums of
+// payloads transferred in chunks over gRPC streams, like NeoFS object streams.
+//
+// The package doesn't depend on gRPC: streams are described by the minimal
+// interface satisfied by grpc.ServerStream and grpc.ClientStream, metadata
+// is accepted as map[string][]string, which metadata.MD is. A stream server
+// interceptor verifying incoming chunks can be built as follows:
+//
+//	func interceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+//		md, _ := metadata.FromIncomingContext(ss.Context())
+//		expected, ok, err := tzgrpc.ExpectedFromMetadata(md)
+//		if err != nil {
+//			return status.Error(codes.InvalidArgument, err.Error())
+//		} else if !ok {
+//			return h(srv, ss)
+//		}
+//		return h(srv, &serverStream{ServerStream: ss, s: tzgrpc.NewVerifyingStream(ss, payload, expected)})
+//	}
+//
+// where serverStream overrides RecvMsg with the one of the verifying stream.
+package tzgrpc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// MetadataKey is the metadata key of the expected hex-encoded checksum
+// of all payload chunks of the stream.
+const MetadataKey = "x-tz-hash"
+
+// Stream is the receiving part of a gRPC stream.
+type Stream interface {
+	RecvMsg(m interface{}) error
+}
+
+// PayloadFunc returns payload chunk of the message, nil if there is none.
+type PayloadFunc func(m interface{}) []byte
+
+// Accumulator combines checksums of payload chunks.
+// It is safe for concurrent use.
+type Accumulator struct {
+	mtx sync.Mutex
+	d   *tz.Digest
+	n   int
+}
+
+// NewAccumulator returns empty Accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{d: tz.NewDigest()}
+}
+
+// Add adds the next payload chunk. Chunks are combined in order,
+// so the result is the checksum of the whole payload.
+func (a *Accumulator) Add(chunk []byte) {
+	a.mtx.Lock()
+	_, _ = a.d.Write(chunk)
+	a.n++
+	a.mtx.Unlock()
+}
+
+// Sum returns combined checksum of all chunks added so far.
+func (a *Accumulator) Sum() [tz.Size]byte {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.d.Checksum()
+}
+
+// Chunks returns the number of chunks added so far.
+func (a *Accumulator) Chunks() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.n
+}
+
+// Verify returns tz.ErrChecksumMismatch if the combined checksum differs
+// from expected.
+func (a *Accumulator) Verify(expected [tz.Size]byte) error {
+	if a.Sum() != expected {
+		return fmt.Errorf("%w after %d chunks", tz.ErrChecksumMismatch, a.Chunks())
+	}
+	return nil
+}
+
+// VerifyingStream accumulates payload chunks of received messages
+// and verifies them at the end of the stream.
+type VerifyingStream struct {
+	s        Stream
+	payload  PayloadFunc
+	expected [tz.Size]byte
+	acc      *Accumulator
+}
+
+// NewVerifyingStream returns stream receiving messages from s.
+func NewVerifyingStream(s Stream, payload PayloadFunc, expected [tz.Size]byte) *VerifyingStream {
+	return &VerifyingStream{s: s, payload: payload, expected: expected, acc: NewAccumulator()}
+}
+
+// RecvMsg receives the next message. If the stream has ended and combined
+// checksum of payload chunks differs from the expected one,
+// tz.ErrChecksumMismatch is returned instead of io.EOF.
+func (v *VerifyingStream) RecvMsg(m interface{}) error {
+	err := v.s.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		if verr := v.acc.Verify(v.expected); verr != nil {
+			return verr
+		}
+		return err
+	case err != nil:
+		return err
+	}
+	if chunk := v.payload(m); chunk != nil {
+		v.acc.Add(chunk)
+	}
+	return nil
+}
+
+// Accumulator returns accumulator of received chunks.
+func (v *VerifyingStream) Accumulator() *Accumulator {
+	return v.acc
+}
+
+// ExpectedFromMetadata returns expected checksum from metadata.
+// False is returned if there is no checksum.
+func ExpectedFromMetadata(md map[string][]string) ([tz.Size]byte, bool, error) {
+	var h [tz.Size]byte
+
+	vs := md[MetadataKey]
+	if len(vs) == 0 {
+		return h, false, nil
+	}
+	b, err := hex.DecodeString(vs[0])
+	if err != nil || len(b) != tz.Size {
+		return h, false, fmt.Errorf("invalid %s metadata value: %q", MetadataKey, vs[0])
+	}
+	copy(h[:], b)
+	return h, true, nil
+}
+
+// AppendMetadata appends metadata key and the checksum to kv, so that
+// the result can be passed to metadata.AppendToOutgoingContext.
+func AppendMetadata(kv []string, h [tz.Size]byte) []string {
+	return append(kv, MetadataKey, hex.EncodeToString(h[:]))
+}
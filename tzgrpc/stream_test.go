@@ -0,0 +1,93 @@
+package tzgrpc
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+type chunkMsg struct {
+	header bool
+	data   []byte
+}
+
+// fakeStream returns messages one by one.
+type fakeStream struct {
+	msgs []chunkMsg
+	err  error
+}
+
+func (f *fakeStream) RecvMsg(m interface{}) error {
+	if len(f.msgs) == 0 {
+		return f.err
+	}
+	*m.(*chunkMsg) = f.msgs[0]
+	f.msgs = f.msgs[1:]
+	return nil
+}
+
+func payload(m interface{}) []byte {
+	if msg := m.(*chunkMsg); !msg.header {
+		return msg.data
+	}
+	return nil
+}
+
+func recvAll(s Stream) error {
+	for {
+		var m chunkMsg
+		if err := s.RecvMsg(&m); err != nil {
+			return err
+		}
+	}
+}
+
+func TestVerifyingStream(t *testing.T) {
+	msgs := []chunkMsg{
+		{header: true, data: []byte("header")},
+		{data: []byte("chunk 1")},
+		{data: []byte("chunk 2")},
+	}
+	expected := tz.Sum([]byte("chunk 1chunk 2"))
+
+	t.Run("ok", func(t *testing.T) {
+		v := NewVerifyingStream(&fakeStream{msgs: msgs, err: io.EOF}, payload, expected)
+		require.Equal(t, io.EOF, recvAll(v))
+		require.Equal(t, 2, v.Accumulator().Chunks())
+		require.Equal(t, expected, v.Accumulator().Sum())
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		v := NewVerifyingStream(&fakeStream{msgs: msgs[:2], err: io.EOF}, payload, expected)
+		require.True(t, errors.Is(recvAll(v), tz.ErrChecksumMismatch))
+	})
+	t.Run("error", func(t *testing.T) {
+		streamErr := errors.New("stream error")
+		v := NewVerifyingStream(&fakeStream{msgs: msgs, err: streamErr}, payload, expected)
+		require.Equal(t, streamErr, recvAll(v))
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	h := tz.Sum([]byte("data"))
+
+	kv := AppendMetadata([]string{"k", "v"}, h)
+	md := map[string][]string{}
+	for i := 0; i < len(kv); i += 2 {
+		md[kv[i]] = append(md[kv[i]], kv[i+1])
+	}
+
+	actual, ok, err := ExpectedFromMetadata(md)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, h, actual)
+
+	_, ok, err = ExpectedFromMetadata(map[string][]string{})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = ExpectedFromMetadata(map[string][]string{MetadataKey: {"xyz"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package tzhttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// ErrMissingChecksum is returned by response body at EOF if the checksum
+// is required but the server hasn't announced it.
+var ErrMissingChecksum = errors.New("missing checksum")
+
+// Transport returns http.RoundTripper using base (http.DefaultTransport if nil)
+// which verifies response bodies against checksum announced by the server
+// in a header or a trailer. Body returns tz.ErrChecksumMismatch instead
+// of io.EOF if the data doesn't match, so that io.ReadAll and similar
+// functions fail. Responses without checksum aren't verified unless
+// WithRequired is used.
+func Transport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, config: newConfig(opts)}
+}
+
+type transport struct {
+	base http.RoundTripper
+	config
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody || req.Method == http.MethodHead {
+		return resp, err
+	}
+	resp.Body = &verifyingBody{
+		ReadCloser: resp.Body,
+		resp:       resp,
+		w:          tz.NewHashingWriter(io.Discard),
+		config:     &t.config,
+	}
+	return resp, nil
+}
+
+// verifyingBody hashes response body and checks it at EOF.
+type verifyingBody struct {
+	io.ReadCloser
+	resp *http.Response
+	w    *tz.HashingWriter
+	*config
+}
+
+func (b *verifyingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	_, _ = b.w.Write(p[:n])
+	if err == io.EOF {
+		if verr := b.verify(); verr != nil {
+			err = verr
+		}
+	}
+	return n, err
+}
+
+// verify checks body checksum, trailers are available only after EOF.
+func (b *verifyingBody) verify() error {
+	v := b.resp.Header.Get(b.field)
+	if v == "" {
+		v = b.resp.Trailer.Get(b.field)
+	}
+	if v == "" {
+		if b.required {
+			return ErrMissingChecksum
+		}
+		return nil
+	}
+
+	h, ok := parseHash(v)
+	if !ok {
+		return fmt.Errorf("invalid %s value: %q", b.field, v)
+	}
+	if h != b.w.Sum() {
+		return tz.ErrChecksumMismatch
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package tzhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, h http.Handler, opts ...Option) ([]byte, error) {
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c := &http.Client{Transport: Transport(nil, opts...)}
+	resp, err := c.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func TestTransport(t *testing.T) {
+	body := []byte("response body")
+
+	t.Run("trailer", func(t *testing.T) {
+		data, err := get(t, Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(body)
+		})), WithRequired())
+		require.NoError(t, err)
+		require.Equal(t, body, data)
+	})
+	t.Run("header", func(t *testing.T) {
+		_, err := get(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Custom", hexSum(body))
+			_, _ = w.Write(body)
+		}), WithField("x-custom"))
+		require.NoError(t, err)
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := get(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(DefaultField, hexSum(body[1:]))
+			_, _ = w.Write(body)
+		}))
+		require.True(t, errors.Is(err, tz.ErrChecksumMismatch))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := get(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(DefaultField, "xyz")
+			_, _ = w.Write(body)
+		}))
+		require.Error(t, err)
+	})
+	t.Run("missing", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(body)
+		})
+
+		data, err := get(t, h)
+		require.NoError(t, err)
+		require.Equal(t, body, data)
+
+		_, err = get(t, h, WithRequired())
+		require.True(t, errors.Is(err, ErrMissingChecksum))
+	})
+}
@@ -0,0 +1,128 @@
+// Package tzhttp provides net/http helpers transferring and verifying
+// Tillich-Zémor checksums of message bodies.
+//
+// Checksums are hex-encoded and transferred in the X-Tz-Hash field
+// by default, in a header for request bodies and in a trailer
+// for response bodies, so that they can be computed while streaming.
+package tzhttp
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// DefaultField is the default name of the checksum header or trailer.
+const DefaultField = "X-Tz-Hash"
+
+// Option configures Handler and Transport.
+type Option func(*config)
+
+type config struct {
+	field    string
+	required bool
+}
+
+// WithField sets the name of the checksum header or trailer.
+func WithField(name string) Option {
+	return func(c *config) {
+		c.field = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithRequired makes Transport fail if the server hasn't announced
+// the checksum.
+func WithRequired() Option {
+	return func(c *config) {
+		c.required = true
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{field: DefaultField}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// Handler returns handler calling next which emits checksum of the response
+// body in a trailer. If request has checksum header, its body returns
+// tz.ErrChecksumMismatch instead of io.EOF if the data doesn't match,
+// so next must read the body to the end to verify it. Requests with
+// malformed checksum header are rejected with 400 status.
+//
+// Trailers are only sent with chunked encoding, so next must not set
+// Content-Length header.
+func Handler(next http.Handler, opts ...Option) http.Handler {
+	c := newConfig(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(c.field); v != "" {
+			h, ok := parseHash(v)
+			if !ok {
+				http.Error(w, "invalid "+c.field+" header", http.StatusBadRequest)
+				return
+			}
+			r.Body = &readCloser{Reader: tz.NewVerifyingReader(r.Body, h), Closer: r.Body}
+		}
+
+		hw := &hashingResponseWriter{ResponseWriter: w, field: c.field, w: tz.NewHashingWriter(w)}
+		next.ServeHTTP(hw, r)
+		hw.writeHeader(http.StatusOK)
+		h := hw.w.Sum()
+		w.Header().Set(c.field, hex.EncodeToString(h[:]))
+	})
+}
+
+// hashingResponseWriter hashes response body and declares the trailer.
+type hashingResponseWriter struct {
+	http.ResponseWriter
+	field       string
+	w           *tz.HashingWriter
+	wroteHeader bool
+}
+
+func (h *hashingResponseWriter) writeHeader(code int) {
+	if h.wroteHeader {
+		return
+	}
+	h.wroteHeader = true
+	h.ResponseWriter.Header().Add("Trailer", h.field)
+	h.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (h *hashingResponseWriter) WriteHeader(code int) {
+	h.writeHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (h *hashingResponseWriter) Write(p []byte) (int, error) {
+	h.writeHeader(http.StatusOK)
+	return h.w.Write(p)
+}
+
+// Flush implements http.Flusher.
+func (h *hashingResponseWriter) Flush() {
+	h.writeHeader(http.StatusOK)
+	if f, ok := h.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// readCloser combines reader wrapping the body with the original Close.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func parseHash(s string) (h [tz.Size]byte, ok bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != tz.Size {
+		return h, false
+	}
+	copy(h[:], b)
+	return h, true
+}
@@ -0,0 +1,89 @@
+package tzhttp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func hexSum(data []byte) string {
+	h := tz.Sum(data)
+	return hex.EncodeToString(h[:])
+}
+
+func TestHandlerTrailer(t *testing.T) {
+	body := []byte("response body")
+
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body[:5])
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(body[5:])
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Trailer, DefaultField)
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, data)
+	require.Equal(t, hexSum(body), resp.Trailer.Get(DefaultField))
+}
+
+func TestHandlerEmpty(t *testing.T) {
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), WithField("x-custom")))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, hexSum(nil), resp.Trailer.Get("X-Custom"))
+}
+
+func TestHandlerRequest(t *testing.T) {
+	body := []byte("request body")
+
+	var readErr error
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	})))
+	defer srv.Close()
+
+	post := func(hash string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(DefaultField, hash)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, post(hexSum(body)))
+	require.NoError(t, readErr)
+
+	require.Equal(t, http.StatusOK, post(hexSum(body[1:])))
+	require.True(t, errors.Is(readErr, tz.ErrChecksumMismatch))
+
+	readErr = nil
+	require.Equal(t, http.StatusBadRequest, post("xyz"))
+	require.NoError(t, readErr)
+}
@@ -0,0 +1,82 @@
+// Package tzneofs converts Tillich-Zémor hashes to and from NeoFS API
+// checksums (neo.fs.v2.refs.Checksum of TZ type).
+//
+// The package doesn't depend on NeoFS API libraries. Fields of checksum
+// structures can be converted with FromChecksum and ToChecksum:
+//
+//	h, err := tzneofs.FromChecksum(int32(cs.GetType()), cs.GetSum())
+//
+// and the protobuf representation with MarshalChecksum and UnmarshalChecksum.
+package tzneofs
+
+import (
+	"fmt"
+
+	"github.com/nspcc-dev/tzhash/internal/protowire"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// ChecksumTypeTZ is the value of neo.fs.v2.refs.ChecksumType for Tillich-Zémor hashes.
+const ChecksumTypeTZ = 1
+
+// Field numbers of neo.fs.v2.refs.Checksum message.
+const (
+	fieldType = 1
+	fieldSum  = 2
+)
+
+// FromChecksum returns hash from checksum type and value. An error is returned
+// if checksum is not of TZ type or the value is not a valid hash.
+func FromChecksum(typ int32, sum []byte) (tz.Hash, error) {
+	var h tz.Hash
+
+	if typ != ChecksumTypeTZ {
+		return h, fmt.Errorf("unexpected checksum type %d", typ)
+	}
+	if len(sum) != tz.Size {
+		return h, fmt.Errorf("invalid checksum length: expected %d, got %d", tz.Size, len(sum))
+	}
+	copy(h[:], sum)
+	if err := h.Validate(); err != nil {
+		return h, fmt.Errorf("invalid checksum: %w", err)
+	}
+	return h, nil
+}
+
+// ToChecksum returns checksum type and value for the hash.
+func ToChecksum(h tz.Hash) (typ int32, sum []byte) {
+	return ChecksumTypeTZ, append([]byte(nil), h[:]...)
+}
+
+// MarshalChecksum returns protobuf encoding of neo.fs.v2.refs.Checksum
+// with the hash, which is the same as the one of NeoFS API libraries.
+func MarshalChecksum(h tz.Hash) []byte {
+	b := make([]byte, 0, 4+tz.Size)
+	b = protowire.AppendVarintField(b, fieldType, ChecksumTypeTZ)
+	return protowire.AppendBytesField(b, fieldSum, h[:])
+}
+
+// UnmarshalChecksum decodes protobuf-encoded neo.fs.v2.refs.Checksum
+// and validates it like FromChecksum does. Unknown fields are skipped.
+func UnmarshalChecksum(b []byte) (tz.Hash, error) {
+	var (
+		typ uint64
+		sum []byte
+	)
+	err := protowire.Range(b, func(f protowire.Field) error {
+		switch {
+		case f.Num == fieldType && f.Wire == protowire.Varint:
+			typ = f.Value
+		case f.Num == fieldSum && f.Wire == protowire.Bytes:
+			sum = f.Data
+		}
+		return nil
+	})
+	if err != nil {
+		return tz.Hash{}, err
+	}
+	if typ > 1<<31-1 {
+		return tz.Hash{}, fmt.Errorf("unexpected checksum type %d", typ)
+	}
+	return FromChecksum(int32(typ), sum)
+}
@@ -0,0 +1,74 @@
+package tzneofs
+
+import (
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/internal/protowire"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte("data")))
+
+	typ, sum := ToChecksum(h)
+	require.EqualValues(t, ChecksumTypeTZ, typ)
+	require.Equal(t, h[:], sum)
+
+	actual, err := FromChecksum(typ, sum)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	_, err = FromChecksum(2, sum) // SHA256
+	require.Error(t, err)
+
+	_, err = FromChecksum(typ, sum[1:])
+	require.Error(t, err)
+
+	sum[3] ^= 1
+	_, err = FromChecksum(typ, sum)
+	require.Error(t, err)
+}
+
+func TestMarshalChecksum(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte("data")))
+
+	b := MarshalChecksum(h)
+	require.Equal(t, []byte{0x08, 0x01, 0x12, 0x40}, b[:4])
+
+	actual, err := UnmarshalChecksum(b)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	t.Run("unknown fields", func(t *testing.T) {
+		var msg []byte
+		msg = append(msg, 3<<3|protowire.Varint, 0x96, 0x01)
+		msg = append(msg, 4<<3|protowire.Fixed32, 1, 2, 3, 4)
+		msg = append(msg, fieldSum<<3|protowire.Bytes, tz.Size) // overwritten by the next one
+		msg = append(msg, make([]byte, tz.Size)...)
+		msg = append(msg, b...)
+		msg = append(msg, 5<<3|protowire.Fixed64, 1, 2, 3, 4, 5, 6, 7, 8)
+		msg = append(msg, 6<<3|protowire.Bytes, 2, 0xff, 0xff)
+
+		actual, err := UnmarshalChecksum(msg)
+		require.NoError(t, err)
+		require.Equal(t, h, actual)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, b := range [][]byte{
+			nil,
+			b[:len(b)-1],
+			b[2:], // no type
+			{0x08},
+			{0x08, 0x01, 0x12},
+			{0x08, 0x01, 0x12, 0x41},
+			{0x0b}, // group wire type
+			append([]byte{0x08, 0x02}, b[2:]...),
+			append(b[:len(b):len(b)], 0x25, 0x01),
+			append(b[:len(b):len(b)], 0x29, 0x01),
+		} {
+			_, err := UnmarshalChecksum(b)
+			require.Error(t, err, "%x", b)
+		}
+	})
+}
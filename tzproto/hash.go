@@ -0,0 +1,124 @@
+// Package tzproto implements tzhash.v1.Hash protobuf message defined
+// in hash.proto, the canonical wire representation of Tillich-Zémor hashes
+// exchanged between services. Encoding is compatible with the code
+// generated from hash.proto, but doesn't depend on protobuf libraries.
+package tzproto
+
+import (
+	"fmt"
+
+	"github.com/nspcc-dev/tzhash/internal/protowire"
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+// Algorithm is the hash algorithm.
+type Algorithm uint32
+
+// Supported algorithms.
+const (
+	AlgorithmUnspecified  Algorithm = 0
+	AlgorithmTillichZemor Algorithm = 1
+)
+
+// Version is the current version of Tillich-Zémor algorithm parameters.
+const Version = 1
+
+// Field numbers of tzhash.v1.Hash message.
+const (
+	fieldHash      = 1
+	fieldAlgorithm = 2
+	fieldVersion   = 3
+	fieldSalt      = 4
+	fieldChunkSize = 5
+)
+
+// Hash is tzhash.v1.Hash message.
+type Hash struct {
+	Hash      []byte
+	Algorithm Algorithm
+	Version   uint32
+	Salt      []byte
+	ChunkSize uint64
+}
+
+// New returns message with Tillich-Zémor hash of the current version.
+func New(h tz.Hash) *Hash {
+	return &Hash{
+		Hash:      append([]byte(nil), h[:]...),
+		Algorithm: AlgorithmTillichZemor,
+		Version:   Version,
+	}
+}
+
+// Marshal returns protobuf encoding of the message. As in proto3,
+// fields with zero values are omitted.
+func (m *Hash) Marshal() []byte {
+	b := make([]byte, 0, 8+len(m.Hash)+len(m.Salt)+2*10)
+	if len(m.Hash) != 0 {
+		b = protowire.AppendBytesField(b, fieldHash, m.Hash)
+	}
+	if m.Algorithm != 0 {
+		b = protowire.AppendVarintField(b, fieldAlgorithm, uint64(m.Algorithm))
+	}
+	if m.Version != 0 {
+		b = protowire.AppendVarintField(b, fieldVersion, uint64(m.Version))
+	}
+	if len(m.Salt) != 0 {
+		b = protowire.AppendBytesField(b, fieldSalt, m.Salt)
+	}
+	if m.ChunkSize != 0 {
+		b = protowire.AppendVarintField(b, fieldChunkSize, m.ChunkSize)
+	}
+	return b
+}
+
+// Unmarshal decodes protobuf-encoded message. Unknown fields are skipped.
+// Decoded byte fields are copied.
+func (m *Hash) Unmarshal(b []byte) error {
+	*m = Hash{}
+	return protowire.Range(b, func(f protowire.Field) error {
+		switch {
+		case f.Num == fieldHash && f.Wire == protowire.Bytes:
+			m.Hash = append([]byte(nil), f.Data...)
+		case f.Num == fieldAlgorithm && f.Wire == protowire.Varint:
+			m.Algorithm = Algorithm(f.Value) // truncated as in protobuf
+		case f.Num == fieldVersion && f.Wire == protowire.Varint:
+			m.Version = uint32(f.Value)
+		case f.Num == fieldSalt && f.Wire == protowire.Bytes:
+			m.Salt = append([]byte(nil), f.Data...)
+		case f.Num == fieldChunkSize && f.Wire == protowire.Varint:
+			m.ChunkSize = f.Value
+		case f.Num >= fieldHash && f.Num <= fieldChunkSize:
+			return fmt.Errorf("invalid wire type %d of field %d", f.Wire, f.Num)
+		}
+		return nil
+	})
+}
+
+// Validate checks that the message contains valid Tillich-Zémor hash
+// of the supported version.
+func (m *Hash) Validate() error {
+	if m.Algorithm != AlgorithmTillichZemor {
+		return fmt.Errorf("unsupported algorithm %d", m.Algorithm)
+	}
+	if m.Version != Version {
+		return fmt.Errorf("unsupported version %d", m.Version)
+	}
+	if len(m.Hash) != tz.Size {
+		return fmt.Errorf("invalid hash length: expected %d, got %d", tz.Size, len(m.Hash))
+	}
+
+	var h tz.Hash
+	copy(h[:], m.Hash)
+	return h.Validate()
+}
+
+// TZ returns validated Tillich-Zémor hash from the message.
+func (m *Hash) TZ() (tz.Hash, error) {
+	var h tz.Hash
+	if err := m.Validate(); err != nil {
+		return h, err
+	}
+	copy(h[:], m.Hash)
+	return h, nil
+}
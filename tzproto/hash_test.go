@@ -0,0 +1,69 @@
+package tzproto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte("data")))
+
+	m := New(h)
+	m.Salt = []byte{1, 2, 3}
+	m.ChunkSize = 1 << 20
+
+	b := m.Marshal()
+	// Fields 2-5 after the hash, as encoded by protoc-generated code.
+	require.Equal(t, "0a40", hex.EncodeToString(b[:2]))
+	require.Equal(t, "100118012203010203"+"28808040", hex.EncodeToString(b[2+tz.Size:]))
+
+	var actual Hash
+	require.NoError(t, actual.Unmarshal(b))
+	require.Equal(t, *m, actual)
+
+	th, err := actual.TZ()
+	require.NoError(t, err)
+	require.Equal(t, h, th)
+
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, new(Hash).Marshal())
+
+		var m Hash
+		require.NoError(t, m.Unmarshal(nil))
+		require.Error(t, m.Validate())
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		var actual Hash
+		require.NoError(t, actual.Unmarshal(append(b[:len(b):len(b)], 0x30, 0x01)))
+		require.Equal(t, *m, actual)
+	})
+	t.Run("invalid wire type", func(t *testing.T) {
+		var actual Hash
+		require.Error(t, actual.Unmarshal([]byte{0x08, 0x01}))
+	})
+}
+
+func TestHashValidate(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte("data")))
+	require.NoError(t, New(h).Validate())
+
+	m := New(h)
+	m.Algorithm = AlgorithmUnspecified
+	require.Error(t, m.Validate())
+
+	m = New(h)
+	m.Version = 2
+	require.Error(t, m.Validate())
+
+	m = New(h)
+	m.Hash = m.Hash[1:]
+	require.Error(t, m.Validate())
+
+	m = New(h)
+	m.Hash[5] ^= 1
+	_, err := m.TZ()
+	require.Error(t, err)
+}
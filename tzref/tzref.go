@@ -0,0 +1,203 @@
+// Package tzref is a reference implementation of the Tillich-Zémor hash
+// of package tz.
+//
+// It is deliberately simple and slow: field elements are big.Int
+// polynomials, multiplication is done bit by bit and data is hashed one
+// matrix product per bit, exactly as the construction is defined. It is meant
+// for differential testing of optimized backends and ports to other
+// languages, never for hashing real data. Results are byte-for-byte
+// compatible with package tz.
+package tzref
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// Degree is the degree of the field.
+	Degree = 127
+	// ElementSize is the size of encoded field element in bytes.
+	ElementSize = 16
+	// Size is the size of encoded hash in bytes.
+	Size = 4 * ElementSize
+)
+
+// Modulus returns the reduction polynomial x^127 + x^63 + 1, bit i of
+// the result is the coefficient of x^i.
+func Modulus() *big.Int {
+	p := new(big.Int).SetBit(new(big.Int), Degree, 1)
+	p.SetBit(p, 63, 1)
+	return p.SetBit(p, 0, 1)
+}
+
+var modulus = Modulus()
+
+// Add returns a + b.
+func Add(a, b *big.Int) *big.Int {
+	return new(big.Int).Xor(a, b)
+}
+
+// MulX returns a*x.
+func MulX(a *big.Int) *big.Int {
+	r := new(big.Int).Lsh(a, 1)
+	if r.Bit(Degree) == 1 {
+		r.Xor(r, modulus)
+	}
+	return r
+}
+
+// Mul returns a*b computed with Horner's scheme over bits of b:
+// r = r*x + a for every set bit and r = r*x for every zero bit.
+func Mul(a, b *big.Int) *big.Int {
+	r := new(big.Int)
+	for i := b.BitLen() - 1; i >= 0; i-- {
+		r = MulX(r)
+		if b.Bit(i) == 1 {
+			r = Add(r, a)
+		}
+	}
+	return r
+}
+
+// Inv returns a^-1 computed as a^(2^127 - 2). Inverse of zero is zero.
+func Inv(a *big.Int) *big.Int {
+	r := big.NewInt(1)
+	for i := 1; i < Degree; i++ {
+		a = Mul(a, a)
+		r = Mul(r, a)
+	}
+	return r
+}
+
+// Matrix is a 2x2 matrix over the field.
+type Matrix [2][2]*big.Int
+
+// Identity returns the identity matrix, the hash of empty data.
+func Identity() Matrix {
+	return Matrix{{big.NewInt(1), big.NewInt(0)}, {big.NewInt(0), big.NewInt(1)}}
+}
+
+// A returns generator [[x, 1], [1, 0]] corresponding to zero bits.
+func A() Matrix {
+	return Matrix{{big.NewInt(2), big.NewInt(1)}, {big.NewInt(1), big.NewInt(0)}}
+}
+
+// B returns generator [[x, x+1], [1, 1]] corresponding to set bits.
+func B() Matrix {
+	return Matrix{{big.NewInt(2), big.NewInt(3)}, {big.NewInt(1), big.NewInt(1)}}
+}
+
+// Mul returns m*n.
+func (m Matrix) Mul(n Matrix) Matrix {
+	var r Matrix
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			r[i][j] = Add(Mul(m[i][0], n[0][j]), Mul(m[i][1], n[1][j]))
+		}
+	}
+	return r
+}
+
+// Det returns the determinant of m, which is 1 for every hash.
+func (m Matrix) Det() *big.Int {
+	return Add(Mul(m[0][0], m[1][1]), Mul(m[0][1], m[1][0]))
+}
+
+// Inv returns m^-1. The determinant of m must be non-zero.
+func (m Matrix) Inv() Matrix {
+	d := Inv(m.Det())
+	return Matrix{
+		{Mul(m[1][1], d), Mul(m[0][1], d)},
+		{Mul(m[1][0], d), Mul(m[0][0], d)},
+	}
+}
+
+// Bytes returns matrix encoded like hashes of package tz: elements
+// m00, m01, m10, m11, every one as 16 big-endian bytes.
+func (m Matrix) Bytes() []byte {
+	buf := make([]byte, Size)
+	for i := 0; i < 4; i++ {
+		m[i/2][i%2].FillBytes(buf[i*ElementSize : (i+1)*ElementSize])
+	}
+	return buf
+}
+
+// Parse decodes matrix encoded with Bytes and checks that it is a hash,
+// i.e. that its elements belong to the field and the determinant is 1.
+func Parse(data []byte) (Matrix, error) {
+	var m Matrix
+	if len(data) != Size {
+		return m, errors.New("hash must be 64 bytes long")
+	}
+	for i := 0; i < 4; i++ {
+		e := new(big.Int).SetBytes(data[i*ElementSize : (i+1)*ElementSize])
+		if e.BitLen() > Degree {
+			return m, errors.New("element doesn't belong to the field")
+		}
+		m[i/2][i%2] = e
+	}
+	if m.Det().Cmp(big.NewInt(1)) != 0 {
+		return m, errors.New("determinant must be 1")
+	}
+	return m, nil
+}
+
+// Sum returns the hash of data: the product of A for every zero bit and
+// B for every set bit, most significant bit of every byte first.
+func Sum(data []byte) []byte {
+	var (
+		a = A()
+		b = B()
+		r = Identity()
+	)
+	for _, v := range data {
+		for i := 7; i >= 0; i-- {
+			if v>>uint(i)&1 == 0 {
+				r = r.Mul(a)
+			} else {
+				r = r.Mul(b)
+			}
+		}
+	}
+	return r.Bytes()
+}
+
+// Concat returns the hash of concatenated data given hashes of its parts.
+func Concat(hs [][]byte) ([]byte, error) {
+	r := Identity()
+	for i := range hs {
+		m, err := Parse(hs[i])
+		if err != nil {
+			return nil, err
+		}
+		r = r.Mul(m)
+	}
+	return r.Bytes(), nil
+}
+
+// SubtractR returns hash a, such that Concat(a, b) == c.
+func SubtractR(c, b []byte) ([]byte, error) {
+	mc, err := Parse(c)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return mc.Mul(mb.Inv()).Bytes(), nil
+}
+
+// SubtractL returns hash b, such that Concat(a, b) == c.
+func SubtractL(c, a []byte) ([]byte, error) {
+	mc, err := Parse(c)
+	if err != nil {
+		return nil, err
+	}
+	ma, err := Parse(a)
+	if err != nil {
+		return nil, err
+	}
+	return ma.Inv().Mul(mc).Bytes(), nil
+}
@@ -0,0 +1,83 @@
+package tzref
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/gf127"
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func toBig(a *gf127.GF127) *big.Int {
+	b := a.Bytes()
+	return new(big.Int).SetBytes(b[:])
+}
+
+func TestField(t *testing.T) {
+	// x^127 = x^63 + 1.
+	r := big.NewInt(1)
+	for i := 0; i < Degree; i++ {
+		r = MulX(r)
+	}
+	require.Equal(t, 0, r.Cmp(new(big.Int).SetBit(big.NewInt(1), 63, 1)))
+
+	for i := 0; i < 200; i++ {
+		a, b := gf127.Random(), gf127.Random()
+
+		var c gf127.GF127
+		gf127.Mul(a, b, &c)
+		require.Equal(t, 0, toBig(&c).Cmp(Mul(toBig(a), toBig(b))))
+
+		gf127.Inv(a, &c)
+		require.Equal(t, 0, toBig(&c).Cmp(Inv(toBig(a))))
+	}
+}
+
+func TestSum(t *testing.T) {
+	require.Equal(t, Identity().Bytes(), Sum(nil))
+
+	for _, n := range []int{1, 2, 17, 64, 129} {
+		data := make([]byte, n)
+		rand.Read(data)
+
+		h := tz.Sum(data)
+		require.Equal(t, h[:], Sum(data), "length %d", n)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	data := make([]byte, 48)
+	rand.Read(data)
+
+	a, b, c := Sum(data[:10]), Sum(data[10:30]), Sum(data[30:])
+	h, err := Concat([][]byte{a, b, c})
+	require.NoError(t, err)
+	require.Equal(t, Sum(data), h)
+
+	ab, err := SubtractR(h, c)
+	require.NoError(t, err)
+	expected, err := Concat([][]byte{a, b})
+	require.NoError(t, err)
+	require.Equal(t, expected, ab)
+
+	bc, err := SubtractL(h, a)
+	require.NoError(t, err)
+	expected, err = Concat([][]byte{b, c})
+	require.NoError(t, err)
+	require.Equal(t, expected, bc)
+
+	_, err = Concat([][]byte{a[1:]})
+	require.Error(t, err)
+
+	bad := append([]byte(nil), a...)
+	bad[0] |= 0x80
+	_, err = Concat([][]byte{bad})
+	require.Error(t, err)
+
+	bad = append([]byte(nil), a...)
+	bad[Size-1] ^= 1
+	_, err = Concat([][]byte{bad})
+	require.Error(t, err)
+}
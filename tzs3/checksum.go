@@ -0,0 +1,121 @@
+// Package tzs3 adapts Tillich-Zémor hash to the checksum conventions of S3
+// API, so that S3-compatible gateways (like MinIO-based ones) can offer it
+// as an additional checksum algorithm along with CRC32 or SHA-256.
+//
+// Checksums are Base64-encoded and transferred in the x-amz-checksum-tz
+// header, or in the trailer of aws-chunked bodies for streaming uploads.
+// Composite checksums of multipart uploads are formed as "<checksum>-<parts>",
+// but unlike other algorithms, the combined value of TZ is the checksum of the
+// whole object, so it can be verified against the full object checksum.
+package tzs3
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nspcc-dev/tzhash/tz"
+)
+
+const (
+	// Algorithm is the value of x-amz-checksum-algorithm and
+	// x-amz-sdk-checksum-algorithm headers.
+	Algorithm = "TZ"
+	// Header is the name of the checksum header and trailer.
+	Header = "x-amz-checksum-tz"
+)
+
+// New returns hash.Hash computing streaming checksum.
+func New() hash.Hash {
+	return tz.New()
+}
+
+// Encode returns checksum value of h in the S3 format.
+func Encode(h tz.Hash) string {
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// Decode parses checksum value in the S3 format.
+func Decode(s string) (tz.Hash, error) {
+	var h tz.Hash
+
+	b, err := base64.StdEncoding.Strict().DecodeString(s)
+	if err != nil {
+		return h, fmt.Errorf("invalid checksum: %w", err)
+	}
+	if len(b) != tz.Size {
+		return h, fmt.Errorf("invalid checksum length: expected %d, got %d", tz.Size, len(b))
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// Trailer returns trailer line of aws-chunked body with the checksum,
+// without the terminating CRLF.
+func Trailer(h tz.Hash) string {
+	return Header + ":" + Encode(h)
+}
+
+// ParseTrailer parses trailer line returned with Trailer. The trailer name
+// is case-insensitive and optional whitespace around the value is ignored.
+func ParseTrailer(line string) (tz.Hash, error) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 || !strings.EqualFold(strings.TrimSpace(line[:i]), Header) {
+		return tz.Hash{}, fmt.Errorf("not a %s trailer", Header)
+	}
+	return Decode(strings.TrimSpace(line[i+1:]))
+}
+
+// Composite returns composite checksum of the multipart upload
+// with the given part checksums.
+func Composite(parts []tz.Hash) (string, error) {
+	if len(parts) == 0 {
+		return "", errors.New("no parts")
+	}
+
+	hs := make([][]byte, len(parts))
+	for i := range parts {
+		hs[i] = parts[i][:]
+	}
+	c, err := tz.Concat(hs)
+	if err != nil {
+		return "", err
+	}
+
+	var h tz.Hash
+	copy(h[:], c)
+	return Encode(h) + "-" + strconv.Itoa(len(parts)), nil
+}
+
+// ParseComposite parses composite checksum returning the combined hash,
+// which is the hash of the whole object, and the number of parts.
+func ParseComposite(s string) (tz.Hash, int, error) {
+	i := strings.LastIndexByte(s, '-')
+	if i < 0 {
+		return tz.Hash{}, 0, errors.New("composite checksum must have parts count")
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil || n < 1 {
+		return tz.Hash{}, 0, fmt.Errorf("invalid parts count %q", s[i+1:])
+	}
+	h, err := Decode(s[:i])
+	if err != nil {
+		return tz.Hash{}, 0, err
+	}
+	return h, n, nil
+}
+
+// NewReader returns reader verifying that the data read from r matches
+// the checksum in the S3 format. tz.ErrChecksumMismatch is returned instead
+// of io.EOF if it doesn't.
+func NewReader(r io.Reader, checksum string) (io.Reader, error) {
+	h, err := Decode(checksum)
+	if err != nil {
+		return nil, err
+	}
+	return tz.NewVerifyingReader(r, h), nil
+}
@@ -0,0 +1,93 @@
+package tzs3
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/tzhash/tz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	data := []byte("object data")
+	h := tz.Hash(tz.Sum(data))
+
+	s := Encode(h)
+	require.Equal(t, base64.StdEncoding.EncodeToString(h[:]), s)
+
+	actual, err := Decode(s)
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	for _, s := range []string{"", s[:len(s)-4], s + "AAAA", strings.TrimRight(s, "="), "!" + s[1:]} {
+		_, err := Decode(s)
+		require.Error(t, err, s)
+	}
+
+	w := New()
+	_, _ = w.Write(data)
+	require.Equal(t, h[:], w.Sum(nil))
+}
+
+func TestTrailer(t *testing.T) {
+	h := tz.Hash(tz.Sum([]byte("data")))
+
+	line := Trailer(h)
+	require.Equal(t, "x-amz-checksum-tz:"+Encode(h), line)
+
+	for _, line := range []string{line, "X-Amz-Checksum-Tz: " + Encode(h) + " "} {
+		actual, err := ParseTrailer(line)
+		require.NoError(t, err)
+		require.Equal(t, h, actual)
+	}
+
+	for _, line := range []string{"", Encode(h), "x-amz-checksum-crc32:" + Encode(h), "x-amz-checksum-tz:"} {
+		_, err := ParseTrailer(line)
+		require.Error(t, err, line)
+	}
+}
+
+func TestComposite(t *testing.T) {
+	parts := [][]byte{[]byte("first part"), []byte("second part")}
+	hs := []tz.Hash{tz.Sum(parts[0]), tz.Sum(parts[1])}
+
+	s, err := Composite(hs)
+	require.NoError(t, err)
+
+	full := tz.Hash(tz.Sum(bytes.Join(parts, nil)))
+	require.Equal(t, Encode(full)+"-2", s)
+
+	h, n, err := ParseComposite(s)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, full, h)
+
+	_, err = Composite(nil)
+	require.Error(t, err)
+
+	for _, s := range []string{"", Encode(full), Encode(full) + "-", Encode(full) + "-0", Encode(full) + "-x", "AAAA-1"} {
+		_, _, err := ParseComposite(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	data := []byte("object data")
+
+	r, err := NewReader(bytes.NewReader(data), Encode(tz.Sum(data)))
+	require.NoError(t, err)
+	actual, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, actual)
+
+	r, err = NewReader(bytes.NewReader(data[1:]), Encode(tz.Sum(data)))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, tz.ErrChecksumMismatch)
+
+	_, err = NewReader(bytes.NewReader(data), "invalid")
+	require.Error(t, err)
+}